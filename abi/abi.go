@@ -19,6 +19,7 @@ package abi
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 
@@ -32,6 +33,12 @@ type ABI struct {
 	Constructor Method
 	Methods     map[string]Method
 	Events      map[string]Event
+	Errors      map[string]Error
+
+	// Fallback and Receive are the special functions of solidity >= 0.6,
+	// check HasFallback and HasReceive before using them.
+	Fallback Method
+	Receive  Method
 }
 
 // JSON returns a parsed ABI interface and error if it failed.
@@ -105,27 +112,61 @@ func (abi ABI) UnpackIntoMap(v map[string]interface{}, name string, data []byte)
 	return fmt.Errorf("abi: could not locate named method or event")
 }
 
+// UnpackIntoNestedMap unpacks a method output or log into the provided map,
+// converting tuples to nested map[string]interface{} values.
+func (abi ABI) UnpackIntoNestedMap(v map[string]interface{}, name string, data []byte) (err error) {
+	if method, ok := abi.Methods[name]; ok {
+		if len(data)%32 != 0 {
+			return fmt.Errorf("abi: improperly formatted output")
+		}
+		return method.Outputs.UnpackIntoNestedMap(v, data)
+	}
+	if event, ok := abi.Events[name]; ok {
+		return event.Inputs.UnpackIntoNestedMap(v, data)
+	}
+	return fmt.Errorf("abi: could not locate named method or event")
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface
 func (abi *ABI) UnmarshalJSON(data []byte) error {
 	var fields []struct {
-		Type      string
-		Name      string
-		Constant  bool
-		Anonymous bool
-		Inputs    []Argument
-		Outputs   []Argument
+		Type            string
+		Name            string
+		Constant        bool
+		Payable         bool
+		StateMutability string
+		Anonymous       bool
+		Inputs          []Argument
+		Outputs         []Argument
 	}
 	if err := json.Unmarshal(data, &fields); err != nil {
 		return err
 	}
 	abi.Methods = make(map[string]Method)
 	abi.Events = make(map[string]Event)
+	abi.Errors = make(map[string]Error)
 	for _, field := range fields {
+		method := Method{
+			RawName:         field.Name,
+			StateMutability: field.StateMutability,
+			Const:           field.Constant || field.StateMutability == "view" || field.StateMutability == "pure",
+			Payable:         field.Payable || field.StateMutability == "payable",
+			Inputs:          field.Inputs,
+			Outputs:         field.Outputs,
+		}
 		switch field.Type {
 		case "constructor":
-			abi.Constructor = Method{
-				Inputs: field.Inputs,
+			method.Type = Constructor
+			abi.Constructor = method
+		case "fallback":
+			method.Type = Fallback
+			abi.Fallback = method
+		case "receive":
+			if !method.Payable {
+				return errors.New("abi: the state mutability of receive can only be payable")
 			}
+			method.Type = Receive
+			abi.Receive = method
 		// empty defaults to function according to the abi spec
 		case "function", "":
 			name := field.Name
@@ -134,13 +175,8 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 				name = fmt.Sprintf("%s%d", field.Name, idx)
 				_, ok = abi.Methods[name]
 			}
-			abi.Methods[name] = Method{
-				Name:    name,
-				RawName: field.Name,
-				Const:   field.Constant,
-				Inputs:  field.Inputs,
-				Outputs: field.Outputs,
-			}
+			method.Name = name
+			abi.Methods[name] = method
 		case "event":
 			name := field.Name
 			_, ok := abi.Events[name]
@@ -154,12 +190,34 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 				Anonymous: field.Anonymous,
 				Inputs:    field.Inputs,
 			}
+		case "error":
+			name := field.Name
+			_, ok := abi.Errors[name]
+			for idx := 0; ok; idx++ {
+				name = fmt.Sprintf("%s%d", field.Name, idx)
+				_, ok = abi.Errors[name]
+			}
+			abi.Errors[name] = Error{
+				Name:    name,
+				RawName: field.Name,
+				Inputs:  field.Inputs,
+			}
 		}
 	}
 
 	return nil
 }
 
+// HasFallback returns whether the contract has a fallback function.
+func (abi *ABI) HasFallback() bool {
+	return abi.Fallback.Type == Fallback
+}
+
+// HasReceive returns whether the contract has a receive function.
+func (abi *ABI) HasReceive() bool {
+	return abi.Receive.Type == Receive
+}
+
 // MethodById looks up a method by the 4-byte id
 // returns nil if none found
 func (abi *ABI) MethodById(sigdata []byte) (*Method, error) {
@@ -184,3 +242,16 @@ func (abi *ABI) EventByID(topic common.Hash) (*Event, error) {
 	}
 	return nil, fmt.Errorf("no event with id: %#x", topic.Hex())
 }
+
+// ErrorByID looks up a custom error by the 4-byte selector of revert data.
+func (abi *ABI) ErrorByID(sigdata []byte) (*Error, error) {
+	if len(sigdata) < 4 {
+		return nil, fmt.Errorf("data too short (%d bytes) for abi error lookup", len(sigdata))
+	}
+	for _, e := range abi.Errors {
+		if bytes.Equal(e.ID().Bytes()[:4], sigdata[:4]) {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("no error with id: %#x", sigdata[:4])
+}
@@ -1,9 +1,16 @@
 package abi
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
 )
 
 const DATAMATCHERC1155ABI = `[
@@ -1393,3 +1400,525 @@ func TestAbi(t *testing.T) {
 	}
 
 }
+
+func TestAbiStateMutability(t *testing.T) {
+	evmABI, err := JSON(strings.NewReader(DATAMATCHERC1155ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"balanceOf", "uri", "getData", "supportsInterface"} {
+		if method := evmABI.Methods[name]; !method.IsConstant() || method.IsPayable() {
+			t.Errorf("%s: constant %v payable %v, want view", name, method.IsConstant(), method.IsPayable())
+		}
+	}
+	if method := evmABI.Methods["setData"]; method.IsConstant() || method.IsPayable() {
+		t.Errorf("setData should be nonpayable")
+	}
+	if method := evmABI.Methods["upgradeToAndCall"]; !method.IsPayable() {
+		t.Errorf("upgradeToAndCall should be payable")
+	}
+	if len(evmABI.Errors) != 32 {
+		t.Errorf("got %d errors, want 32", len(evmABI.Errors))
+	}
+	e := evmABI.Errors["InvalidTokenId"]
+	if e.Sig() != "InvalidTokenId(uint256)" {
+		t.Errorf("unexpected error signature %s", e.Sig())
+	}
+	if found, err := evmABI.ErrorByID(e.ID().Bytes()[:4]); err != nil || found.Name != e.Name {
+		t.Errorf("error lookup failed: %v", err)
+	}
+	if evmABI.HasFallback() || evmABI.HasReceive() {
+		t.Errorf("abi has no fallback or receive function")
+	}
+}
+
+func TestAbiSpecialFunctions(t *testing.T) {
+	const def = `[
+		{"type": "constructor", "stateMutability": "payable", "inputs": [{"name": "token", "type": "address", "internalType": "contract IERC20"}]},
+		{"type": "fallback", "stateMutability": "nonpayable"},
+		{"type": "receive", "stateMutability": "payable"},
+		{"type": "function", "name": "legacy", "constant": true, "inputs": [], "outputs": []}
+	]`
+	parsed, err := JSON(strings.NewReader(def))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.HasFallback() || parsed.Fallback.IsPayable() || parsed.Fallback.String() != "fallback() nonpayable" {
+		t.Errorf("unexpected fallback %v", parsed.Fallback)
+	}
+	if !parsed.HasReceive() || !parsed.Receive.IsPayable() {
+		t.Errorf("unexpected receive %v", parsed.Receive)
+	}
+	if !parsed.Constructor.IsPayable() || parsed.Constructor.Inputs[0].Type.InternalType != "contract IERC20" {
+		t.Errorf("unexpected constructor %v", parsed.Constructor)
+	}
+	if !parsed.Methods["legacy"].IsConstant() {
+		t.Errorf("legacy constant flag ignored")
+	}
+	if _, err := JSON(strings.NewReader(`[{"type": "receive", "stateMutability": "nonpayable"}]`)); err == nil {
+		t.Errorf("non-payable receive accepted")
+	}
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	const def = `[
+		{"type": "function", "name": "transfer", "stateMutability": "nonpayable", "outputs": [],
+		 "inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}]},
+		{"type": "function", "name": "note", "stateMutability": "nonpayable", "outputs": [],
+		 "inputs": [{"name": "text", "type": "string"}, {"name": "ids", "type": "uint8[]"}, {"name": "tag", "type": "bytes4"}]}
+	]`
+	parsed, err := JSON(strings.NewReader(def))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	input, err := parsed.Pack("transfer", to, new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := DecodeCalldata(&parsed, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "transfer(address to=0x00000000000000000000000000000000000000AA, uint256 amount=100000000000000000000)"; call.String() != want {
+		t.Errorf("got %s, want %s", call, want)
+	}
+	blob, _ := json.Marshal(call)
+	if want := `{"method":"transfer","signature":"transfer(address,uint256)","selector":"0xa9059cbb","args":[{"name":"to","type":"address","value":"0x00000000000000000000000000000000000000AA"},{"name":"amount","type":"uint256","value":"100000000000000000000"}]}`; string(blob) != want {
+		t.Errorf("got %s, want %s", blob, want)
+	}
+
+	input, err = parsed.Pack("note", "hi", []uint8{1, 2}, [4]byte{0xde, 0xad, 0xbe, 0xef})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call, err = DecodeCalldata(&parsed, input); err != nil {
+		t.Fatal(err)
+	}
+	if want := `note(string text="hi", uint8[] ids=[1, 2], bytes4 tag=0xdeadbeef)`; call.String() != want {
+		t.Errorf("got %s, want %s", call, want)
+	}
+	if _, err := DecodeCalldata(&parsed, input[:len(input)-1]); err == nil {
+		t.Errorf("truncated call data accepted")
+	}
+	if _, err := DecodeCalldata(&parsed, []byte{1, 2, 3, 4}); err == nil {
+		t.Errorf("unknown selector accepted")
+	}
+}
+
+func TestParseHumanReadable(t *testing.T) {
+	parsed, err := ParseHumanReadable([]string{
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"function balanceOf(address) external view returns (uint)",
+		"function swap((address tokenIn, uint256[] amounts)[2] steps, bytes calldata data) payable",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"error InsufficientBalance(uint256)",
+		"constructor(string memory name) payable",
+		"receive() external payable",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if method := parsed.Methods["transfer"]; method.Sig() != "transfer(address,uint256)" || method.IsConstant() || len(method.Outputs) != 1 {
+		t.Errorf("unexpected transfer %v", method)
+	}
+	if method := parsed.Methods["balanceOf"]; !method.IsConstant() || method.Outputs[0].Type.String() != "uint256" {
+		t.Errorf("unexpected balanceOf %v", method)
+	}
+	swap := parsed.Methods["swap"]
+	if swap.Sig() != "swap((address,uint256[])[2],bytes)" || !swap.IsPayable() || swap.Inputs[0].Name != "steps" {
+		t.Errorf("unexpected swap %v", swap)
+	}
+	if event := parsed.Events["Transfer"]; event.Sig() != "Transfer(address,address,uint256)" || !event.Inputs[1].Indexed || event.Inputs[2].Indexed {
+		t.Errorf("unexpected event %v", event)
+	}
+	if e := parsed.Errors["InsufficientBalance"]; e.Sig() != "InsufficientBalance(uint256)" {
+		t.Errorf("unexpected error %v", e)
+	}
+	if !parsed.Constructor.IsPayable() || parsed.Constructor.Inputs[0].Type.String() != "string" || !parsed.HasReceive() {
+		t.Errorf("unexpected constructor or receive")
+	}
+	want := []string{
+		"constructor(string name) payable",
+		"receive() external payable",
+		"function balanceOf(address) view returns (uint256)",
+		"function swap((address tokenIn, uint256[] amounts)[2] steps, bytes data) payable",
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"error InsufficientBalance(uint256)",
+	}
+	if got := parsed.HumanReadable(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	payable, err := ParseHumanReadable([]string{
+		"function pay(address payable to, address payable[] memory tos, address payableTo)",
+		"event Paid(address payable indexed to)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay := payable.Methods["pay"]
+	if pay.Sig() != "pay(address,address[],address)" || pay.Inputs[0].Name != "to" || pay.Inputs[1].Name != "tos" || pay.Inputs[2].Name != "payableTo" {
+		t.Errorf("unexpected pay %v", pay)
+	}
+	if event := payable.Events["Paid"]; event.Sig() != "Paid(address)" || !event.Inputs[0].Indexed {
+		t.Errorf("unexpected event %v", event)
+	}
+
+	for _, sig := range []string{
+		"function transfer(address to uint256 amount)",
+		"function f(uint256 payable x)",
+		"function f(uint256 indexed x)",
+		"event E(uint256) view",
+		"function f(uint256 a b)",
+		"function f((uint256)",
+		"foo bar baz(uint256)",
+	} {
+		if _, err := ParseHumanReadable([]string{sig}); err == nil {
+			t.Errorf("%q: expected error", sig)
+		}
+	}
+}
+
+func TestHumanReadableRoundTrip(t *testing.T) {
+	parsed, err := JSON(strings.NewReader(DATAMATCHERC1155ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := parsed.HumanReadable()
+	reparsed, err := ParseHumanReadable(sigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again := reparsed.HumanReadable(); strings.Join(again, "\n") != strings.Join(sigs, "\n") {
+		t.Errorf("round trip mismatch:\n%s\n\n%s", strings.Join(sigs, "\n"), strings.Join(again, "\n"))
+	}
+	for name, method := range parsed.Methods {
+		if reparsed.Methods[name].Sig() != method.Sig() || reparsed.Methods[name].IsConstant() != method.IsConstant() {
+			t.Errorf("method %s changed", name)
+		}
+	}
+	for name, event := range parsed.Events {
+		if reparsed.Events[name].ID() != event.ID() {
+			t.Errorf("event %s changed", name)
+		}
+	}
+	if len(reparsed.Errors) != len(parsed.Errors) {
+		t.Errorf("got %d errors, want %d", len(reparsed.Errors), len(parsed.Errors))
+	}
+}
+
+func TestEncodePacked(t *testing.T) {
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	tests := []struct {
+		types  []string
+		values []interface{}
+		want   string
+	}{
+		// Example of the Solidity documentation on non-standard packed mode.
+		{[]string{"int16", "bytes1", "uint16", "string"}, []interface{}{int16(-1), [1]byte{0x42}, uint16(3), "Hello, world!"}, "0xffff42000348656c6c6f2c20776f726c6421"},
+		{[]string{"int16", "uint48"}, []interface{}{int16(-1), big.NewInt(12)}, "0xffff00000000000c"},
+		{[]string{"string", "uint8"}, []interface{}{"Hello", uint8(3)}, "0x48656c6c6f03"},
+		{[]string{"address", "bool", "bytes"}, []interface{}{addr, true, []byte{1, 2}}, "0x00000000000000000000000000000000000000aa010102"},
+		{[]string{"int", "uint"}, []interface{}{big.NewInt(-2), big.NewInt(1)}, "0x" + strings.Repeat("ff", 31) + "fe" + strings.Repeat("00", 31) + "01"},
+		// Array elements are padded to 32 bytes.
+		{[]string{"uint8[]", "address[1]", "bool[2]", "bytes2[]"}, []interface{}{[]uint8{1, 2}, [1]common.Address{addr}, [2]bool{true, false}, [][2]byte{{0xab, 0xcd}}},
+			"0x" + strings.Repeat("00", 31) + "01" + strings.Repeat("00", 31) + "02" +
+				strings.Repeat("00", 31) + "aa" + strings.Repeat("00", 31) + "01" + strings.Repeat("00", 32) + "abcd" + strings.Repeat("00", 30)},
+		{[]string{"uint256[]"}, []interface{}{[]*big.Int{}}, "0x"},
+	}
+	for i, test := range tests {
+		packed, err := EncodePacked(test.types, test.values)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if got := hexutil.Encode(packed); got != test.want {
+			t.Errorf("test %d: got %s, want %s", i, got, test.want)
+		}
+	}
+
+	hash, err := SoliditySHA3([]string{"int16", "uint48"}, []interface{}{int16(-1), big.NewInt(12)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash.Hex() != "0x81da7abb5c9c7515f57dab2fc946f01217ab52f3bd8958bc36bd55894451a93c" {
+		t.Errorf("unexpected hash %s", hash.Hex())
+	}
+	if hash := EthSignedMessageHash([]byte("Hello World")); hash.Hex() != "0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2" {
+		t.Errorf("unexpected message hash %s", hash.Hex())
+	}
+
+	for i, test := range []struct {
+		types  []string
+		values []interface{}
+	}{
+		{[]string{"string[]"}, []interface{}{[]string{"a"}}},
+		{[]string{"uint8[][]"}, []interface{}{[][]uint8{{1}}}},
+		{[]string{"(uint256 a)"}, []interface{}{struct{ A *big.Int }{big.NewInt(1)}}},
+		{[]string{"uint72"}, []interface{}{new(big.Int).Lsh(big.NewInt(1), 72)}},
+		{[]string{"int72"}, []interface{}{new(big.Int).Lsh(big.NewInt(1), 71)}},
+		{[]string{"uint256"}, []interface{}{big.NewInt(-1)}},
+		{[]string{"uint8"}, []interface{}{uint16(1)}},
+		{[]string{"uint8", "uint8"}, []interface{}{uint8(1)}},
+	} {
+		if _, err := EncodePacked(test.types, test.values); err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	encoded, err := Encode([]string{"address", "uint", "(uint256 id, bytes data)"}, []interface{}{addr, big.NewInt(1), struct {
+		Id   *big.Int
+		Data []byte
+	}{big.NewInt(2), []byte{0xff}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0x" + strings.Repeat("00", 31) + "aa" + strings.Repeat("00", 31) + "01" + strings.Repeat("00", 31) + "60" +
+		strings.Repeat("00", 31) + "02" + strings.Repeat("00", 31) + "40" + strings.Repeat("00", 31) + "01" + "ff" + strings.Repeat("00", 31)
+	if got := hexutil.Encode(encoded); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestPackFromJSON(t *testing.T) {
+	parsed, err := ParseHumanReadable([]string{
+		"function submit(uint64 id, address to, ((address token, uint256 amount)[] items, bytes32 tag) order, bool[2] flags)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := parsed.Methods["submit"]
+	const args = `[
+		"0x10",
+		"0x00000000000000000000000000000000000000aa",
+		{"items": [{"token": "0x00000000000000000000000000000000000000bb", "amount": 1e18}, {"token": "0x00000000000000000000000000000000000000cc", "amount": "2"}], "tag": "0xabcd"},
+		[true, "false"]
+	]`
+	packed, err := PackFromJSON(method, json.RawMessage(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName, err := PackFromJSON(method, json.RawMessage(`{
+		"id": 16,
+		"to": "0x00000000000000000000000000000000000000aa",
+		"order": [[["0x00000000000000000000000000000000000000bb", "1000000000000000000"], ["0x00000000000000000000000000000000000000cc", 2]], "abcd"],
+		"flags": [true, false]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed, byName) || !bytes.Equal(packed[:4], method.ID()) {
+		t.Fatalf("positional and named arguments pack differently")
+	}
+	out, err := UnpackToJSON(method.Inputs, packed[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[16,"0x00000000000000000000000000000000000000AA",{"items":[{"amount":"1000000000000000000","token":"0x00000000000000000000000000000000000000bb"},{"amount":"2","token":"0x00000000000000000000000000000000000000cc"}],"tag":"0xabcd000000000000000000000000000000000000000000000000000000000000"},[true,false]]`
+	if string(out) != want {
+		t.Errorf("got %s, want %s", out, want)
+	}
+	if again, err := PackFromJSON(method, out); err != nil || !bytes.Equal(again, packed) {
+		t.Errorf("unpacked JSON does not pack back: %v", err)
+	}
+
+	for _, test := range []struct{ args, err string }{
+		{`[1, "0x00000000000000000000000000000000000000aa", {"items": [{"token": "0x00000000000000000000000000000000000000bb", "amount": 1}, {"token": "0x00000000000000000000000000000000000000bb", "amount": -1}], "tag": "0x"}, [true, true]]`,
+			"abi: args[2].items[1].amount (uint256): -1 out of range"},
+		{`[1, "0x00000000000000000000000000000000000000aa", {"items": [], "tag": "0x"}, [true]]`,
+			"abi: args[3] (bool[2]): expected 2 elements, got 1"},
+		{`[1, "0x00000000000000000000000000000000000000aa", {"items": [{"token": 5, "amount": 1}], "tag": "0x"}, [true, true]]`,
+			"abi: args[2].items[0].token (address): expected address, got number"},
+		{`[1, "0x00000000000000000000000000000000000000aa", {"items": []}, [true, true]]`,
+			"abi: args[2].tag: missing"},
+		{`{"id": 1, "to": "0x00000000000000000000000000000000000000aa", "order": {"items": [], "tag": "0x"}, "flags": [true, true], "extra": 1}`,
+			"abi: args.extra: unknown field"},
+		{`[1, 2]`, "abi: 4 arguments expected, got 2"},
+	} {
+		if _, err := PackFromJSON(method, json.RawMessage(test.args)); err == nil || err.Error() != test.err {
+			t.Errorf("got error %v, want %s", err, test.err)
+		}
+	}
+}
+
+func TestParseArg(t *testing.T) {
+	mustType := func(s string) Type {
+		typ, err := NewType(s, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return typ
+	}
+	for _, test := range []struct {
+		typ, arg string
+		want     interface{}
+	}{
+		{"uint8", "0xff", uint8(255)},
+		{"int16", "-300", int16(-300)},
+		{"uint256", "1.5e3", big.NewInt(1500)},
+		{"int72", "-0x10", big.NewInt(-16)},
+		{"uint256", "0100", big.NewInt(100)},
+		{"uint256", "1.2500e2", big.NewInt(125)},
+		{"uint256", "0.0e-5", big.NewInt(0)},
+		{"uint256", "1e18", new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)},
+		{"bool", "1", true},
+		{"string", " spaced ", " spaced "},
+		{"address", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", common.HexToAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")},
+		{"bytes", "0102", []byte{1, 2}},
+		{"bytes4", "0xabcd", [4]byte{0xab, 0xcd}},
+		{"uint8[]", `[1, "0x02"]`, []uint8{1, 2}},
+		{"address[1]", `["0x00000000000000000000000000000000000000aa"]`, [1]common.Address{common.HexToAddress("0xaa")}},
+	} {
+		got, err := ParseArg(mustType(test.typ), test.arg)
+		if err != nil {
+			t.Errorf("%s %q: %v", test.typ, test.arg, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %q: got %#v, want %#v", test.typ, test.arg, got, test.want)
+		}
+	}
+	for _, test := range []struct{ typ, arg string }{
+		{"uint8", "256"},
+		{"int8", "-129"},
+		{"uint256", "1.5"},
+		{"uint256", "abc"},
+		{"uint256", "0b101"},
+		{"uint256", "0o17"},
+		{"uint256", "1_000"},
+		{"uint256", "0x"},
+		{"uint256", "1." + strings.Repeat("0", 400) + "1e10"},
+		{"uint256", "1.25e1"},
+		{"uint256", "1e1000"},
+		{"int256", "0x-5"},
+		{"int256", "0x+5"},
+		{"bool", "yes"},
+		{"address", "0x5aaeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{"address", "0x1234"},
+		{"bytes2", "0x010203"},
+		{"bytes", "0xzz"},
+		{"uint8[]", `[1,`},
+	} {
+		if _, err := ParseArg(mustType(test.typ), test.arg); err == nil {
+			t.Errorf("%s %q: expected error", test.typ, test.arg)
+		}
+	}
+	values, err := ParseArgs(Arguments{{Type: mustType("uint8")}, {Type: mustType("bool[]")}}, []string{"1", `[true, "x"]`})
+	if err == nil || err.Error() != `abi: args[1][1] (bool): invalid bool "x"` {
+		t.Errorf("unexpected result %v, %v", values, err)
+	}
+}
+
+func TestUnpackNestedTuples(t *testing.T) {
+	parsed, err := ParseHumanReadable([]string{
+		"function getData() view returns ((uint256 id, address owner, (string key, uint64[] values)[] attrs)[] items, (bool ok, bytes32 tag) meta, uint8)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := parsed.Methods["getData"]
+	type attr struct {
+		Name   string   `abi:"key"`
+		Values []uint64 `abi:"values"`
+	}
+	type item struct {
+		ID     *big.Int `abi:"id"`
+		Owner  common.Address
+		Attrs  []*attr
+		Ignore string `abi:"-"`
+	}
+	type meta struct {
+		Ok  bool
+		Tag [32]byte
+	}
+	items := []item{
+		{ID: big.NewInt(1), Owner: common.HexToAddress("0xaa"), Attrs: []*attr{{Name: "color", Values: []uint64{1, 2}}}},
+		{ID: big.NewInt(2), Owner: common.HexToAddress("0xbb"), Attrs: []*attr{}},
+	}
+	type packedItem struct {
+		Id    *big.Int
+		Owner common.Address
+		Attrs []attr
+	}
+	data, err := method.Outputs.Pack([]packedItem{
+		{Id: big.NewInt(1), Owner: common.HexToAddress("0xaa"), Attrs: []attr{{Name: "color", Values: []uint64{1, 2}}}},
+		{Id: big.NewInt(2), Owner: common.HexToAddress("0xbb"), Attrs: []attr{}},
+	}, meta{Ok: true, Tag: [32]byte{0xab}}, uint8(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out struct {
+		Items []item
+		Meta  *meta
+		Field [1]uint8 `abi:"field2"`
+	}
+	if err := method.Outputs.Unpack(&out, data); err == nil || err.Error() != "struct: abi tag 'field2' in 'struct { Items []abi.item; Meta *abi.meta; Field [1]uint8 \"abi:\\\"field2\\\"\" }.Field' defined but not found in abi [items meta ]" {
+		t.Fatalf("unexpected error for unnamed output: %v", err)
+	}
+	values := make([]interface{}, 3)
+	if err := method.Outputs.Unpack(&values, data); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Items []item
+		Meta  *meta
+	}
+	if err := parsed.Unpack(&got, "getData", data); err == nil {
+		t.Fatalf("expected error for unnamed output")
+	}
+	if err := method.Outputs[:2].Unpack(&got, data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Items, items) {
+		t.Errorf("items mismatch: got %+v, want %+v", got.Items, items)
+	}
+	if got.Meta == nil || !got.Meta.Ok || got.Meta.Tag[0] != 0xab {
+		t.Errorf("meta mismatch: got %+v", got.Meta)
+	}
+
+	nested := make(map[string]interface{})
+	if err := parsed.UnpackIntoNestedMap(nested, "getData", data); err != nil {
+		t.Fatal(err)
+	}
+	first := nested["items"].([]interface{})[0].(map[string]interface{})
+	if first["id"].(*big.Int).Int64() != 1 || first["owner"] != common.HexToAddress("0xaa") {
+		t.Errorf("unexpected first item %v", first)
+	}
+	if key := first["attrs"].([]interface{})[0].(map[string]interface{})["key"]; key != "color" {
+		t.Errorf("unexpected attribute key %v", key)
+	}
+	if nested["meta"].(map[string]interface{})["ok"] != true || nested["2"] != uint8(7) {
+		t.Errorf("unexpected nested map %v", nested)
+	}
+
+	for _, test := range []struct {
+		dst interface{}
+		err string
+	}{
+		{new(struct {
+			Items []struct{ ID *big.Int }
+			Meta  meta
+		}), "abi: struct { ID *big.Int } has no field for items[0].id, add a field Id or tag one with `abi:\"id\"`"},
+		{new(struct {
+			Items []struct {
+				A *big.Int `abi:"id"`
+				B *big.Int `abi:"id"`
+			}
+			Meta meta
+		}), "struct: abi tag 'id' in 'struct { A *big.Int \"abi:\\\"id\\\"\"; B *big.Int \"abi:\\\"id\\\"\" }.B' already mapped to 'A' at items[0]"},
+		{new(struct {
+			Items []item
+			Meta  []bool
+		}), "abi: cannot unpack tuple meta into []bool, want struct or map[string]interface{}"},
+	} {
+		if err := method.Outputs[:2].Unpack(test.dst, data); err == nil || err.Error() != test.err {
+			t.Errorf("got error %v, want %s", err, test.err)
+		}
+	}
+}
@@ -20,13 +20,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
-
-	"github.com/ethclient/common/flogging"
 )
 
-var log = flogging.MustGetLogger("")
-
 // Argument holds the name of the argument and the corresponding type.
 // Types are used when packing and testing arguments.
 type Argument struct {
@@ -130,75 +127,142 @@ func (arguments Arguments) UnpackIntoMap(v map[string]interface{}, data []byte)
 	return arguments.unpackIntoMap(v, marshalledValues)
 }
 
+// UnpackIntoNestedMap performs the operation hexdata -> mapping of argument
+// name to argument value like UnpackIntoMap, but converts tuples to nested
+// maps keyed by component name and arrays of tuples to []interface{}.
+// Unnamed arguments are keyed by their index.
+func (arguments Arguments) UnpackIntoNestedMap(v map[string]interface{}, data []byte) error {
+	if v == nil {
+		return fmt.Errorf("abi: cannot unpack into a nil map")
+	}
+	if len(data) == 0 {
+		if len(arguments) != 0 {
+			return fmt.Errorf("abi: attempting to unmarshall an empty string while arguments are expected")
+		}
+		return nil
+	}
+	marshalledValues, err := arguments.UnpackValues(data)
+	if err != nil {
+		return err
+	}
+	for i, arg := range arguments.NonIndexed() {
+		name := arg.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		v[name] = nestedValue(arg.Type, reflect.ValueOf(marshalledValues[i]))
+	}
+	return nil
+}
+
 // unpack sets the unmarshalled value to go format.
-// Note the dst here must be settable.
-func unpack(t *Type, dst interface{}, src interface{}) error {
+// Note the dst here must be settable. Path locates the value in error
+// messages, e.g. items[1].amount.
+func unpack(t *Type, dst interface{}, src interface{}, path string) error {
 	var (
 		dstVal = reflect.ValueOf(dst).Elem()
 		srcVal = reflect.ValueOf(src)
 	)
-	tuple, typ := false, t
-	for {
-		if typ.T == SliceTy || typ.T == ArrayTy {
-			typ = typ.Elem
-			continue
+	if !containsTuple(*t) {
+		if err := set(dstVal, srcVal); err != nil {
+			return fmt.Errorf("%v at %s", err, path)
 		}
-		tuple = typ.T == TupleTy
-		break
-	}
-	if !tuple {
-		return set(dstVal, srcVal)
+		return nil
 	}
 
-	// Dereferences interface or pointer wrapper
+	// Dereferences interface or pointer wrapper, allocating nil pointers
 	dstVal = indirectInterfaceOrPtr(dstVal)
+	for dstVal.Kind() == reflect.Ptr {
+		if dstVal.IsNil() {
+			dstVal.Set(reflect.New(dstVal.Type().Elem()))
+		}
+		dstVal = dstVal.Elem()
+	}
+	switch {
+	case dstVal.Kind() == reflect.Interface && dstVal.NumMethod() == 0:
+		// An empty interface receives the value as is.
+		dstVal.Set(srcVal)
+		return nil
+	case t.T == TupleTy && dstVal.Type() == mapT:
+		dstVal.Set(reflect.ValueOf(nestedValue(*t, srcVal)))
+		return nil
+	}
 
 	switch t.T {
 	case TupleTy:
 		if dstVal.Kind() != reflect.Struct {
-			return fmt.Errorf("abi: invalid dst value for unpack, want struct, got %s", dstVal.Kind())
+			return fmt.Errorf("abi: cannot unpack tuple %s into %v, want struct or map[string]interface{}", path, dstVal.Type())
 		}
 		fieldmap, err := mapArgNamesToStructFields(t.TupleRawNames, dstVal)
 		if err != nil {
-			return err
+			return fmt.Errorf("%v at %s", err, path)
 		}
 		for i, elem := range t.TupleElems {
-			fname := fieldmap[t.TupleRawNames[i]]
-			field := dstVal.FieldByName(fname)
+			name := t.TupleRawNames[i]
+			field := dstVal.FieldByName(fieldmap[name])
 			if !field.IsValid() {
-				return fmt.Errorf("abi: field %s can't found in the given value", t.TupleRawNames[i])
+				return fmt.Errorf("abi: %v has no field for %s.%s, add a field %s or tag one with `abi:%q`", dstVal.Type(), path, name, ToCamelCase(name), name)
 			}
-			if err := unpack(elem, field.Addr().Interface(), srcVal.Field(i).Interface()); err != nil {
+			if err := unpack(elem, field.Addr().Interface(), srcVal.Field(i).Interface(), path+"."+name); err != nil {
 				return err
 			}
 		}
 		return nil
 	case SliceTy:
 		if dstVal.Kind() != reflect.Slice {
-			return fmt.Errorf("abi: invalid dst value for unpack, want slice, got %s", dstVal.Kind())
+			return fmt.Errorf("abi: cannot unpack %s %s into %v, want slice", t, path, dstVal.Type())
 		}
 		slice := reflect.MakeSlice(dstVal.Type(), srcVal.Len(), srcVal.Len())
 		for i := 0; i < slice.Len(); i++ {
-			if err := unpack(t.Elem, slice.Index(i).Addr().Interface(), srcVal.Index(i).Interface()); err != nil {
+			if err := unpack(t.Elem, slice.Index(i).Addr().Interface(), srcVal.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
 				return err
 			}
 		}
 		dstVal.Set(slice)
 	case ArrayTy:
-		if dstVal.Kind() != reflect.Array {
-			return fmt.Errorf("abi: invalid dst value for unpack, want array, got %s", dstVal.Kind())
+		if dstVal.Kind() == reflect.Slice {
+			dstVal.Set(reflect.MakeSlice(dstVal.Type(), t.Size, t.Size))
+		} else if dstVal.Kind() != reflect.Array || dstVal.Len() != t.Size {
+			return fmt.Errorf("abi: cannot unpack %s %s into %v, want array of %d elements or slice", t, path, dstVal.Type(), t.Size)
 		}
-		array := reflect.New(dstVal.Type()).Elem()
-		for i := 0; i < array.Len(); i++ {
-			if err := unpack(t.Elem, array.Index(i).Addr().Interface(), srcVal.Index(i).Interface()); err != nil {
+		for i := 0; i < t.Size; i++ {
+			if err := unpack(t.Elem, dstVal.Index(i).Addr().Interface(), srcVal.Index(i).Interface(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
 				return err
 			}
 		}
-		dstVal.Set(array)
 	}
 	return nil
 }
 
+// containsTuple returns whether t is a tuple or an array of tuples.
+func containsTuple(t Type) bool {
+	for t.T == SliceTy || t.T == ArrayTy {
+		t = *t.Elem
+	}
+	return t.T == TupleTy
+}
+
+// nestedValue converts tuples in v, which has type t, to maps keyed by
+// component name and arrays of tuples to []interface{}. Other values are
+// returned as is.
+func nestedValue(t Type, v reflect.Value) interface{} {
+	if !containsTuple(t) {
+		return v.Interface()
+	}
+	if t.T == TupleTy {
+		out := make(map[string]interface{}, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			out[t.TupleRawNames[i]] = nestedValue(*elem, v.Field(i))
+		}
+		return out
+	}
+	out := make([]interface{}, v.Len())
+	for i := range out {
+		out[i] = nestedValue(*t.Elem, v.Index(i))
+	}
+	return out
+}
+
 // unpackIntoMap unpacks marshalledValues into the provided map[string]interface{}
 func (arguments Arguments) unpackIntoMap(v map[string]interface{}, marshalledValues []interface{}) error {
 	// Make sure map is not nil
@@ -227,12 +291,11 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues interfac
 		}
 		field := elem.FieldByName(fieldmap[argument.Name])
 		if !field.IsValid() {
-			log.Error("unpackAtomic error")
-			return fmt.Errorf("abi: field %s can't be found in the given value", argument.Name)
+			return fmt.Errorf("abi: %v has no field for %s, add a field %s or tag one with `abi:%q`", elem.Type(), argument.Name, ToCamelCase(argument.Name), argument.Name)
 		}
-		return unpack(&argument.Type, field.Addr().Interface(), marshalledValues)
+		return unpack(&argument.Type, field.Addr().Interface(), marshalledValues, argumentPath(argument, 0))
 	}
-	return unpack(&argument.Type, elem.Addr().Interface(), marshalledValues)
+	return unpack(&argument.Type, elem.Addr().Interface(), marshalledValues, argumentPath(argument, 0))
 }
 
 // unpackTuple unpacks ( hexdata -> go ) a batch of values.
@@ -266,9 +329,9 @@ func (arguments Arguments) unpackTuple(v interface{}, marshalledValues []interfa
 		case reflect.Struct:
 			field := value.FieldByName(abi2struct[arg.Name])
 			if !field.IsValid() {
-				return fmt.Errorf("abi: field %s can't be found in the given value", arg.Name)
+				return fmt.Errorf("abi: %v has no field for %s, add a field %s or tag one with `abi:%q`", typ, arg.Name, ToCamelCase(arg.Name), arg.Name)
 			}
-			if err := unpack(&arg.Type, field.Addr().Interface(), marshalledValues[i]); err != nil {
+			if err := unpack(&arg.Type, field.Addr().Interface(), marshalledValues[i], argumentPath(arg, i)); err != nil {
 				return err
 			}
 		case reflect.Slice, reflect.Array:
@@ -279,7 +342,7 @@ func (arguments Arguments) unpackTuple(v interface{}, marshalledValues []interfa
 			if err := requireAssignable(v, reflect.ValueOf(marshalledValues[i])); err != nil {
 				return err
 			}
-			if err := unpack(&arg.Type, v.Addr().Interface(), marshalledValues[i]); err != nil {
+			if err := unpack(&arg.Type, v.Addr().Interface(), marshalledValues[i], argumentPath(arg, i)); err != nil {
 				return err
 			}
 		default:
@@ -290,6 +353,14 @@ func (arguments Arguments) unpackTuple(v interface{}, marshalledValues []interfa
 
 }
 
+// argumentPath names the i-th argument in error messages.
+func argumentPath(arg Argument, i int) string {
+	if arg.Name == "" {
+		return fmt.Sprintf("[%d]", i)
+	}
+	return arg.Name
+}
+
 // UnpackValues can be used to unpack ABI-encoded hexdata according to the ABI-specification,
 // without supplying a struct to unpack into. Instead, this method returns a list containing the
 // values. An atomic argument will be a list with one element.
@@ -0,0 +1,179 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+)
+
+// DecodedArgument is a method argument decoded from call data.
+type DecodedArgument struct {
+	Name  string
+	Type  Type
+	Value interface{}
+}
+
+// JSONValue returns the value in a form suitable for JSON encoding: integers
+// of more than 64 bits become decimal strings, addresses, hashes and byte
+// strings become hex, tuples become objects keyed by component name.
+func (arg DecodedArgument) JSONValue() interface{} {
+	return jsonValue(arg.Type, reflect.ValueOf(arg.Value))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (arg DecodedArgument) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name  string      `json:"name"`
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	}{arg.Name, arg.Type.String(), arg.JSONValue()})
+}
+
+// String renders the argument as "type name=value".
+func (arg DecodedArgument) String() string {
+	value := humanValue(arg.Type, arg.JSONValue())
+	if arg.Name == "" {
+		return fmt.Sprintf("%v %s", arg.Type, value)
+	}
+	return fmt.Sprintf("%v %s=%s", arg.Type, arg.Name, value)
+}
+
+// DecodedCall is a method call decoded from transaction input.
+type DecodedCall struct {
+	Method Method
+	Args   []DecodedArgument
+}
+
+// DecodeCalldata looks up the method of the transaction input by its 4-byte
+// selector and decodes the arguments in declaration order.
+func DecodeCalldata(abi *ABI, input []byte) (*DecodedCall, error) {
+	method, err := abi.MethodById(input)
+	if err != nil {
+		return nil, err
+	}
+	if (len(input)-4)%32 != 0 {
+		return nil, fmt.Errorf("abi: call data of %s is not a multiple of 32 bytes", method.RawName)
+	}
+	values, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	call := &DecodedCall{Method: *method, Args: make([]DecodedArgument, len(method.Inputs))}
+	for i, input := range method.Inputs {
+		call.Args[i] = DecodedArgument{Name: input.Name, Type: input.Type, Value: values[i]}
+	}
+	return call, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (call *DecodedCall) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Method    string            `json:"method"`
+		Signature string            `json:"signature"`
+		Selector  hexutil.Bytes     `json:"selector"`
+		Args      []DecodedArgument `json:"args"`
+	}{call.Method.RawName, call.Method.Sig(), call.Method.ID(), call.Args})
+}
+
+// String renders the call as "name(type name=value, ...)".
+func (call *DecodedCall) String() string {
+	args := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", call.Method.RawName, strings.Join(args, ", "))
+}
+
+func jsonValue(t Type, v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		if _, ok := v.Interface().(*big.Int); !ok {
+			return jsonValue(t, v.Elem())
+		}
+	}
+	switch t.T {
+	case IntTy, UintTy:
+		if n, ok := v.Interface().(*big.Int); ok {
+			return n.String()
+		}
+		return v.Interface()
+	case AddressTy:
+		if addr, ok := v.Interface().(common.Address); ok {
+			return addr.Hex()
+		}
+	case BytesTy, FixedBytesTy, HashTy, FunctionTy:
+		if v.Kind() == reflect.Slice {
+			return hexutil.Encode(v.Bytes())
+		}
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	case SliceTy, ArrayTy:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = jsonValue(*t.Elem, v.Index(i))
+		}
+		return out
+	case TupleTy:
+		out := make(map[string]interface{}, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			out[t.TupleRawNames[i]] = jsonValue(*elem, v.Field(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// humanValue renders a value returned by jsonValue.
+func humanValue(t Type, v interface{}) string {
+	switch t.T {
+	case StringTy:
+		if s, ok := v.(string); ok {
+			return strconv.Quote(s)
+		}
+	case SliceTy, ArrayTy:
+		if elems, ok := v.([]interface{}); ok {
+			out := make([]string, len(elems))
+			for i, elem := range elems {
+				out[i] = humanValue(*t.Elem, elem)
+			}
+			return "[" + strings.Join(out, ", ") + "]"
+		}
+	case TupleTy:
+		if fields, ok := v.(map[string]interface{}); ok {
+			out := make([]string, len(t.TupleElems))
+			for i, elem := range t.TupleElems {
+				out[i] = t.TupleRawNames[i] + ": " + humanValue(*elem, fields[t.TupleRawNames[i]])
+			}
+			return "(" + strings.Join(out, ", ") + ")"
+		}
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,459 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+)
+
+// ArgumentError is returned when a JSON or string value cannot be converted
+// to its ABI type. Path locates the value, e.g. args[2].items[1].amount.
+type ArgumentError struct {
+	Path string
+	Type string
+	Err  error
+}
+
+func (e *ArgumentError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("abi: %s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("abi: %s (%s): %v", e.Path, e.Type, e.Err)
+}
+
+// ParseArg converts s to a Go value of type t as accepted by Pack. Scalars
+// are given as plain strings:
+//
+//   - integers in decimal, 0x-prefixed hex or exact scientific notation (1e18)
+//   - bool as true/false or 1/0
+//   - address, bytes, bytesN and function as hex, the 0x prefix is optional;
+//     mixed-case addresses must have a valid EIP-55 checksum, bytesN values
+//     shorter than N bytes are right-padded
+//
+// Arrays and tuples are given as JSON. Array elements are JSON values of the
+// above forms, numbers may be JSON numbers; tuples are objects keyed by
+// component name or arrays in component order.
+func ParseArg(t Type, s string) (interface{}, error) {
+	v, err := coerce(t, s, "arg")
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// ParseArgs converts string arguments with ParseArg. Errors are located as
+// args[i].
+func ParseArgs(inputs Arguments, params []string) ([]interface{}, error) {
+	if len(params) != len(inputs) {
+		return nil, fmt.Errorf("abi: %d arguments expected, got %d", len(inputs), len(params))
+	}
+	values := make([]interface{}, len(params))
+	for i, input := range inputs {
+		v, err := coerce(input.Type, params[i], fmt.Sprintf("args[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v.Interface()
+	}
+	return values, nil
+}
+
+// ValuesFromJSON converts JSON arguments to Go values as accepted by Pack.
+// The arguments are a JSON array in declaration order or an object keyed by
+// argument name; the values take the forms described at ParseArg, scalars
+// may also be JSON numbers and booleans.
+func (arguments Arguments) ValuesFromJSON(data json.RawMessage) ([]interface{}, error) {
+	decoded, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("abi: invalid arguments: %v", err)
+	}
+	values := make([]interface{}, len(arguments))
+	switch args := decoded.(type) {
+	case []interface{}:
+		if len(args) != len(arguments) {
+			return nil, fmt.Errorf("abi: %d arguments expected, got %d", len(arguments), len(args))
+		}
+		for i, arg := range arguments {
+			v, err := coerce(arg.Type, args[i], fmt.Sprintf("args[%d]", i))
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v.Interface()
+		}
+	case map[string]interface{}:
+		for i, arg := range arguments {
+			if arg.Name == "" {
+				return nil, fmt.Errorf("abi: argument %d has no name, pass the arguments as array", i)
+			}
+		}
+		if err := checkKeys(args, arguments.names(), "args"); err != nil {
+			return nil, err
+		}
+		for i, arg := range arguments {
+			v, err := coerce(arg.Type, args[arg.Name], "args."+arg.Name)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v.Interface()
+		}
+	case nil:
+		if len(arguments) != 0 {
+			return nil, fmt.Errorf("abi: %d arguments expected, got none", len(arguments))
+		}
+	default:
+		return nil, errors.New("abi: arguments must be a JSON array or object")
+	}
+	return values, nil
+}
+
+// PackFromJSON packs a call of method with arguments given as JSON, see
+// Arguments.ValuesFromJSON. The result starts with the method selector,
+// except for the constructor.
+func PackFromJSON(method Method, data json.RawMessage) ([]byte, error) {
+	values, err := method.Inputs.ValuesFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+	if method.Type == Constructor {
+		return packed, nil
+	}
+	return append(method.ID(), packed...), nil
+}
+
+// UnpackToJSON unpacks data, e.g. the output of a call, into a JSON array in
+// argument order. Integers wider than 64 bits are decimal strings, addresses,
+// bytes and bytesN are hex and tuples are objects keyed by component name,
+// which ValuesFromJSON reads back.
+func UnpackToJSON(arguments Arguments, data []byte) (json.RawMessage, error) {
+	values, err := arguments.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(values))
+	for i, arg := range arguments.NonIndexed() {
+		out[i] = jsonValue(arg.Type, reflect.ValueOf(values[i]))
+	}
+	return json.Marshal(out)
+}
+
+func (arguments Arguments) names() []string {
+	names := make([]string, len(arguments))
+	for i, arg := range arguments {
+		names[i] = arg.Name
+	}
+	return names
+}
+
+// decodeJSON decodes data keeping numbers as json.Number.
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+// checkKeys verifies that the object has exactly the given keys.
+func checkKeys(object map[string]interface{}, names []string, path string) error {
+	known := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := object[name]; !ok {
+			return &ArgumentError{Path: path + "." + name, Err: errors.New("missing")}
+		}
+		known[name] = true
+	}
+	for key := range object {
+		if !known[key] {
+			return &ArgumentError{Path: path + "." + key, Err: errors.New("unknown field")}
+		}
+	}
+	return nil
+}
+
+// coerce converts a value decoded from JSON to a reflect value of type t.
+func coerce(t Type, value interface{}, path string) (reflect.Value, error) {
+	fail := func(format string, args ...interface{}) (reflect.Value, error) {
+		return reflect.Value{}, &ArgumentError{Path: path, Type: t.String(), Err: fmt.Errorf(format, args...)}
+	}
+	switch t.T {
+	case IntTy, UintTy:
+		var s string
+		switch v := value.(type) {
+		case string:
+			s = strings.TrimSpace(v)
+		case json.Number:
+			s = v.String()
+		default:
+			return fail("expected integer, got %s", jsonKind(value))
+		}
+		n, err := ParseInteger(s)
+		if err != nil {
+			return fail("%v", err)
+		}
+		if t.T == UintTy && (n.Sign() < 0 || n.BitLen() > t.Size) {
+			return fail("%s out of range", s)
+		}
+		if t.T == IntTy {
+			limit := new(big.Int).Lsh(common.Big1, uint(t.Size-1))
+			if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+				return fail("%s out of range", s)
+			}
+		}
+		if t.Type == reflect.TypeOf(n) {
+			return reflect.ValueOf(n), nil
+		}
+		v := reflect.New(t.Type).Elem()
+		if t.T == UintTy {
+			v.SetUint(n.Uint64())
+		} else {
+			v.SetInt(n.Int64())
+		}
+		return v, nil
+
+	case BoolTy:
+		switch v := value.(type) {
+		case bool:
+			return reflect.ValueOf(v), nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fail("invalid bool %q", v)
+			}
+			return reflect.ValueOf(b), nil
+		}
+		return fail("expected bool, got %s", jsonKind(value))
+
+	case StringTy:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s), nil
+		}
+		return fail("expected string, got %s", jsonKind(value))
+
+	case AddressTy:
+		s, ok := value.(string)
+		if !ok {
+			return fail("expected address, got %s", jsonKind(value))
+		}
+		s = strings.TrimSpace(s)
+		if !common.IsHexAddress(s) {
+			return fail("invalid address %q", s)
+		}
+		addr := common.HexToAddress(s)
+		if hex := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"); hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && "0x"+hex != addr.Hex() {
+			return fail("invalid address checksum %q", s)
+		}
+		return reflect.ValueOf(addr), nil
+
+	case BytesTy, FixedBytesTy, FunctionTy:
+		s, ok := value.(string)
+		if !ok {
+			return fail("expected hex string, got %s", jsonKind(value))
+		}
+		s = strings.TrimSpace(s)
+		if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+			s = "0x" + s
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return fail("%v", err)
+		}
+		if t.T == BytesTy {
+			return reflect.ValueOf(b), nil
+		}
+		size := t.Size
+		if t.T == FunctionTy {
+			size = 24
+		}
+		if len(b) > size || (t.T == FunctionTy && len(b) != size) {
+			return fail("%d bytes do not fit", len(b))
+		}
+		v := reflect.New(t.Type).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+
+	case SliceTy, ArrayTy:
+		if s, ok := value.(string); ok {
+			decoded, err := decodeJSON([]byte(s))
+			if err != nil {
+				return fail("expected JSON array: %v", err)
+			}
+			value = decoded
+		}
+		elems, ok := value.([]interface{})
+		if !ok {
+			return fail("expected array, got %s", jsonKind(value))
+		}
+		var v reflect.Value
+		if t.T == SliceTy {
+			v = reflect.MakeSlice(t.Type, len(elems), len(elems))
+		} else {
+			if len(elems) != t.Size {
+				return fail("expected %d elements, got %d", t.Size, len(elems))
+			}
+			v = reflect.New(t.Type).Elem()
+		}
+		for i, elem := range elems {
+			ev, err := coerce(*t.Elem, elem, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(ev)
+		}
+		return v, nil
+
+	case TupleTy:
+		if s, ok := value.(string); ok {
+			decoded, err := decodeJSON([]byte(s))
+			if err != nil {
+				return fail("expected JSON object: %v", err)
+			}
+			value = decoded
+		}
+		v := reflect.New(t.Type).Elem()
+		switch fields := value.(type) {
+		case map[string]interface{}:
+			if err := checkKeys(fields, t.TupleRawNames, path); err != nil {
+				return reflect.Value{}, err
+			}
+			for i, elem := range t.TupleElems {
+				name := t.TupleRawNames[i]
+				fv, err := coerce(*elem, fields[name], path+"."+name)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Field(i).Set(fv)
+			}
+		case []interface{}:
+			if len(fields) != len(t.TupleElems) {
+				return fail("expected %d components, got %d", len(t.TupleElems), len(fields))
+			}
+			for i, elem := range t.TupleElems {
+				fv, err := coerce(*elem, fields[i], path+"."+t.TupleRawNames[i])
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Field(i).Set(fv)
+			}
+		default:
+			return fail("expected object, got %s", jsonKind(value))
+		}
+		return v, nil
+	}
+	return fail("unsupported type")
+}
+
+var (
+	decimalRegex    = regexp.MustCompile(`^[0-9]+$`)
+	hexRegex        = regexp.MustCompile(`^0[xX][0-9a-fA-F]+$`)
+	scientificRegex = regexp.MustCompile(`^([0-9]+)(?:\.([0-9]+))?[eE]([+-]?[0-9]+)$`)
+)
+
+// maxExponent bounds the decimal exponent of scientific notation, larger
+// values do not fit any abi integer type.
+const maxExponent = 100
+
+// ParseInteger parses a decimal, 0x-prefixed hex or scientific notation
+// integer. Leading zeros are decimal, not octal, and other base prefixes and
+// digit separators are rejected.
+func ParseInteger(s string) (*big.Int, error) {
+	digits, neg := s, false
+	if strings.HasPrefix(digits, "-") {
+		digits, neg = digits[1:], true
+	}
+	var (
+		n  *big.Int
+		ok bool
+	)
+	switch {
+	case hexRegex.MatchString(digits):
+		n, ok = new(big.Int).SetString(digits[2:], 16)
+	case decimalRegex.MatchString(digits):
+		n, ok = new(big.Int).SetString(digits, 10)
+	case scientificRegex.MatchString(digits):
+		n, ok = parseScientific(digits)
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+// parseScientific parses scientific notation exactly, it fails unless the
+// value is an integer.
+func parseScientific(s string) (*big.Int, bool) {
+	m := scientificRegex.FindStringSubmatch(s)
+	exp, err := strconv.Atoi(m[3])
+	if err != nil {
+		return nil, false
+	}
+	// mantissa * 10^exp, with the fraction digits moved into the mantissa
+	mantissa := strings.TrimLeft(m[1]+m[2], "0")
+	if mantissa == "" {
+		return new(big.Int), true
+	}
+	exp -= len(m[2])
+	for exp < 0 && strings.HasSuffix(mantissa, "0") {
+		mantissa, exp = mantissa[:len(mantissa)-1], exp+1
+	}
+	if exp < 0 || exp > maxExponent {
+		return nil, false
+	}
+	n, _ := new(big.Int).SetString(mantissa, 10)
+	return n.Mul(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)), true
+}
+
+// jsonKind names the JSON type of a decoded value for error messages.
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
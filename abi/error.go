@@ -17,15 +17,63 @@
 package abi
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
 )
 
 var (
 	errBadBool = errors.New("abi: improperly encoded boolean value")
 )
 
+// Error is a custom error declared in the contract ABI (solidity >= 0.8.4).
+// Reverts with a custom error return its selector followed by the ABI
+// encoded inputs.
+type Error struct {
+	// Name is the error name used for internal representation, a suffix is
+	// added to overloaded errors just like for methods.
+	Name string
+	// RawName is the raw error name parsed from ABI.
+	RawName string
+	Inputs  Arguments
+}
+
+func (e Error) String() string {
+	inputs := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		inputs[i] = fmt.Sprintf("%v %v", input.Type, input.Name)
+	}
+	return fmt.Sprintf("error %v(%v)", e.RawName, strings.Join(inputs, ", "))
+}
+
+// Sig returns the error string signature according to the ABI spec.
+func (e Error) Sig() string {
+	types := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		types[i] = input.Type.String()
+	}
+	return fmt.Sprintf("%v(%v)", e.RawName, strings.Join(types, ","))
+}
+
+// ID returns the hash of the error signature, its first 4 bytes are the
+// selector of the revert data.
+func (e Error) ID() common.Hash {
+	return common.BytesToHash(crypto.Keccak256([]byte(e.Sig())))
+}
+
+// Unpack decodes the inputs of the error from revert data.
+func (e Error) Unpack(data []byte) ([]interface{}, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], e.ID().Bytes()[:4]) {
+		return nil, fmt.Errorf("abi: revert data is not a %s error", e.RawName)
+	}
+	return e.Inputs.UnpackValues(data[4:])
+}
+
 // formatSliceString formats the reflection kind with the given slice size
 // and returns a formatted string representation.
 func formatSliceString(kind reflect.Kind, sliceSize int) string {
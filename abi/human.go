@@ -0,0 +1,389 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// humanArgument and humanEntry mirror the JSON ABI format.
+type humanArgument struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Components []humanArgument `json:"components,omitempty"`
+	Indexed    bool            `json:"indexed,omitempty"`
+}
+
+type humanEntry struct {
+	Type            string          `json:"type"`
+	Name            string          `json:"name,omitempty"`
+	Inputs          []humanArgument `json:"inputs"`
+	Outputs         []humanArgument `json:"outputs,omitempty"`
+	StateMutability string          `json:"stateMutability,omitempty"`
+	Anonymous       bool            `json:"anonymous,omitempty"`
+}
+
+// ParseHumanReadable parses an ABI from human-readable signatures, e.g.
+//
+//	function transfer(address to, uint256 amount) returns (bool)
+//	function balanceOf(address) view returns (uint256)
+//	function swap((address tokenIn, uint256 amountIn)[] steps) payable
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//	error InsufficientBalance(uint256)
+//	constructor(string name) payable
+//	fallback() external
+//	receive() external payable
+//
+// Signatures without a keyword are functions. Parameter names are optional,
+// unnamed tuple components are named field0, field1, ... since tuples are
+// unpacked into structs. See HumanReadableJSON for the accepted syntax.
+func ParseHumanReadable(signatures []string) (ABI, error) {
+	data, err := HumanReadableJSON(signatures)
+	if err != nil {
+		return ABI{}, err
+	}
+	return JSON(bytes.NewReader(data))
+}
+
+// HumanReadableJSON converts human-readable signatures into a JSON ABI
+// document. Visibility, data location and virtual/override specifiers are
+// accepted and dropped, uint and int are read as uint256 and int256, and a
+// trailing semicolon is ignored.
+func HumanReadableJSON(signatures []string) ([]byte, error) {
+	entries := make([]humanEntry, 0, len(signatures))
+	for _, sig := range signatures {
+		sig = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sig), ";"))
+		if sig == "" {
+			continue
+		}
+		entry, err := parseHumanEntry(sig)
+		if err != nil {
+			return nil, fmt.Errorf("abi: %v in %q", err, sig)
+		}
+		entries = append(entries, entry)
+	}
+	return json.Marshal(entries)
+}
+
+func parseHumanEntry(sig string) (humanEntry, error) {
+	var entry humanEntry
+
+	open := strings.IndexByte(sig, '(')
+	if open < 0 {
+		return entry, fmt.Errorf("missing parameter list")
+	}
+	head := strings.Fields(sig[:open])
+	switch {
+	case len(head) == 1 && isHumanKeyword(head[0]):
+		entry.Type = head[0]
+	case len(head) == 1:
+		entry.Type, entry.Name = "function", head[0]
+	case len(head) == 2 && (head[0] == "function" || head[0] == "event" || head[0] == "error"):
+		entry.Type, entry.Name = head[0], head[1]
+	default:
+		return entry, fmt.Errorf("invalid declaration %q", sig[:open])
+	}
+	if entry.Name != "" && !identifierRegex.MatchString(entry.Name) {
+		return entry, fmt.Errorf("invalid name %q", entry.Name)
+	}
+	end, err := closingParen(sig, open)
+	if err != nil {
+		return entry, err
+	}
+	if entry.Inputs, err = parseHumanArguments(sig[open+1 : end]); err != nil {
+		return entry, err
+	}
+	rest := strings.TrimSpace(sig[end+1:])
+
+	// Modifiers up to an optional returns clause.
+	var modifiers []string
+	if i := strings.Index(rest, "returns"); i >= 0 {
+		modifiers = strings.Fields(rest[:i])
+		returns := strings.TrimSpace(rest[i+len("returns"):])
+		if !strings.HasPrefix(returns, "(") {
+			return entry, fmt.Errorf("invalid returns clause")
+		}
+		end, err := closingParen(returns, 0)
+		if err != nil {
+			return entry, err
+		}
+		if strings.TrimSpace(returns[end+1:]) != "" {
+			return entry, fmt.Errorf("unexpected %q after returns clause", returns[end+1:])
+		}
+		if entry.Type != "function" && entry.Type != "fallback" {
+			return entry, fmt.Errorf("%s cannot return values", entry.Type)
+		}
+		if entry.Outputs, err = parseHumanArguments(returns[1:end]); err != nil {
+			return entry, err
+		}
+	} else {
+		modifiers = strings.Fields(rest)
+	}
+	for _, modifier := range modifiers {
+		switch {
+		case modifier == "anonymous" && entry.Type == "event":
+			entry.Anonymous = true
+		case modifier == "view" || modifier == "pure" || modifier == "payable" || modifier == "nonpayable":
+			if entry.Type == "event" || entry.Type == "error" {
+				return entry, fmt.Errorf("%s cannot be %s", entry.Type, modifier)
+			}
+			entry.StateMutability = modifier
+		case modifier == "constant":
+			entry.StateMutability = "view"
+		case modifier == "external" || modifier == "public" || modifier == "virtual" || strings.HasPrefix(modifier, "override"):
+		default:
+			return entry, fmt.Errorf("unexpected %q", modifier)
+		}
+	}
+
+	if entry.Type != "event" {
+		for _, input := range entry.Inputs {
+			if input.Indexed {
+				return entry, fmt.Errorf("indexed parameter outside event")
+			}
+		}
+	}
+	if entry.Type != "event" && entry.Type != "error" {
+		if entry.StateMutability == "" {
+			entry.StateMutability = "nonpayable"
+		}
+		if entry.Type == "fallback" || entry.Type == "receive" {
+			// The input and output of fallback(bytes) returns (bytes) are
+			// not part of the JSON ABI.
+			entry.Inputs, entry.Outputs = nil, nil
+		}
+	}
+	if entry.Inputs == nil {
+		entry.Inputs = []humanArgument{}
+	}
+	return entry, nil
+}
+
+func isHumanKeyword(word string) bool {
+	switch word {
+	case "constructor", "fallback", "receive":
+		return true
+	}
+	return false
+}
+
+var (
+	identifierRegex = regexp.MustCompile(`^[a-zA-Z$_][a-zA-Z0-9$_]*$`)
+	// intAliasRegex matches uint and int without a size, also as array element.
+	intAliasRegex = regexp.MustCompile(`^(u?int)(\[|$)`)
+)
+
+// parseHumanArguments parses a comma separated parameter list.
+func parseHumanArguments(list string) ([]humanArgument, error) {
+	args := []humanArgument{}
+	if strings.TrimSpace(list) == "" {
+		return args, nil
+	}
+	depth, start := 0, 0
+	for i := 0; i <= len(list); i++ {
+		if i < len(list) {
+			switch list[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if list[i] != ',' || depth > 0 {
+				continue
+			}
+		}
+		arg, err := parseHumanArgument(strings.TrimSpace(list[start:i]))
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		start = i + 1
+	}
+	return args, nil
+}
+
+// parseHumanArgument parses "type [payable] [indexed] [location] [name]", where a
+// tuple type is a parenthesised parameter list, optionally prefixed by
+// "tuple" and followed by array dimensions.
+func parseHumanArgument(s string) (humanArgument, error) {
+	var (
+		arg  humanArgument
+		rest string
+	)
+	if strings.HasPrefix(s, "tuple(") {
+		s = s[len("tuple"):]
+	}
+	if strings.HasPrefix(s, "(") {
+		end, err := closingParen(s, 0)
+		if err != nil {
+			return arg, err
+		}
+		if arg.Components, err = parseHumanArguments(s[1:end]); err != nil {
+			return arg, err
+		}
+		for i := range arg.Components {
+			if arg.Components[i].Name == "" {
+				arg.Components[i].Name = fmt.Sprintf("field%d", i)
+			}
+		}
+		rest = s[end+1:]
+		dims := len(rest) - len(strings.TrimLeft(rest, "[]0123456789"))
+		arg.Type, rest = "tuple"+rest[:dims], rest[dims:]
+	} else {
+		fields := strings.Fields(s)
+		if len(fields) == 0 {
+			return arg, fmt.Errorf("empty parameter")
+		}
+		arg.Type = intAliasRegex.ReplaceAllString(fields[0], "${1}256$2")
+		// "address payable" and "address payable[]" encode as address
+		if arg.Type == "address" && len(fields) > 1 && (fields[1] == "payable" || strings.HasPrefix(fields[1], "payable[")) {
+			arg.Type += strings.TrimPrefix(fields[1], "payable")
+			fields = fields[1:]
+		}
+		rest = strings.Join(fields[1:], " ")
+	}
+	fields := strings.Fields(rest)
+	if len(fields) > 0 && fields[0] == "indexed" {
+		arg.Indexed, fields = true, fields[1:]
+	}
+	if len(fields) > 0 && (fields[0] == "memory" || fields[0] == "calldata" || fields[0] == "storage") {
+		fields = fields[1:]
+	}
+	switch {
+	case len(fields) == 1 && identifierRegex.MatchString(fields[0]):
+		arg.Name = fields[0]
+	case len(fields) > 0:
+		return arg, fmt.Errorf("invalid parameter %q", s)
+	}
+	return arg, nil
+}
+
+// closingParen returns the index of the parenthesis closing the one at open.
+func closingParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses")
+}
+
+// HumanReadable returns the ABI as human-readable signatures which
+// ParseHumanReadable reads back: the constructor, fallback and receive
+// functions followed by the functions, events and errors ordered by name.
+func (abi ABI) HumanReadable() []string {
+	var out []string
+	if abi.Constructor.Type == Constructor {
+		out = append(out, "constructor("+humanArguments(abi.Constructor.Inputs)+")"+humanMutability(abi.Constructor))
+	}
+	if abi.HasFallback() {
+		out = append(out, "fallback() external"+humanMutability(abi.Fallback))
+	}
+	if abi.HasReceive() {
+		out = append(out, "receive() external payable")
+	}
+	names := make([]string, 0, len(abi.Methods))
+	for name := range abi.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		method := abi.Methods[name]
+		sig := "function " + method.RawName + "(" + humanArguments(method.Inputs) + ")" + humanMutability(method)
+		if len(method.Outputs) > 0 {
+			sig += " returns (" + humanArguments(method.Outputs) + ")"
+		}
+		out = append(out, sig)
+	}
+	names = names[:0]
+	for name := range abi.Events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event := abi.Events[name]
+		sig := "event " + event.RawName + "(" + humanArguments(event.Inputs) + ")"
+		if event.Anonymous {
+			sig += " anonymous"
+		}
+		out = append(out, sig)
+	}
+	names = names[:0]
+	for name := range abi.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		e := abi.Errors[name]
+		out = append(out, "error "+e.RawName+"("+humanArguments(e.Inputs)+")")
+	}
+	return out
+}
+
+// humanMutability returns the state mutability of method as a modifier,
+// which is empty for nonpayable methods.
+func humanMutability(method Method) string {
+	switch mutability := method.mutability(); mutability {
+	case "", "nonpayable":
+		return ""
+	default:
+		return " " + mutability
+	}
+}
+
+func humanArguments(args Arguments) string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = humanType(arg.Type)
+		if arg.Indexed {
+			out[i] += " indexed"
+		}
+		if arg.Name != "" {
+			out[i] += " " + arg.Name
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// humanType formats t like its canonical type string, but with the names of
+// tuple components.
+func humanType(t Type) string {
+	switch t.T {
+	case TupleTy:
+		out := make([]string, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			out[i] = humanType(*elem) + " " + t.TupleRawNames[i]
+		}
+		return "(" + strings.Join(out, ", ") + ")"
+	case SliceTy:
+		return humanType(*t.Elem) + "[]"
+	case ArrayTy:
+		return fmt.Sprintf("%s[%d]", humanType(*t.Elem), t.Size)
+	}
+	return t.String()
+}
@@ -23,6 +23,22 @@ import (
 	"github.com/ethclient/crypto"
 )
 
+// FunctionType represents the different kinds of functions a contract might have.
+type FunctionType int
+
+const (
+	// Function represents a normal function.
+	Function FunctionType = iota
+	// Constructor represents the constructor of the contract.
+	Constructor
+	// Fallback represents the fallback function, which is executed when no
+	// other function matches the call data.
+	Fallback
+	// Receive represents the receive function, which is executed on plain
+	// Ether transfers.
+	Receive
+)
+
 // Method represents a callable given a `Name` and whether the method is a constant.
 // If the method is `Const` no transaction needs to be created for this
 // particular Method call. It can easily be simulated using a local VM.
@@ -44,7 +60,16 @@ type Method struct {
 	Name string
 	// RawName is the raw method name parsed from ABI.
 	RawName string
+	// Type indicates whether the method is a special fallback, receive or
+	// constructor function.
+	Type FunctionType
+	// StateMutability is the "stateMutability" of solc >= 0.4.16: one of
+	// "pure", "view", "nonpayable" or "payable". It's empty for legacy ABIs.
+	StateMutability string
+	// Const and Payable are the legacy "constant" and "payable" flags. When
+	// parsing an ABI they are also derived from the state mutability.
 	Const   bool
+	Payable bool
 	Inputs  Arguments
 	Outputs Arguments
 }
@@ -65,6 +90,10 @@ func (method Method) Sig() string {
 }
 
 func (method Method) String() string {
+	switch method.Type {
+	case Fallback, Receive:
+		return fmt.Sprintf("%v() %s", method.Type, method.mutability())
+	}
 	inputs := make([]string, len(method.Inputs))
 	for i, input := range method.Inputs {
 		inputs[i] = fmt.Sprintf("%v %v", input.Type, input.Name)
@@ -76,11 +105,57 @@ func (method Method) String() string {
 			outputs[i] += fmt.Sprintf(" %v", output.Name)
 		}
 	}
-	constant := ""
-	if method.Const {
-		constant = "constant "
+	if method.Type == Constructor {
+		state := ""
+		if method.IsPayable() {
+			state = " payable"
+		}
+		return fmt.Sprintf("constructor(%v)%s", strings.Join(inputs, ", "), state)
+	}
+	state := method.mutability()
+	if state == "nonpayable" {
+		state = ""
+	} else if state != "" {
+		state += " "
+	}
+	return fmt.Sprintf("function %v(%v) %sreturns(%v)", method.RawName, strings.Join(inputs, ", "), state, strings.Join(outputs, ", "))
+}
+
+// mutability returns the state mutability, derived from the legacy flags for
+// old ABIs.
+func (method Method) mutability() string {
+	switch {
+	case method.StateMutability != "":
+		return method.StateMutability
+	case method.Const:
+		return "constant"
+	case method.Payable:
+		return "payable"
+	}
+	return "nonpayable"
+}
+
+// IsConstant returns whether the method is read-only, i.e. it doesn't modify
+// the state and can be executed with eth_call.
+func (method Method) IsConstant() bool {
+	return method.StateMutability == "view" || method.StateMutability == "pure" || method.Const
+}
+
+// IsPayable returns whether the method accepts Ether.
+func (method Method) IsPayable() bool {
+	return method.StateMutability == "payable" || method.Payable
+}
+
+func (t FunctionType) String() string {
+	switch t {
+	case Constructor:
+		return "constructor"
+	case Fallback:
+		return "fallback"
+	case Receive:
+		return "receive"
 	}
-	return fmt.Sprintf("function %v(%v) %sreturns(%v)", method.RawName, strings.Join(inputs, ", "), constant, strings.Join(outputs, ", "))
+	return "function"
 }
 
 // ID returns the canonical representation of the method's signature used by the
@@ -27,6 +27,7 @@ import (
 var (
 	bigT      = reflect.TypeOf(&big.Int{})
 	derefbigT = reflect.TypeOf(big.Int{})
+	mapT      = reflect.TypeOf(map[string]interface{}{})
 	uint8T    = reflect.TypeOf(uint8(0))
 	uint16T   = reflect.TypeOf(uint16(0))
 	uint32T   = reflect.TypeOf(uint32(0))
@@ -0,0 +1,192 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
+)
+
+// parseTypes parses Solidity type names, e.g. "uint256", "address[]" or
+// "(uint256 id, bytes data)[]", into ABI types.
+func parseTypes(types []string) ([]Type, error) {
+	parsed := make([]Type, len(types))
+	for i, typ := range types {
+		arg, err := parseHumanArgument(typ)
+		if err != nil {
+			return nil, fmt.Errorf("abi: %v", err)
+		}
+		if parsed[i], err = NewType(arg.Type, "", arg.components()); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// components converts the tuple components of arg to their JSON form.
+func (arg humanArgument) components() []ArgumentMarshaling {
+	if len(arg.Components) == 0 {
+		return nil
+	}
+	out := make([]ArgumentMarshaling, len(arg.Components))
+	for i, c := range arg.Components {
+		out[i] = ArgumentMarshaling{Name: c.Name, Type: c.Type, Components: c.components()}
+	}
+	return out
+}
+
+// Encode encodes values of the given Solidity types like abi.encode, i.e. in
+// the standard ABI encoding. Values must have the Go types accepted by Pack.
+func Encode(types []string, values []interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("abi: %d types but %d values", len(types), len(values))
+	}
+	parsed, err := parseTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	args := make(Arguments, len(parsed))
+	for i, t := range parsed {
+		args[i] = Argument{Name: "arg" + strconv.Itoa(i), Type: t}
+	}
+	return args.Pack(values...)
+}
+
+// EncodePacked encodes values of the given Solidity types like
+// abi.encodePacked:
+//
+//   - integers, bool, address and bytesN take their size, without padding
+//   - string and bytes are written as is, without length
+//   - elements of arrays are padded to 32 bytes, arrays have no length
+//
+// Like solc, it rejects tuples, nested arrays and arrays of dynamic types.
+// Values must have the Go types accepted by Pack; integers given as *big.Int
+// must fit the type.
+func EncodePacked(types []string, values []interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("abi: %d types but %d values", len(types), len(values))
+	}
+	parsed, err := parseTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	for i, t := range parsed {
+		packed, err := packPacked(t, reflect.ValueOf(values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("abi: argument %d (%v): %v", i, t, err)
+		}
+		out = append(out, packed...)
+	}
+	return out, nil
+}
+
+// SoliditySHA3 returns keccak256(abi.encodePacked(values)).
+func SoliditySHA3(types []string, values []interface{}) (common.Hash, error) {
+	packed, err := EncodePacked(types, values)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(packed), nil
+}
+
+// EthSignedMessageHash returns the hash signed by eth_sign and personal_sign
+// for message, i.e. keccak256("\x19Ethereum Signed Message:\n" + len(message)
+// + message). It matches toEthSignedMessageHash of OpenZeppelin's ECDSA
+// library, which contracts use to verify signatures of a 32-byte hash.
+func EthSignedMessageHash(message []byte) common.Hash {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(message))
+	return crypto.Keccak256Hash([]byte(prefix), message)
+}
+
+var errPackedUnsupported = errors.New("type not supported in packed mode")
+
+func packPacked(t Type, v reflect.Value) ([]byte, error) {
+	v = indirect(v)
+	if err := typeCheck(t, v); err != nil {
+		return nil, err
+	}
+	switch t.T {
+	case StringTy:
+		return []byte(v.String()), nil
+	case BytesTy:
+		if v.Kind() == reflect.Array {
+			v = mustArrayToByteSlice(v)
+		}
+		return v.Bytes(), nil
+	case SliceTy, ArrayTy:
+		if isDynamicType(*t.Elem) || t.Elem.T == SliceTy || t.Elem.T == ArrayTy || t.Elem.T == TupleTy {
+			return nil, errPackedUnsupported
+		}
+		var out []byte
+		for i := 0; i < v.Len(); i++ {
+			elem := indirect(v.Index(i))
+			if err := checkPackedInt(*t.Elem, elem); err != nil {
+				return nil, err
+			}
+			out = append(out, packElement(*t.Elem, elem)...)
+		}
+		return out, nil
+	case TupleTy:
+		return nil, errPackedUnsupported
+	case IntTy, UintTy:
+		if err := checkPackedInt(t, v); err != nil {
+			return nil, err
+		}
+		return packNum(v)[32-t.Size/8:], nil
+	case BoolTy:
+		if v.Bool() {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case AddressTy, FixedBytesTy, FunctionTy:
+		if v.Kind() == reflect.Array {
+			v = mustArrayToByteSlice(v)
+		}
+		return common.CopyBytes(v.Bytes()), nil
+	}
+	return nil, errPackedUnsupported
+}
+
+// checkPackedInt verifies that a *big.Int value fits the integer type t,
+// since the packed encoding would silently drop the excess bits.
+func checkPackedInt(t Type, v reflect.Value) error {
+	if t.T != IntTy && t.T != UintTy {
+		return nil
+	}
+	n, ok := v.Interface().(*big.Int)
+	if !ok {
+		return nil
+	}
+	if t.T == UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("%v out of range", n)
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(common.Big1, uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%v out of range", n)
+	}
+	return nil
+}
@@ -146,6 +146,7 @@ func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[stri
 
 	abi2struct := make(map[string]string)
 	struct2abi := make(map[string]string)
+	skipped := make(map[string]bool)
 
 	// first round ~~~
 	for i := 0; i < typ.NumField(); i++ {
@@ -162,14 +163,19 @@ func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[stri
 		}
 		// check if tag is empty.
 		if tagName == "" {
-			return nil, fmt.Errorf("struct: abi tag in '%s' is empty", structFieldName)
+			return nil, fmt.Errorf("struct: abi tag in '%v.%s' is empty", typ, structFieldName)
+		}
+		// abi:"-" excludes the field from the mapping.
+		if tagName == "-" {
+			skipped[structFieldName] = true
+			continue
 		}
 		// check which argument field matches with the abi tag.
 		found := false
 		for _, arg := range argNames {
 			if arg == tagName {
 				if abi2struct[arg] != "" {
-					return nil, fmt.Errorf("struct: abi tag in '%s' already mapped", structFieldName)
+					return nil, fmt.Errorf("struct: abi tag '%s' in '%v.%s' already mapped to '%s'", tagName, typ, structFieldName, abi2struct[arg])
 				}
 				// pair them
 				abi2struct[arg] = structFieldName
@@ -179,7 +185,7 @@ func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[stri
 		}
 		// check if this tag has been mapped.
 		if !found {
-			return nil, fmt.Errorf("struct: abi tag '%s' defined but not found in abi", tagName)
+			return nil, fmt.Errorf("struct: abi tag '%s' in '%v.%s' defined but not found in abi %v", tagName, typ, structFieldName, argNames)
 		}
 	}
 
@@ -189,7 +195,7 @@ func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[stri
 		structFieldName := ToCamelCase(argName)
 
 		if structFieldName == "" {
-			return nil, fmt.Errorf("abi: purely underscored output cannot unpack to struct")
+			return nil, fmt.Errorf("abi: unnamed or purely underscored output cannot unpack to struct %v, unpack into a slice instead", typ)
 		}
 
 		// this abi has already been paired, skip it... unless there exists another, yet unassigned
@@ -199,18 +205,19 @@ func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[stri
 		if abi2struct[argName] != "" {
 			if abi2struct[argName] != structFieldName &&
 				struct2abi[structFieldName] == "" &&
+				!skipped[structFieldName] &&
 				value.FieldByName(structFieldName).IsValid() {
-				return nil, fmt.Errorf("abi: multiple variables maps to the same abi field '%s'", argName)
+				return nil, fmt.Errorf("abi: multiple variables in %v map to the same abi field '%s': %s and %s", typ, argName, structFieldName, abi2struct[argName])
 			}
 			continue
 		}
 
 		// return an error if this struct field has already been paired.
 		if struct2abi[structFieldName] != "" {
-			return nil, fmt.Errorf("abi: multiple outputs mapping to the same struct field '%s'", structFieldName)
+			return nil, fmt.Errorf("abi: multiple outputs mapping to the same struct field '%v.%s': %s and %s", typ, structFieldName, struct2abi[structFieldName], argName)
 		}
 
-		if value.FieldByName(structFieldName).IsValid() {
+		if !skipped[structFieldName] && value.FieldByName(structFieldName).IsValid() {
 			// pair them
 			abi2struct[argName] = structFieldName
 			struct2abi[structFieldName] = argName
@@ -52,6 +52,10 @@ type Type struct {
 
 	stringKind string // holds the unparsed string for deriving signatures
 
+	// InternalType is the solidity type given by solc >= 0.5.11, e.g.
+	// "contract IERC20", "enum Token.State" or "struct Data[]".
+	InternalType string
+
 	// Tuple relative fields
 	TupleRawName  string   // Raw struct name defined in source code, may be empty.
 	TupleElems    []*Type  // Type information of all tuple fields
@@ -70,6 +74,7 @@ func NewType(t string, internalType string, components []ArgumentMarshaling) (ty
 		return Type{}, fmt.Errorf("invalid arg type in abi")
 	}
 	typ.stringKind = t
+	typ.InternalType = internalType
 
 	// if there are brackets, get ready to go into slice/array mode and
 	// recursively create the type
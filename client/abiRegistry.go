@@ -0,0 +1,234 @@
+package Client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/models"
+	"github.com/ethclient/sigdb"
+)
+
+// 本地ABI注册表, 按合约地址保存ABI, 用于解码交易的调用数据
+// dir 不为空时, 每个ABI保存为 dir/<地址>.json
+type AbiRegistry struct {
+	mu   sync.RWMutex
+	dir  string
+	abis map[common.Address]*abi.ABI
+	raw  map[common.Address][]byte
+
+	fallback *sigdb.DB // 地址未注册时用于按选择器解码的签名库
+}
+
+// 新建只在内存中的ABI注册表
+func NewAbiRegistry() *AbiRegistry {
+	return &AbiRegistry{
+		abis: make(map[common.Address]*abi.ABI),
+		raw:  make(map[common.Address][]byte),
+	}
+}
+
+// 从目录加载ABI注册表, 目录不存在时创建, 之后的注册和删除会同步到目录
+func LoadAbiRegistry(dir string) (*AbiRegistry, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	r := NewAbiRegistry()
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		address := strings.TrimSuffix(name, ".json")
+		if !common.IsHexAddress(address) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if err := r.add(common.HexToAddress(address), data); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	r.dir = dir
+	return r, nil
+}
+
+// 注册合约地址的ABI, 已存在时覆盖
+func (r *AbiRegistry) Register(address string, abiJSON []byte) error {
+	if !common.IsHexAddress(address) {
+		return fmt.Errorf("invalid address %s", address)
+	}
+	addr := common.HexToAddress(address)
+	if err := r.add(addr, abiJSON); err != nil {
+		return err
+	}
+	if r.dir == "" {
+		return nil
+	}
+	return ioutil.WriteFile(r.file(addr), abiJSON, 0600)
+}
+
+// 删除合约地址的ABI
+func (r *AbiRegistry) Unregister(address string) error {
+	addr := common.HexToAddress(address)
+	r.mu.Lock()
+	_, ok := r.abis[addr]
+	delete(r.abis, addr)
+	delete(r.raw, addr)
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no abi registered for %s", addr.Hex())
+	}
+	if r.dir == "" {
+		return nil
+	}
+	return os.Remove(r.file(addr))
+}
+
+// 查询合约地址的ABI
+func (r *AbiRegistry) Lookup(address string) (*abi.ABI, bool) {
+	if !common.IsHexAddress(address) {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	parsed, ok := r.abis[common.HexToAddress(address)]
+	return parsed, ok
+}
+
+// 查询合约地址注册时的ABI原文
+func (r *AbiRegistry) Raw(address string) ([]byte, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	data, ok := r.raw[common.HexToAddress(address)]
+	return data, ok
+}
+
+// 已注册的合约地址, 按地址排序
+func (r *AbiRegistry) Addresses() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	addresses := make([]string, 0, len(r.abis))
+	for addr := range r.abis {
+		addresses = append(addresses, addr.Hex())
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// 设置签名库, 设置后未注册地址的调用按函数选择器解码
+func (r *AbiRegistry) SetFallback(db *sigdb.DB) {
+	r.mu.Lock()
+	r.fallback = db
+	r.mu.Unlock()
+}
+
+// 用接收地址的ABI解码调用数据, 地址未注册时使用签名库
+// 选择器冲突时返回签名库中所有能解码的函数, 无法识别时返回 nil, nil
+func (r *AbiRegistry) DecodeCall(to string, input []byte) ([]*abi.DecodedCall, error) {
+	if parsed, ok := r.Lookup(to); ok {
+		call, err := abi.DecodeCalldata(parsed, input)
+		if err != nil {
+			return nil, err
+		}
+		return []*abi.DecodedCall{call}, nil
+	}
+	r.mu.RLock()
+	fallback := r.fallback
+	r.mu.RUnlock()
+	if fallback == nil {
+		return nil, nil
+	}
+	calls, err := fallback.DecodeCall(input)
+	if err == sigdb.ErrUnknownSignature {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+func (r *AbiRegistry) add(addr common.Address, abiJSON []byte) error {
+	parsed, err := abi.JSON(strings.NewReader(string(abiJSON)))
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.abis[addr] = &parsed
+	r.raw[addr] = abiJSON
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *AbiRegistry) file(addr common.Address) string {
+	return filepath.Join(r.dir, addr.Hex()+".json")
+}
+
+// 设置ABI注册表, 设置后 GetMixedBlockByBlockNumOrHash 会为接收地址已注册的交易附加解码后的调用
+func (c *EthClient) SetAbiRegistry(r *AbiRegistry) {
+	c.abiRegistry = r
+}
+
+// 为块中能识别的交易调用附加解码结果, 解码失败的交易只记录日志
+func decodeBlockCalls(r *AbiRegistry, block *models.MixedBlock) {
+	for i := range block.Transactions {
+		tx := &block.Transactions[i].Tx
+		if tx.Recipient == "" {
+			continue
+		}
+		input, err := hexutil.Decode(tx.Payload)
+		if err != nil || len(input) < 4 {
+			continue
+		}
+		calls, err := r.DecodeCall(tx.Recipient, input)
+		if err != nil {
+			log.Warnf("decode call of tx %s failed: %v", tx.Hash, err)
+			continue
+		}
+		block.Transactions[i].Call = toAmbiguousCall(calls)
+	}
+}
+
+// 转换解码结果, 有多个候选函数时标记为不确定并附带所有候选
+func toAmbiguousCall(calls []*abi.DecodedCall) *models.DecodedCall {
+	if len(calls) == 0 {
+		return nil
+	}
+	call := ToDecodedCall(calls[0])
+	if len(calls) > 1 {
+		call.Ambiguous = true
+		call.Candidates = make([]*models.DecodedCall, len(calls))
+		for i, c := range calls {
+			call.Candidates[i] = ToDecodedCall(c)
+		}
+	}
+	return call
+}
+
+// 转换为 models.DecodedCall
+func ToDecodedCall(call *abi.DecodedCall) *models.DecodedCall {
+	args := make([]models.DecodedArg, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = models.DecodedArg{Name: arg.Name, Type: arg.Type.String(), Value: arg.JSONValue()}
+	}
+	return &models.DecodedCall{
+		Method:    call.Method.RawName,
+		Signature: call.Method.Sig(),
+		Selector:  hexutil.Encode(call.Method.ID()),
+		Args:      args,
+	}
+}
@@ -0,0 +1,37 @@
+package Client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethclient/models"
+	"github.com/ethclient/sigdb"
+)
+
+// 选择器 0xa9059cbb 冲突时, 输入同时能解码为 transfer(address,uint256) 和 func_2093253501(bytes)
+func TestDecodeBlockCallsAmbiguous(t *testing.T) {
+	db := sigdb.Default()
+	if _, err := db.AddText(strings.NewReader("func_2093253501(bytes)")); err != nil {
+		t.Fatal(err)
+	}
+	r := NewAbiRegistry()
+	r.SetFallback(db)
+
+	payload := "0xa9059cbb" + strings.Repeat("0", 62) + "20" + strings.Repeat("0", 64)
+	block := &models.MixedBlock{Transactions: []models.MixTransaction{
+		{Tx: models.Transaction{Recipient: "0x00000000000000000000000000000000000000aa", Payload: payload}},
+		{Tx: models.Transaction{Recipient: "0x00000000000000000000000000000000000000aa", Payload: "0xa9059cbb"}},
+	}}
+	decodeBlockCalls(r, block)
+
+	call := block.Transactions[0].Call
+	if call == nil || !call.Ambiguous || len(call.Candidates) != 2 {
+		t.Fatalf("expected an ambiguous call with 2 candidates, got %+v", call)
+	}
+	if call.Candidates[0].Signature != "transfer(address,uint256)" || call.Candidates[1].Signature != "func_2093253501(bytes)" {
+		t.Errorf("unexpected candidates %s, %s", call.Candidates[0].Signature, call.Candidates[1].Signature)
+	}
+	if call := block.Transactions[1].Call; call != nil {
+		t.Errorf("undecodable input decoded as %+v", call)
+	}
+}
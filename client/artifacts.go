@@ -0,0 +1,357 @@
+package Client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/models"
+)
+
+// Hardhat, Foundry 和 Truffle 编译产物的公共字段
+type artifactFile struct {
+	Format                 string                `json:"_format"`
+	ContractName           string                `json:"contractName"`
+	SourceName             string                `json:"sourceName"` // hardhat
+	SourcePath             string                `json:"sourcePath"` // truffle, 绝对路径
+	Abi                    json.RawMessage       `json:"abi"`
+	Bytecode               json.RawMessage       `json:"bytecode"` // hardhat 和 truffle 为字符串, foundry 为对象
+	DeployedBytecode       json.RawMessage       `json:"deployedBytecode"`
+	LinkReferences         models.LinkReferences `json:"linkReferences"`
+	DeployedLinkReferences models.LinkReferences `json:"deployedLinkReferences"`
+	Metadata               json.RawMessage       `json:"metadata"` // foundry 为对象, truffle 为字符串
+}
+
+// foundry 的字节码对象
+type foundryBytecode struct {
+	Object         string                `json:"object"`
+	LinkReferences models.LinkReferences `json:"linkReferences"`
+}
+
+// 读取Hardhat产物目录 artifacts, 跳过 build-info 和 .dbg.json 文件
+func LoadHardhatArtifacts(dir string) (map[string]models.ContractConfig, error) {
+	return loadArtifacts(dir, func(name string) bool { return strings.HasSuffix(name, ".dbg.json") })
+}
+
+// 读取Foundry产物目录 out, 跳过 build-info
+func LoadFoundryArtifacts(dir string) (map[string]models.ContractConfig, error) {
+	return loadArtifacts(dir, nil)
+}
+
+// 读取Truffle产物目录 build/contracts
+func LoadTruffleArtifacts(dir string) (map[string]models.ContractConfig, error) {
+	return loadArtifacts(dir, nil)
+}
+
+// 读取目录下的所有产物, 以 源文件:合约名 为键, 源文件未知时以合约名为键
+// 不同文件得到相同的键时返回错误
+func loadArtifacts(dir string, skip func(name string) bool) (map[string]models.ContractConfig, error) {
+	contracts := make(map[string]models.ContractConfig)
+	files := make(map[string]string) // 键 => 产物文件
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "build-info" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".json" || skip != nil && skip(info.Name()) {
+			return nil
+		}
+		config, err := LoadArtifact(path)
+		if err != nil {
+			return err
+		}
+		if config == nil {
+			return nil
+		}
+		key := config.Name
+		if config.SourceName != "" {
+			key = config.SourceName + ":" + config.Name
+		}
+		if prev, ok := files[key]; ok {
+			return fmt.Errorf("duplicate contract %s in %s and %s", key, prev, path)
+		}
+		contracts[key], files[key] = *config, path
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contracts, nil
+}
+
+// 读取单个产物文件, 自动识别Hardhat, Foundry和Truffle格式
+// 不含abi的json文件(如Foundry的缓存文件)返回 nil, nil
+func LoadArtifact(file string) (*models.ContractConfig, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var artifact artifactFile
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if len(artifact.Abi) == 0 || artifact.Abi[0] != '[' {
+		return nil, nil
+	}
+	config := &models.ContractConfig{
+		AbiData:    artifact.Abi,
+		Name:       artifact.ContractName,
+		SourceName: artifact.SourceName,
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(artifact.Bytecode), []byte("{")) {
+		err = parseFoundryArtifact(file, &artifact, config)
+	} else {
+		err = parseArtifact(&artifact, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	config.MetadataHash = metadataHash(config.DeployedCode)
+	return config, nil
+}
+
+// Foundry 产物, 合约名取文件名, 源文件取元数据的 compilationTarget
+func parseFoundryArtifact(file string, artifact *artifactFile, config *models.ContractConfig) error {
+	var code, deployed foundryBytecode
+	if err := json.Unmarshal(artifact.Bytecode, &code); err != nil {
+		return err
+	}
+	if len(artifact.DeployedBytecode) > 0 {
+		if err := json.Unmarshal(artifact.DeployedBytecode, &deployed); err != nil {
+			return err
+		}
+	}
+	config.ContractCode, config.LinkReferences = hexCode(code.Object), code.LinkReferences
+	config.DeployedCode, config.DeployedLinkReferences = hexCode(deployed.Object), deployed.LinkReferences
+	config.Name = strings.TrimSuffix(filepath.Base(file), ".json")
+	config.SourceName = compilationTarget(artifact.Metadata, config.Name)
+	return nil
+}
+
+// Hardhat 和 Truffle 产物, Truffle 没有 linkReferences, 从字节码中的占位符推算
+func parseArtifact(artifact *artifactFile, config *models.ContractConfig) error {
+	if err := json.Unmarshal(artifact.Bytecode, &config.ContractCode); err != nil {
+		return fmt.Errorf("invalid bytecode: %v", err)
+	}
+	if len(artifact.DeployedBytecode) > 0 {
+		if err := json.Unmarshal(artifact.DeployedBytecode, &config.DeployedCode); err != nil {
+			return fmt.Errorf("invalid deployedBytecode: %v", err)
+		}
+	}
+	config.ContractCode, config.DeployedCode = hexCode(config.ContractCode), hexCode(config.DeployedCode)
+	if strings.HasPrefix(artifact.Format, "hh-") {
+		config.LinkReferences, config.DeployedLinkReferences = artifact.LinkReferences, artifact.DeployedLinkReferences
+		return nil
+	}
+	// truffle 的元数据是json字符串
+	var metadata string
+	if json.Unmarshal(artifact.Metadata, &metadata) == nil {
+		config.SourceName = compilationTarget(json.RawMessage(metadata), config.Name)
+	}
+	if config.SourceName == "" && artifact.SourcePath != "" {
+		config.SourceName = filepath.Base(artifact.SourcePath)
+	}
+	config.LinkReferences = placeholderReferences(config.ContractCode)
+	config.DeployedLinkReferences = placeholderReferences(config.DeployedCode)
+	return nil
+}
+
+// 元数据 settings.compilationTarget 中合约对应的源文件
+func compilationTarget(metadata json.RawMessage, name string) string {
+	var meta struct {
+		Settings struct {
+			CompilationTarget map[string]string `json:"compilationTarget"`
+		} `json:"settings"`
+	}
+	if json.Unmarshal(metadata, &meta) != nil {
+		return ""
+	}
+	for source, contract := range meta.Settings.CompilationTarget {
+		if contract == name {
+			return source
+		}
+	}
+	return ""
+}
+
+// 统一为0x开头的字节码, 空字节码返回空字符串
+func hexCode(code string) string {
+	code = strings.TrimPrefix(code, "0x")
+	if code == "" {
+		return ""
+	}
+	return "0x" + code
+}
+
+// 查找字节码中 __库名___ 或 __$hash$__ 形式的40字符占位符
+func placeholderReferences(code string) models.LinkReferences {
+	refs := make(models.LinkReferences)
+	body := strings.TrimPrefix(code, "0x")
+	for i := 0; i+40 <= len(body); i += 2 {
+		if body[i:i+2] != "__" {
+			continue
+		}
+		name := strings.Trim(body[i:i+40], "_$")
+		if refs[""] == nil {
+			refs[""] = make(map[string][]models.LinkReference)
+		}
+		refs[""][name] = append(refs[""][name], models.LinkReference{Start: i / 2, Length: 20})
+		i += 38
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	return refs
+}
+
+// 链接库地址, libraries 的键为库名或 源文件:库名, 返回链接后的副本
+// 仍有未提供地址的库时返回错误
+func LinkContract(config models.ContractConfig, libraries map[string]common.Address) (models.ContractConfig, error) {
+	var err error
+	if config.ContractCode, config.LinkReferences, err = linkCode(config.ContractCode, config.LinkReferences, libraries); err != nil {
+		return config, err
+	}
+	config.DeployedCode, config.DeployedLinkReferences, err = linkCode(config.DeployedCode, config.DeployedLinkReferences, libraries)
+	return config, err
+}
+
+func linkCode(code string, refs models.LinkReferences, libraries map[string]common.Address) (string, models.LinkReferences, error) {
+	if len(refs) == 0 {
+		return code, refs, nil
+	}
+	body := []byte(strings.TrimPrefix(code, "0x"))
+	var missing []string
+	for source, libs := range refs {
+		for lib, positions := range libs {
+			address, ok := libraries[source+":"+lib]
+			if !ok {
+				address, ok = libraries[lib]
+			}
+			if !ok {
+				missing = append(missing, strings.TrimPrefix(source+":"+lib, ":"))
+				continue
+			}
+			for _, pos := range positions {
+				if pos.Length != common.AddressLength || 2*(pos.Start+pos.Length) > len(body) {
+					return "", nil, fmt.Errorf("invalid link reference of %s at %d", lib, pos.Start)
+				}
+				copy(body[2*pos.Start:], strings.ToLower(hex.EncodeToString(address.Bytes())))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("missing library addresses: %s", strings.Join(missing, ", "))
+	}
+	return "0x" + string(body), nil, nil
+}
+
+// 解析solc追加在运行时字节码末尾的CBOR元数据, 返回 ipfs:<base58> 或 bzzr0:/bzzr1:<hex>
+// 只解码十六进制串的末尾, 未链接字节码中的库占位符不影响解析
+func metadataHash(code string) string {
+	body := strings.TrimPrefix(code, "0x")
+	if len(body) < 4 {
+		return ""
+	}
+	size, err := hex.DecodeString(body[len(body)-4:])
+	if err != nil {
+		return ""
+	}
+	n := int(binary.BigEndian.Uint16(size))
+	if n == 0 || 2*(n+2) > len(body) {
+		return ""
+	}
+	cbor, err := hex.DecodeString(body[len(body)-2*(n+2) : len(body)-4])
+	if err != nil {
+		return ""
+	}
+	fields := decodeCBORMap(cbor)
+	if hash, ok := fields["ipfs"]; ok && len(hash) == 34 {
+		return "ipfs:" + base58Encode(hash)
+	}
+	for _, key := range []string{"bzzr1", "bzzr0"} {
+		if hash, ok := fields[key]; ok {
+			return key + ":" + hex.EncodeToString(hash)
+		}
+	}
+	return ""
+}
+
+// 只解析元数据用到的CBOR子集: 文本键, 字节串/文本/简单值, 无法解析时返回 nil
+func decodeCBORMap(data []byte) map[string][]byte {
+	if len(data) == 0 || data[0]>>5 != 5 || data[0]&0x1f >= 24 {
+		return nil
+	}
+	count := int(data[0] & 0x1f)
+	data = data[1:]
+	fields := make(map[string][]byte, count)
+	item := func() (major byte, value []byte, ok bool) {
+		if len(data) == 0 {
+			return 0, nil, false
+		}
+		major, info := data[0]>>5, int(data[0]&0x1f)
+		data = data[1:]
+		if major == 7 {
+			return major, nil, true
+		}
+		if info == 24 {
+			if len(data) == 0 {
+				return 0, nil, false
+			}
+			info, data = int(data[0]), data[1:]
+		} else if info > 24 {
+			return 0, nil, false
+		}
+		if major != 2 && major != 3 || info > len(data) {
+			return 0, nil, false
+		}
+		value, data = data[:info], data[info:]
+		return major, value, true
+	}
+	for i := 0; i < count; i++ {
+		major, key, ok := item()
+		if !ok || major != 3 {
+			return nil
+		}
+		_, value, ok := item()
+		if !ok {
+			return nil
+		}
+		fields[string(key)] = value
+	}
+	return fields
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(data []byte) string {
+	x := new(big.Int).SetBytes(data)
+	base, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
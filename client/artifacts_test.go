@@ -0,0 +1,122 @@
+package Client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethclient/common"
+)
+
+// 运行时字节码末尾的CBOR元数据, ipfs哈希为 0x1220 加32个 0x01
+const testMetadata = "a2646970667358221220010101010101010101010101010101010101010101010101010101010101010164736f6c63430008130033"
+
+var testPlaceholder = "__$" + strings.Repeat("ab", 17) + "$__"
+
+func writeArtifacts(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadArtifacts(t *testing.T) {
+	hardhat := writeArtifacts(t, map[string]string{
+		"contracts/Token.sol/Token.json": `{
+			"_format": "hh-sol-artifact-1", "contractName": "Token", "sourceName": "contracts/Token.sol",
+			"abi": [{"type": "constructor", "inputs": [{"name": "supply", "type": "uint256"}]}],
+			"bytecode": "0x6080` + testPlaceholder + `00", "deployedBytecode": "0x6080` + testPlaceholder + `00` + testMetadata + `",
+			"linkReferences": {"contracts/Math.sol": {"Math": [{"start": 2, "length": 20}]}},
+			"deployedLinkReferences": {"contracts/Math.sol": {"Math": [{"start": 2, "length": 20}]}}
+		}`,
+		"contracts/Token.sol/Token.dbg.json": `{"_format": "hh-sol-dbg-1", "buildInfo": "../../build-info/1.json"}`,
+		"build-info/1.json":                  `{"id": "1", "input": {}, "output": {"contracts": {}}}`,
+	})
+	defer os.RemoveAll(hardhat)
+	foundry := writeArtifacts(t, map[string]string{
+		"Token.sol/Token.json": `{
+			"abi": [], "bytecode": {"object": "0x6080", "linkReferences": {}},
+			"deployedBytecode": {"object": "0x6080` + testMetadata + `", "linkReferences": {}},
+			"metadata": {"settings": {"compilationTarget": {"src/Token.sol": "Token"}}}
+		}`,
+		"IToken.sol/IToken.json": `{"abi": [], "bytecode": {"object": "0x"}, "deployedBytecode": {"object": "0x"}}`,
+		"build-info/1.json":      `{"id": "1"}`,
+	})
+	defer os.RemoveAll(foundry)
+	truffle := writeArtifacts(t, map[string]string{
+		"Token.json": `{
+			"contractName": "Token", "abi": [], "sourcePath": "/work/contracts/Token.sol",
+			"metadata": "{\"settings\":{\"compilationTarget\":{\"project:/contracts/Token.sol\":\"Token\"}}}",
+			"bytecode": "0x6080__Math__________________________________00", "deployedBytecode": "0x6080"
+		}`,
+	})
+	defer os.RemoveAll(truffle)
+
+	hh, err := LoadHardhatArtifacts(hardhat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, ok := hh["contracts/Token.sol:Token"]
+	if len(hh) != 1 || !ok {
+		t.Fatalf("unexpected hardhat contracts %v", hh)
+	}
+	if token.MetadataHash != "ipfs:QmNQa1FSTXNHmrjjfgUW3Px3Vkke4oKiFWdigWkYSux2Pi" {
+		t.Errorf("unexpected metadata hash %s", token.MetadataHash)
+	}
+	if _, err := LinkContract(token, nil); err == nil || err.Error() != "missing library addresses: contracts/Math.sol:Math" {
+		t.Errorf("unexpected link error %v", err)
+	}
+	if _, err := (&EthClient{}).DeployContractConfig(token, nil); err == nil {
+		t.Errorf("expected error deploying unlinked contract")
+	}
+	linked, err := LinkContract(token, map[string]common.Address{"Math": common.HexToAddress("0x00000000000000000000000000000000000000aa")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "0x6080" + strings.Repeat("00", 19) + "aa00"; linked.ContractCode != want || len(linked.LinkReferences) != 0 {
+		t.Errorf("got linked code %s, want %s", linked.ContractCode, want)
+	}
+
+	fd, err := LoadFoundryArtifacts(foundry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token := fd["src/Token.sol:Token"]; token.ContractCode != "0x6080" || token.MetadataHash == "" || fd["IToken"].ContractCode != "" || len(fd) != 2 {
+		t.Errorf("unexpected foundry contracts %+v", fd)
+	}
+
+	// 同名合约没有 compilationTarget 时键相同
+	duplicates := writeArtifacts(t, map[string]string{
+		"A.sol/Token.json": `{"abi": [], "bytecode": {"object": "0x01"}}`,
+		"B.sol/Token.json": `{"abi": [], "bytecode": {"object": "0x02"}}`,
+	})
+	defer os.RemoveAll(duplicates)
+	if _, err := LoadFoundryArtifacts(duplicates); err == nil || !strings.Contains(err.Error(), "duplicate contract Token") {
+		t.Errorf("unexpected duplicate error %v", err)
+	}
+
+	tf, err := LoadTruffleArtifacts(truffle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token = tf["project:/contracts/Token.sol:Token"]
+	if refs := token.LinkReferences[""]["Math"]; len(refs) != 1 || refs[0].Start != 2 || refs[0].Length != 20 {
+		t.Fatalf("unexpected truffle link references %+v", token.LinkReferences)
+	}
+	linked, err = LinkContract(token, map[string]common.Address{"Math": common.HexToAddress("0x00000000000000000000000000000000000000bb")})
+	if err != nil || linked.ContractCode != "0x6080"+strings.Repeat("00", 19)+"bb00" {
+		t.Errorf("unexpected truffle link result %s: %v", linked.ContractCode, err)
+	}
+}
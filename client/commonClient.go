@@ -28,16 +28,40 @@ func ToHexString(q string) (*string, error) {
 	return &hexString, nil
 }
 
+// 块参数转为rpc格式, 支持块高(10进制或16进制)和latest/pending/earliest, 为空时取latest
+func blockTag(block string) (string, error) {
+	switch block {
+	case "":
+		return "latest", nil
+	case "latest", "pending", "earliest":
+		return block, nil
+	}
+	hexBlock, err := ToHexString(block)
+	if err != nil {
+		return "", err
+	}
+	return *hexBlock, nil
+}
+
 // Block to MixedBlock
 func BlockToMixedBlock(c *EthClient, block *models.Block) (*models.MixedBlock, error) {
-	mixedTransactions := make([]models.MixTransaction, 0)
-	for _, tx := range block.Transactions {
+	receipts := make([]*models.Receipt, len(block.Transactions))
+	for i, tx := range block.Transactions {
 		receipt, err := c.GetTransactionDetail(tx.Hash)
 		if err != nil {
 
 			return nil, err
 		}
-		mixedTx, err := TransactionToMixedTransaction(&tx, receipt)
+		receipts[i] = receipt
+	}
+	return mixBlock(block, receipts)
+}
+
+// 块和对应的receipt组装成MixedBlock
+func mixBlock(block *models.Block, receipts []*models.Receipt) (*models.MixedBlock, error) {
+	mixedTransactions := make([]models.MixTransaction, 0)
+	for i, tx := range block.Transactions {
+		mixedTx, err := TransactionToMixedTransaction(&tx, receipts[i])
 		if err != nil {
 
 			return nil, err
@@ -184,6 +208,10 @@ func ExchangeBlockHeader(inputBlock interface{}) error {
 			return err
 		}
 		block.TotalDifficulty = *tmp
+
+		if err := exchangeOptional(&block.BaseFee); err != nil {
+			return err
+		}
 	case *models.MixedBlock:
 		block := inputBlock.(*models.MixedBlock)
 		tmp, err := HexStringToDecString(block.GasLimit)
@@ -232,6 +260,10 @@ func ExchangeBlockHeader(inputBlock interface{}) error {
 			return err
 		}
 		block.TotalDifficulty = *tmp
+
+		if err := exchangeOptional(&block.BaseFee); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -285,6 +317,10 @@ func ExchangeUncleHeader(header *models.Header) error {
 		return err
 	}
 	header.TotalDifficulty = *tmp
+
+	if err := exchangeOptional(&header.BaseFee); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -327,6 +363,10 @@ func ExchangeBlockTransaction(inputTransaction interface{}) error {
 			return err
 		}
 		transaction.TransactionIndex = *tmp
+
+		if err := exchangeTypedTransaction(transaction); err != nil {
+			return err
+		}
 	case *models.MixTransaction:
 		transaction := inputTransaction.(*models.MixTransaction)
 		tmp, err := HexStringToDecString(transaction.Tx.BlockNumber)
@@ -363,7 +403,34 @@ func ExchangeBlockTransaction(inputTransaction interface{}) error {
 			return err
 		}
 		transaction.Tx.TransactionIndex = *tmp
+
+		if err := exchangeTypedTransaction(&transaction.Tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 转换EIP-2718类型交易的字段
+func exchangeTypedTransaction(transaction *models.Transaction) error {
+	for _, field := range []*string{&transaction.Type, &transaction.ChainId, &transaction.MaxFeePerGas, &transaction.MaxPriorityFeePerGas} {
+		if err := exchangeOptional(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 字段不为空时转换成十进制
+func exchangeOptional(field *string) error {
+	if *field == "" {
+		return nil
+	}
+	tmp, err := HexStringToDecString(*field)
+	if err != nil {
+		return err
 	}
+	*field = *tmp
 	return nil
 }
 
@@ -613,6 +680,11 @@ func (c *EthClient) GetBlockByBlockNumOrHash(input string) (*models.Block, error
 
 		return nil, err
 	}
+	if c.verifyBlocks {
+		if _, err := verifyBlockInfo(c, blockInfo, method, arg); err != nil {
+			return nil, err
+		}
+	}
 	var block models.Block
 	err = json.Unmarshal(blockInfo, &block)
 	if err != nil {
@@ -674,11 +746,19 @@ func (c *EthClient) GetMixedBlockByBlockNumOrHash(input string) (*models.MixedBl
 
 		return nil, err
 	}
-	mixedBlock, err := BlockToMixedBlock(c, &block)
+	var mixedBlock *models.MixedBlock
+	if c.verifyBlocks {
+		mixedBlock, err = verifiedMixedBlock(c, blockInfo, &block, method, arg)
+	} else {
+		mixedBlock, err = BlockToMixedBlock(c, &block)
+	}
 	if err != nil {
 
 		return nil, err
 	}
+	if c.abiRegistry != nil {
+		decodeBlockCalls(c.abiRegistry, mixedBlock)
+	}
 	err = ExchangeBlock(mixedBlock)
 	if err != nil {
 
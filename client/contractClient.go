@@ -105,44 +105,20 @@ func compilerContractForDocker(contractPath string, contractFileName string, sol
 		if err != nil {
 			return nil, fmt.Errorf("Failed to parse ABIs from compiler output: %v", err)
 		}
-		fmt.Printf("abiValue:%v\n", string(abiValue))
+		log.Debugf("abiValue:%v", string(abiValue))
 		contractMap[name] = models.ContractConfig{AbiData: abiValue, ContractCode: contract.Code}
 	}
 	return contractMap, nil
 }
 
-// 以命令行方式获取solc版本
-func solidityVersion(solc string) (*compiler.Solidity, error) {
-	if solc == "" {
-		solc = "solc"
-	}
-	var out bytes.Buffer
-	cmd := exec.Command(solc, "--version")
-	cmd.Stdout = &out
-	err := cmd.Run()
-	if err != nil {
-		return nil, err
-	}
-	matches := versionRegexp.FindStringSubmatch(out.String())
-	if len(matches) != 4 {
-		return nil, fmt.Errorf("can't parse solc version %q", out.String())
-	}
-	s := &compiler.Solidity{Path: cmd.Path, FullVersion: out.String(), Version: matches[0]}
-	if s.Major, err = strconv.Atoi(matches[1]); err != nil {
-		return nil, err
-	}
-	if s.Minor, err = strconv.Atoi(matches[2]); err != nil {
-		return nil, err
-	}
-	if s.Patch, err = strconv.Atoi(matches[3]); err != nil {
-		return nil, err
-	}
-	return s, nil
+// 编译合约, solc为空时使用PATH中的solc
+func CompileContract(solc, source string) (map[string]models.ContractConfig, error) {
+	return compilerContract(solc, source)
 }
 
 // 编译合约 以solc命令行形式
 func compilerContract(solc, source string) (map[string]models.ContractConfig, error) {
-	s, err := solidityVersion(solc)
+	s, err := compiler.SolidityVersion(solc)
 	if err != nil {
 		return nil, err
 	}
@@ -168,7 +144,7 @@ func compilerContract(solc, source string) (map[string]models.ContractConfig, er
 		if err != nil {
 			return nil, fmt.Errorf("Failed to parse ABIs from compiler output: %v", err)
 		}
-		fmt.Printf("abiValue:%v\n", string(abiValue))
+		log.Debugf("abiValue:%v", string(abiValue))
 		contractMap[name] = models.ContractConfig{AbiData: abiValue, ContractCode: contract.Code}
 	}
 	return contractMap, nil
@@ -176,16 +152,37 @@ func compilerContract(solc, source string) (map[string]models.ContractConfig, er
 
 // 调用合约
 func (c *EthClient) InvokeContract(contractAddressString string, abiData string, nonce uint64, method string, args ...interface{}) (string, error) {
+	return c.InvokeContractWithValue(contractAddressString, abiData, nonce, nil, method, args...)
+}
+
+// 调用合约并转账, 方法不是payable时拒绝转账
+// view和pure方法不修改状态, 通过eth_call执行并返回十六进制编码的调用结果, 不发送交易
+func (c *EthClient) InvokeContractWithValue(contractAddressString string, abiData string, nonce uint64, value *big.Int, method string, args ...interface{}) (string, error) {
 	abiValue, err := abi.JSON(bytes.NewReader([]byte(abiData)))
 	if err != nil {
 		return "", err
 	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	m, ok := abiValue.Methods[method]
+	if ok && value.Sign() > 0 && !m.IsPayable() {
+		return "", fmt.Errorf("method %s is not payable", method)
+	}
 	out, err := abiValue.Pack(method, args...)
 	if err != nil {
 		return "", err
 	}
 	contractAddress := common.HexToAddress(contractAddressString)
 	address := crypto.PubkeyToAddress(c.SignPrikey.PublicKey)
+	if ok && m.IsConstant() {
+		log.Debugf("method %s is read-only, calling it with eth_call", method)
+		res, err := c.ClientPara.Client.CallContract(*c.Ctx, ethclient.CallMsg{From: address, To: &contractAddress, Data: out}, nil)
+		if err != nil {
+			return "", err
+		}
+		return hexutil.Encode(res), nil
+	}
 	gasPrice, err := c.ClientPara.Client.SuggestGasPrice(*c.Ctx)
 	if err != nil {
 		return "", err
@@ -193,6 +190,7 @@ func (c *EthClient) InvokeContract(contractAddressString string, abiData string,
 	msg := ethclient.CallMsg{
 		From:     address,
 		To:       &contractAddress,
+		Value:    value,
 		Data:     out,
 		GasPrice: gasPrice,
 	}
@@ -201,7 +199,7 @@ func (c *EthClient) InvokeContract(contractAddressString string, abiData string,
 		return "", err
 	}
 
-	transaction := types.NewTransaction(nonce, contractAddress, big.NewInt(0), gasLimit, gasPrice, out)
+	transaction := types.NewTransaction(nonce, contractAddress, value, gasLimit, gasPrice, out)
 	transaction, err = types.SignTx(transaction, types.HomesteadSigner{}, c.SignPrikey)
 	if err != nil {
 		return "", err
@@ -245,40 +243,83 @@ func (c *EthClient) QueryContract(contractAddressString string, abiData string,
 
 // 部署合约
 func (c *EthClient) DeployContract(contractData string, contractName string) error {
+	_, err := c.DeployContractAddress(contractData, contractName)
+	return err
+}
+
+// 部署合约并返回合约地址
+func (c *EthClient) DeployContractAddress(contractData string, contractName string) (string, error) {
 	contractMap, err := compilerContract("", contractData)
 	if err != nil {
-		return err
+		return "", err
 	}
 	i := 0
 	nonce, err := c.GetNonce()
 	if err != nil {
-		return err
+		return "", err
 	}
 	contractAddress := ""
 	for _, contractData := range contractMap {
-		txid, err := c.SendTransaction(models.CREATE_CONTRACT, nonce+uint64(i), "", "", common.FromHex(contractData.ContractCode))
+		contractAddress, err = c.deployCode(nonce+uint64(i), common.FromHex(contractData.ContractCode))
 		if err != nil {
-			return err
-		}
-		opType := "DeployContract"
-		var wg sync.WaitGroup
-		txResultStatus := make(chan models.TxResultStatus, 1)
-		wg.Add(1)
-		go c.JudgeUpChainStatus(*txid, opType, txResultStatus, &wg)
-		wg.Wait()
-		select {
-		case receipt := <-txResultStatus:
-			if receipt.Err != nil {
-				return err
-			}
-			contractAddress = receipt.Receipt.ContractAddress.Hex()
-		default:
-			return fmt.Errorf(opType)
+			return "", err
 		}
 		i++
 	}
 	log.Infof("contractAddress:%s,contractName:%s", contractAddress, contractName)
-	return nil
+	return contractAddress, nil
+}
+
+// 部署导入的编译产物, args为构造函数参数, 有未链接的库时先调用 LinkContract
+func (c *EthClient) DeployContractConfig(config models.ContractConfig, args ...interface{}) (string, error) {
+	if len(config.LinkReferences) > 0 {
+		return "", fmt.Errorf("contract %s has unlinked libraries", config.Name)
+	}
+	code := common.FromHex(config.ContractCode)
+	if len(code) == 0 {
+		return "", fmt.Errorf("contract %s has no bytecode, it may be abstract or an interface", config.Name)
+	}
+	abiValue, err := abi.JSON(bytes.NewReader(config.AbiData))
+	if err != nil {
+		return "", err
+	}
+	input, err := abiValue.Pack("", args...)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := c.GetNonce()
+	if err != nil {
+		return "", err
+	}
+	contractAddress, err := c.deployCode(nonce, append(code, input...))
+	if err != nil {
+		return "", err
+	}
+	log.Infof("contractAddress:%s,contractName:%s", contractAddress, config.Name)
+	return contractAddress, nil
+}
+
+// 发送部署交易并等待上链, 返回合约地址
+func (c *EthClient) deployCode(nonce uint64, code []byte) (string, error) {
+	txid, err := c.SendTransaction(models.CREATE_CONTRACT, nonce, "", "", code)
+	if err != nil {
+		return "", err
+	}
+	opType := "DeployContract"
+	var wg sync.WaitGroup
+	txResultStatus := make(chan models.TxResultStatus, 1)
+	wg.Add(1)
+	go c.JudgeUpChainStatus(*txid, opType, txResultStatus, &wg)
+	wg.Wait()
+	select {
+	case receipt := <-txResultStatus:
+		if receipt.Err != nil {
+			return "", receipt.Err
+		}
+		return receipt.Receipt.ContractAddress.Hex(), nil
+	default:
+		return "", fmt.Errorf(opType)
+	}
 }
 
 // 判断上链状态
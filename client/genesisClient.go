@@ -0,0 +1,25 @@
+package Client
+
+import (
+	"math/big"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/core"
+)
+
+// 离线计算创世块hash并与节点上的0号块比较, 不一致时返回 *core.GenesisMismatchError
+func (c *EthClient) VerifyGenesis(genesis *core.Genesis) (common.Hash, error) {
+	block, err := genesis.ToBlock()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	remote, err := c.ClientPara.Client.HeaderByNumber(*c.Ctx, big.NewInt(0))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if remote.Hash() != block.Hash() {
+		log.Debugf("genesis state root: node %x, local %x", remote.Root, block.Root())
+		return block.Hash(), &core.GenesisMismatchError{Stored: remote.Hash(), New: block.Hash()}
+	}
+	return block.Hash(), nil
+}
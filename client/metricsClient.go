@@ -0,0 +1,12 @@
+package Client
+
+import (
+	"github.com/ethclient/common/flogging/metrics"
+	"github.com/ethclient/rpc"
+)
+
+// 开启rpc调用统计: 按方法的请求数, 按错误码的错误数, 耗时, 进行中的请求数和订阅数
+// 指标名为 rpc_client_*, 需在client使用前调用
+func (c *EthClient) EnableMetrics(provider metrics.Provider) {
+	c.ClientPara.RpcClient.Use(rpc.MetricsInterceptor(provider))
+}
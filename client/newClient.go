@@ -3,6 +3,7 @@ package Client
 import (
 	"context"
 	"crypto/ecdsa"
+	"strings"
 
 	"github.com/ethclient/common/flogging"
 	"github.com/ethclient/ethclient"
@@ -15,12 +16,15 @@ var log = flogging.MustGetLogger("sipcclient.Client")
 
 type EthClient struct {
 	// input
-	Address string `json:"address"` // 节点的地址 IP+rpc port
+	Address string `json:"address"` // 节点的地址 IP+rpc port, 或 ws:// ipc:// 地址, 或ipc文件路径
 	// output
 	ClientPara *models.ClientPara  `json:"clientPara"` // client 参数
 	SignPrikey *ecdsa.PrivateKey   `json:"signPrikey"` // 交易签名参数
 	Ctx        *context.Context    `json:"ctx"`
 	Cancel     *context.CancelFunc `json:"cancel"`
+
+	verifyBlocks bool         // 是否校验节点返回的块
+	abiRegistry  *AbiRegistry // 用于解码块中交易调用的ABI注册表
 }
 
 // new 一个client
@@ -43,9 +47,17 @@ func NewClient(address string, signTxPara *models.SignTxPara) (*EthClient, error
 	return client, nil
 }
 
+// 节点地址转为rpc url, IP+rpc port 默认走http, 带scheme的地址或ipc文件路径原样返回
+func Endpoint(address string) string {
+	if strings.Contains(address, "://") || strings.HasPrefix(address, "/") {
+		return address
+	}
+	return "http://" + address
+}
+
 // client初始化
 func (c *EthClient) clientInit() error {
-	cli, err := rpc.Dial("http://"+c.Address, "", "", nil)
+	cli, err := rpc.Dial(Endpoint(c.Address), "", "", nil)
 	if err != nil {
 		log.Error(err.Error())
 		return err
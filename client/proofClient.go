@@ -0,0 +1,139 @@
+package Client
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/crypto"
+	"github.com/ethclient/models"
+	"github.com/ethclient/rlp"
+	"github.com/ethclient/trie"
+)
+
+// 账户和存储的merkle证明 eth_getProof, block为块高或latest/pending, 为空时取latest
+func (c *EthClient) GetProof(addr string, storageKeys []string, block string) (*models.AccountResult, error) {
+	if !common.IsHexAddress(addr) {
+		return nil, fmt.Errorf("invalid address %q", addr)
+	}
+	block, err := blockTag(block)
+	if err != nil {
+		return nil, err
+	}
+	if storageKeys == nil {
+		storageKeys = []string{}
+	}
+	var result models.AccountResult
+	err = c.ClientPara.RpcClient.CallContext(*c.Ctx, &result, "eth_getProof", common.HexToAddress(addr), storageKeys, block)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// 取指定块高的块头和证明, 并用块头的state root校验证明, number为nil时取最新块
+func (c *EthClient) GetVerifiedProof(addr string, storageKeys []string, number *big.Int) (*models.AccountResult, *types.Header, error) {
+	header, err := c.ClientPara.Client.HeaderByNumber(*c.Ctx, number)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := c.GetProof(addr, storageKeys, header.Number.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := VerifyProof(header.Root, common.HexToAddress(addr), storageKeys, result); err != nil {
+		return nil, nil, err
+	}
+	return result, header, nil
+}
+
+// 用可信的state root校验eth_getProof的结果, 包括账户字段和所有存储槽的值
+// 结果必须是请求的账户, 且按请求顺序恰好包含请求的存储槽
+func VerifyProof(root common.Hash, addr common.Address, storageKeys []string, result *models.AccountResult) error {
+	if result.Address != addr {
+		return fmt.Errorf("proof is for account %s, requested %s", result.Address.Hex(), addr.Hex())
+	}
+	if len(result.StorageProof) != len(storageKeys) {
+		return fmt.Errorf("account %s: %d storage proofs for %d requested keys", addr.Hex(), len(result.StorageProof), len(storageKeys))
+	}
+	for i, key := range storageKeys {
+		if have := result.StorageProof[i].Key; common.HexToHash(have) != common.HexToHash(key) {
+			return fmt.Errorf("account %s: storage proof %d is for key %s, requested %s", addr.Hex(), i, have, key)
+		}
+	}
+	value, err := trie.VerifyProof(root, crypto.Keccak256(result.Address[:]), proofNodes(result.AccountProof))
+	if err != nil {
+		return fmt.Errorf("account %s: %v", result.Address.Hex(), err)
+	}
+	balance := (*big.Int)(result.Balance)
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	storageRoot := result.StorageHash
+	if value == nil {
+		// 账户不存在, 节点返回的字段必须为空
+		if result.Nonce != 0 || balance.Sign() != 0 ||
+			(result.CodeHash != (common.Hash{}) && result.CodeHash != types.EmptyCodeHash) ||
+			(storageRoot != (common.Hash{}) && storageRoot != trie.EmptyRoot) {
+			return fmt.Errorf("account %s: proof shows no account but node returned state", result.Address.Hex())
+		}
+		storageRoot = trie.EmptyRoot
+	} else {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return fmt.Errorf("account %s: %v", result.Address.Hex(), err)
+		}
+		switch {
+		case account.Nonce != uint64(result.Nonce):
+			return fmt.Errorf("account %s: nonce mismatch: proof %d, node %d", result.Address.Hex(), account.Nonce, result.Nonce)
+		case account.Balance.Cmp(balance) != 0:
+			return fmt.Errorf("account %s: balance mismatch: proof %v, node %v", result.Address.Hex(), account.Balance, balance)
+		case !bytes.Equal(account.CodeHash, result.CodeHash[:]):
+			return fmt.Errorf("account %s: code hash mismatch: proof %x, node %x", result.Address.Hex(), account.CodeHash, result.CodeHash)
+		case account.Root != storageRoot:
+			return fmt.Errorf("account %s: storage hash mismatch: proof %x, node %x", result.Address.Hex(), account.Root, storageRoot)
+		}
+	}
+	for _, storage := range result.StorageProof {
+		if err := verifyStorageProof(storageRoot, storage); err != nil {
+			return fmt.Errorf("account %s: %v", result.Address.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// 校验单个存储槽, 不存在的槽值必须为0
+func verifyStorageProof(root common.Hash, storage models.StorageResult) error {
+	key := common.HexToHash(storage.Key)
+	value, err := trie.VerifyProof(root, crypto.Keccak256(key[:]), proofNodes(storage.Proof))
+	if err != nil {
+		return fmt.Errorf("storage %s: %v", storage.Key, err)
+	}
+	proven := new(big.Int)
+	if value != nil {
+		_, content, _, err := rlp.Split(value)
+		if err != nil {
+			return fmt.Errorf("storage %s: %v", storage.Key, err)
+		}
+		proven.SetBytes(content)
+	}
+	claimed := (*big.Int)(storage.Value)
+	if claimed == nil {
+		claimed = new(big.Int)
+	}
+	if proven.Cmp(claimed) != 0 {
+		return fmt.Errorf("storage %s: value mismatch: proof %v, node %v", storage.Key, proven, claimed)
+	}
+	return nil
+}
+
+func proofNodes(proof []hexutil.Bytes) [][]byte {
+	nodes := make([][]byte, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return nodes
+}
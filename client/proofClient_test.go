@@ -0,0 +1,106 @@
+package Client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/crypto"
+	"github.com/ethclient/models"
+	"github.com/ethclient/rlp"
+	"github.com/ethclient/trie"
+)
+
+func toProof(nodes [][]byte) []hexutil.Bytes {
+	proof := make([]hexutil.Bytes, len(nodes))
+	for i, node := range nodes {
+		proof[i] = node
+	}
+	return proof
+}
+
+// 本地构造状态树, 模拟eth_getProof的返回
+func TestVerifyProof(t *testing.T) {
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	slot := common.BigToHash(big.NewInt(1))
+	storage := trie.New()
+	value, _ := rlp.EncodeToBytes(big.NewInt(1000))
+	storage.Update(crypto.Keccak256(slot[:]), value)
+	codeHash := crypto.Keccak256Hash([]byte{0x60, 0x00})
+	account, _ := rlp.EncodeToBytes(&types.StateAccount{Nonce: 2, Balance: big.NewInt(5), Root: storage.Hash(), CodeHash: codeHash[:]})
+	state := trie.New()
+	state.Update(crypto.Keccak256(addr[:]), account)
+	for i := byte(0); i < 50; i++ {
+		other := common.BytesToAddress([]byte{i})
+		state.Update(crypto.Keccak256(other[:]), account)
+	}
+	missing := common.BigToHash(big.NewInt(2))
+	result := &models.AccountResult{
+		Address:      addr,
+		AccountProof: toProof(state.Prove(crypto.Keccak256(addr[:]))),
+		Balance:      (*hexutil.Big)(big.NewInt(5)),
+		CodeHash:     codeHash,
+		Nonce:        2,
+		StorageHash:  storage.Hash(),
+		StorageProof: []models.StorageResult{
+			{Key: slot.Hex(), Value: (*hexutil.Big)(big.NewInt(1000)), Proof: toProof(storage.Prove(crypto.Keccak256(slot[:])))},
+			{Key: "0x2", Value: (*hexutil.Big)(big.NewInt(0)), Proof: toProof(storage.Prove(crypto.Keccak256(missing[:])))},
+		},
+	}
+	keys := []string{slot.Hex(), "0x02"}
+	if err := VerifyProof(state.Hash(), addr, keys, result); err != nil {
+		t.Fatal(err)
+	}
+
+	// 节点替换账户, 或丢弃、调换存储槽
+	other := common.BytesToAddress([]byte{1})
+	substituted := *result
+	substituted.Address = other
+	substituted.AccountProof = toProof(state.Prove(crypto.Keccak256(other[:])))
+	if err := VerifyProof(state.Hash(), other, keys, &substituted); err != nil {
+		t.Fatalf("substituted proof should be valid on its own: %v", err)
+	}
+	if err := VerifyProof(state.Hash(), addr, keys, &substituted); err == nil {
+		t.Fatal("expected substituted account to fail")
+	}
+	dropped := *result
+	dropped.StorageProof = result.StorageProof[:1]
+	if err := VerifyProof(state.Hash(), addr, keys, &dropped); err == nil {
+		t.Fatal("expected missing storage proof to fail")
+	}
+	swapped := *result
+	swapped.StorageProof = []models.StorageResult{result.StorageProof[1], result.StorageProof[0]}
+	if err := VerifyProof(state.Hash(), addr, keys, &swapped); err == nil {
+		t.Fatal("expected swapped storage proofs to fail")
+	}
+	if err := VerifyProof(state.Hash(), addr, []string{slot.Hex(), "0x03"}, result); err == nil {
+		t.Fatal("expected proof for another slot to fail")
+	}
+
+	result.Balance = (*hexutil.Big)(big.NewInt(6))
+	if err := VerifyProof(state.Hash(), addr, keys, result); err == nil {
+		t.Fatal("expected balance mismatch")
+	}
+	result.Balance = (*hexutil.Big)(big.NewInt(5))
+	result.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(999))
+	if err := VerifyProof(state.Hash(), addr, keys, result); err == nil {
+		t.Fatal("expected storage value mismatch")
+	}
+	result.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(1000))
+	if err := VerifyProof(common.Hash{1}, addr, keys, result); err == nil {
+		t.Fatal("expected proof to fail against another root")
+	}
+
+	// 不存在的账户
+	absent := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	empty := &models.AccountResult{Address: absent, AccountProof: toProof(state.Prove(crypto.Keccak256(absent[:])))}
+	if err := VerifyProof(state.Hash(), absent, nil, empty); err != nil {
+		t.Fatal(err)
+	}
+	empty.Balance = (*hexutil.Big)(big.NewInt(1))
+	if err := VerifyProof(state.Hash(), absent, nil, empty); err == nil {
+		t.Fatal("expected absent account with balance to fail")
+	}
+}
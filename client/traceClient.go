@@ -0,0 +1,195 @@
+package Client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/models"
+)
+
+var (
+	revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector  = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+)
+
+// 交易追踪 debug_traceTransaction
+func (c *EthClient) TraceTransaction(txHash string, config *models.TraceConfig) (*models.TraceResult, error) {
+	var raw json.RawMessage
+	err := c.ClientPara.RpcClient.CallContext(*c.Ctx, &raw, "debug_traceTransaction", common.HexToHash(txHash), config)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTraceResult(raw, config)
+}
+
+// 调用追踪 debug_traceCall, block为块高或latest/pending, 为空时取latest
+func (c *EthClient) TraceCall(args models.SendTxArgs, block string, config *models.TraceConfig) (*models.TraceResult, error) {
+	block, err := blockTag(block)
+	if err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	err = c.ClientPara.RpcClient.CallContext(*c.Ctx, &raw, "debug_traceCall", args, block, config)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTraceResult(raw, config)
+}
+
+// 按tracer类型解析trace结果
+func decodeTraceResult(raw json.RawMessage, config *models.TraceConfig) (*models.TraceResult, error) {
+	result := &models.TraceResult{Raw: raw}
+	if config != nil {
+		result.Tracer = config.Tracer
+	}
+	var err error
+	switch result.Tracer {
+	case models.StructLogger:
+		result.StructLogs = new(models.ExecutionResult)
+		err = json.Unmarshal(raw, result.StructLogs)
+	case models.CallTracer:
+		result.Call = new(models.CallFrame)
+		err = json.Unmarshal(raw, result.Call)
+	case models.PrestateTracer:
+		var tracerConfig struct {
+			DiffMode bool `json:"diffMode"`
+		}
+		if len(config.TracerConfig) > 0 {
+			if err = json.Unmarshal(config.TracerConfig, &tracerConfig); err != nil {
+				return nil, err
+			}
+		}
+		if tracerConfig.DiffMode {
+			result.PrestateDiff = new(models.PrestateDiff)
+			err = json.Unmarshal(raw, result.PrestateDiff)
+		} else {
+			err = json.Unmarshal(raw, &result.Prestate)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode %q trace result: %v", result.Tracer, err)
+	}
+	return result, nil
+}
+
+// 格式化callTracer结果, abis为合约地址到ABI的映射, 用于解码调用参数, 并标出回滚点
+func FormatCallTrace(frame *models.CallFrame, abis map[common.Address]abi.ABI) string {
+	var buf bytes.Buffer
+	writeCallFrame(&buf, frame, abis, frame.RevertFrame(), 0)
+	return buf.String()
+}
+
+func writeCallFrame(buf *bytes.Buffer, frame *models.CallFrame, abis map[common.Address]abi.ABI, revert *models.CallFrame, depth int) {
+	indent := strings.Repeat("  ", depth)
+	to := "<create>"
+	if frame.To != nil {
+		to = frame.To.Hex()
+	}
+	fmt.Fprintf(buf, "%s[%s] %s -> %s", indent, frame.Type, frame.From.Hex(), to)
+	if frame.Value != nil && frame.Value.ToInt().Sign() > 0 {
+		fmt.Fprintf(buf, " value=%s", frame.Value.ToInt())
+	}
+	fmt.Fprintf(buf, " gas=%d/%d\n", uint64(frame.GasUsed), uint64(frame.Gas))
+	if call := formatCallInput(frame, abis); call != "" {
+		fmt.Fprintf(buf, "%s  %s\n", indent, call)
+	}
+	if frame.Error != "" {
+		fmt.Fprintf(buf, "%s  error: %s", indent, frame.Error)
+		if reason := revertReason(frame); reason != "" {
+			fmt.Fprintf(buf, " (%s)", reason)
+		}
+		if frame == revert {
+			buf.WriteString("  <== revert point")
+		}
+		buf.WriteString("\n")
+	}
+	for i := range frame.Calls {
+		writeCallFrame(buf, &frame.Calls[i], abis, revert, depth+1)
+	}
+}
+
+// 用ABI解码调用参数, 找不到方法时输出selector
+func formatCallInput(frame *models.CallFrame, abis map[common.Address]abi.ABI) string {
+	if len(frame.Input) < 4 || frame.To == nil {
+		return ""
+	}
+	method := lookupMethod(*frame.To, frame.Input, abis)
+	if method == nil {
+		return fmt.Sprintf("%#x(%d bytes)", []byte(frame.Input[:4]), len(frame.Input)-4)
+	}
+	values, err := method.Inputs.UnpackValues(frame.Input[4:])
+	if err != nil {
+		return fmt.Sprintf("%s(<undecodable: %v>)", method.RawName, err)
+	}
+	args := make([]string, len(values))
+	for i, value := range values {
+		args[i] = fmt.Sprintf("%s=%s", method.Inputs[i].Name, formatTraceValue(value))
+	}
+	return fmt.Sprintf("%s(%s)", method.RawName, strings.Join(args, ", "))
+}
+
+// 优先用目标地址的ABI, 否则依次尝试其他ABI(代理合约等)
+func lookupMethod(to common.Address, input []byte, abis map[common.Address]abi.ABI) *abi.Method {
+	if contract, ok := abis[to]; ok {
+		if method, err := contract.MethodById(input); err == nil {
+			return method
+		}
+	}
+	for _, contract := range abis {
+		if method, err := contract.MethodById(input); err == nil {
+			return method
+		}
+	}
+	return nil
+}
+
+func formatTraceValue(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return hexutil.Encode(v)
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	case common.Address:
+		return v.Hex()
+	case []common.Address:
+		addrs := make([]string, len(v))
+		for i, addr := range v {
+			addrs[i] = addr.Hex()
+		}
+		return "[" + strings.Join(addrs, " ") + "]"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// 回滚原因, 解码Error(string)和Panic(uint256)
+func revertReason(frame *models.CallFrame) string {
+	if frame.RevertReason != "" {
+		return frame.RevertReason
+	}
+	output := []byte(frame.Output)
+	if len(output) < 4 {
+		return ""
+	}
+	switch {
+	case bytes.Equal(output[:4], revertSelector):
+		typ, _ := abi.NewType("string", "", nil)
+		values, err := abi.Arguments{{Type: typ}}.UnpackValues(output[4:])
+		if err == nil {
+			return values[0].(string)
+		}
+	case bytes.Equal(output[:4], panicSelector):
+		typ, _ := abi.NewType("uint256", "", nil)
+		values, err := abi.Arguments{{Type: typ}}.UnpackValues(output[4:])
+		if err == nil {
+			return fmt.Sprintf("panic code %#x", values[0].(*big.Int))
+		}
+	}
+	return hexutil.Encode(output)
+}
@@ -0,0 +1,206 @@
+package Client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/models"
+)
+
+const (
+	// Error("nope")
+	revertNope = "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000"
+	// Panic(0x11), 算术溢出
+	panicOverflow = "0x4e487b71" +
+		"0000000000000000000000000000000000000000000000000000000000000011"
+
+	callTrace = `{
+  "type": "CALL",
+  "from": "0x0000000000000000000000000000000000000001",
+  "to": "0x0000000000000000000000000000000000000002",
+  "value": "0x0",
+  "gas": "0x7530",
+  "gasUsed": "0x5208",
+  "input": "0xa9059cbb000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000000000000000000000000000000000003e8",
+  "output": "` + revertNope + `",
+  "error": "execution reverted",
+  "calls": [{
+    "type": "STATICCALL",
+    "from": "0x0000000000000000000000000000000000000002",
+    "to": "0x0000000000000000000000000000000000000003",
+    "gas": "0x1000",
+    "gasUsed": "0x100",
+    "input": "0x70a08231"
+  }, {
+    "type": "CALL",
+    "from": "0x0000000000000000000000000000000000000002",
+    "to": "0x0000000000000000000000000000000000000003",
+    "value": "0xa",
+    "gas": "0x2000",
+    "gasUsed": "0x2000",
+    "input": "0x",
+    "output": "` + revertNope + `",
+    "error": "execution reverted"
+  }]
+}`
+
+	prestateTrace = `{
+  "0x0000000000000000000000000000000000000001": {"balance": "0x100", "nonce": 2},
+  "0x0000000000000000000000000000000000000002": {
+    "balance": "0x0",
+    "code": "0x6000",
+    "storage": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000005"}
+  }
+}`
+
+	prestateDiffTrace = `{
+  "pre": {"0x0000000000000000000000000000000000000001": {"balance": "0x100", "nonce": 2}},
+  "post": {"0x0000000000000000000000000000000000000001": {"balance": "0xf0", "nonce": 3}}
+}`
+
+	structLogTrace = `{
+  "gas": 21510,
+  "failed": true,
+  "returnValue": "08c379a0",
+  "structLogs": [
+    {"pc": 0, "op": "PUSH1", "gas": 9000, "gasCost": 3, "depth": 1, "stack": []},
+    {"pc": 2, "op": "PUSH1", "gas": 8997, "gasCost": 3, "depth": 1, "stack": ["0x0"]},
+    {"pc": 4, "op": "REVERT", "gas": 8994, "gasCost": 0, "depth": 1, "stack": ["0x0", "0x0"]}
+  ]
+}`
+)
+
+func TestDecodeTraceResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		config *models.TraceConfig
+		check  func(t *testing.T, result *models.TraceResult)
+	}{
+		{
+			name: "structLog",
+			raw:  structLogTrace,
+			check: func(t *testing.T, result *models.TraceResult) {
+				if result.StructLogs == nil || len(result.StructLogs.StructLogs) != 3 {
+					t.Fatalf("unexpected struct logs %+v", result.StructLogs)
+				}
+				if revert := result.StructLogs.RevertLog(); revert == nil || revert.Pc != 4 {
+					t.Errorf("revert log %+v, want pc 4", revert)
+				}
+			},
+		},
+		{
+			name:   "callTracer",
+			raw:    callTrace,
+			config: &models.TraceConfig{Tracer: models.CallTracer},
+			check: func(t *testing.T, result *models.TraceResult) {
+				if result.Call == nil || len(result.Call.Calls) != 2 {
+					t.Fatalf("unexpected call frame %+v", result.Call)
+				}
+				if revert := result.Call.RevertFrame(); revert != &result.Call.Calls[1] {
+					t.Errorf("revert frame %+v, want the second subcall", revert)
+				}
+			},
+		},
+		{
+			name:   "prestateTracer",
+			raw:    prestateTrace,
+			config: &models.TraceConfig{Tracer: models.PrestateTracer},
+			check: func(t *testing.T, result *models.TraceResult) {
+				account := result.Prestate[common.HexToAddress("0x02")]
+				if len(result.Prestate) != 2 || account == nil {
+					t.Fatalf("unexpected prestate %+v", result.Prestate)
+				}
+				if account.Storage[common.HexToHash("0x01")] != common.HexToHash("0x05") || hexutil.Encode(account.Code) != "0x6000" {
+					t.Errorf("unexpected account %+v", account)
+				}
+			},
+		},
+		{
+			name:   "prestateTracer diffMode",
+			raw:    prestateDiffTrace,
+			config: &models.TraceConfig{Tracer: models.PrestateTracer, TracerConfig: json.RawMessage(`{"diffMode": true}`)},
+			check: func(t *testing.T, result *models.TraceResult) {
+				if result.PrestateDiff == nil {
+					t.Fatal("missing prestate diff")
+				}
+				post := result.PrestateDiff.Post[common.HexToAddress("0x01")]
+				if post == nil || post.Nonce != 3 || post.Balance.ToInt().Int64() != 0xf0 {
+					t.Errorf("unexpected post state %+v", post)
+				}
+			},
+		},
+		{
+			name:   "custom tracer",
+			raw:    `[1, 2, 3]`,
+			config: &models.TraceConfig{Tracer: "{result: function() { return [1, 2, 3]; }}"},
+			check: func(t *testing.T, result *models.TraceResult) {
+				if result.Call != nil || result.StructLogs != nil || string(result.Raw) != `[1, 2, 3]` {
+					t.Errorf("custom tracer result not kept raw: %+v", result)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := decodeTraceResult(json.RawMessage(test.raw), test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.check(t, result)
+		})
+	}
+
+	if _, err := decodeTraceResult(json.RawMessage(`[]`), &models.TraceConfig{Tracer: models.CallTracer}); err == nil {
+		t.Error("expected an error for a malformed callTracer result")
+	}
+}
+
+func TestFormatCallTrace(t *testing.T) {
+	var frame models.CallFrame
+	if err := json.Unmarshal([]byte(callTrace), &frame); err != nil {
+		t.Fatal(err)
+	}
+	token, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	abis := map[common.Address]abi.ABI{common.HexToAddress("0x02"): token}
+	want := `[CALL] 0x0000000000000000000000000000000000000001 -> 0x0000000000000000000000000000000000000002 gas=21000/30000
+  transfer(to=0x0000000000000000000000000000000000000003, amount=1000)
+  error: execution reverted (nope)
+  [STATICCALL] 0x0000000000000000000000000000000000000002 -> 0x0000000000000000000000000000000000000003 gas=256/4096
+    0x70a08231(0 bytes)
+  [CALL] 0x0000000000000000000000000000000000000002 -> 0x0000000000000000000000000000000000000003 value=10 gas=8192/8192
+    error: execution reverted (nope)  <== revert point
+`
+	if got := FormatCallTrace(&frame, abis); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRevertReason(t *testing.T) {
+	tests := []struct {
+		frame models.CallFrame
+		want  string
+	}{
+		{models.CallFrame{Output: hexutil.MustDecode(revertNope)}, "nope"},
+		{models.CallFrame{Output: hexutil.MustDecode(panicOverflow)}, "panic code 0x11"},
+		{models.CallFrame{RevertReason: "from node", Output: hexutil.MustDecode(revertNope)}, "from node"},
+		{models.CallFrame{Output: hexutil.MustDecode("0xdeadbeef01")}, "0xdeadbeef01"},
+		{models.CallFrame{Output: hexutil.MustDecode("0x08c379a0")}, "0x08c379a0"},
+		{models.CallFrame{}, ""},
+	}
+	for i, test := range tests {
+		if got := revertReason(&test.frame); got != test.want {
+			t.Errorf("test %d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
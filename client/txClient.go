@@ -54,3 +54,155 @@ func (c *EthClient) SendTransaction(opType int, nonce uint64, to string, amount
 	txHash := signedTx.Hash().Hex()
 	return &txHash, nil
 }
+
+// 发送EIP-2718类型交易, to为空时创建合约
+// 动态费用交易未指定费用时, gasTipCap取节点建议值, gasFeeCap取 2*baseFee+gasTipCap
+func (c *EthClient) SendTypedTransaction(opts *models.TxOptions, nonce uint64, to string, amount string, data []byte) (*string, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("tx options are required")
+	}
+	amountBigInt, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		amountBigInt = new(big.Int)
+	}
+	var toAddr *common.Address
+	if to != "" {
+		if !common.IsHexAddress(to) {
+			return nil, fmt.Errorf("%s is not HexAddress", to)
+		}
+		addr := common.HexToAddress(to)
+		toAddr = &addr
+	}
+	chainID, err := c.ClientPara.Client.ChainID(*c.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain id: %v", err)
+	}
+	msg := ethclient.CallMsg{
+		From:       crypto.PubkeyToAddress(c.SignPrikey.PublicKey),
+		To:         toAddr,
+		Value:      amountBigInt,
+		Data:       data,
+		AccessList: opts.AccessList,
+	}
+	switch opts.Type {
+	case types.AccessListTxType:
+		if msg.GasPrice, err = c.ClientPara.Client.SuggestGasPrice(*c.Ctx); err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	case types.DynamicFeeTxType:
+		if msg.GasTipCap, msg.GasFeeCap, err = c.suggestFees(opts); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", opts.Type)
+	}
+	if opts.CreateAccessList {
+		accessList, _, vmErr, err := c.ClientPara.Client.CreateAccessList(*c.Ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create access list: %v", err)
+		}
+		if vmErr != "" {
+			return nil, fmt.Errorf("failed to create access list: %s", vmErr)
+		}
+		if accessList != nil {
+			msg.AccessList = *accessList
+		}
+	}
+	gasLimit, err := c.ClientPara.Client.EstimateGas(*c.Ctx, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas needed: %v", err)
+	}
+	var rawTx *types.Transaction
+	if opts.Type == types.AccessListTxType {
+		rawTx = types.NewTx(&types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasPrice:   msg.GasPrice,
+			Gas:        gasLimit,
+			To:         toAddr,
+			Value:      amountBigInt,
+			Data:       data,
+			AccessList: msg.AccessList,
+		})
+	} else {
+		rawTx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasTipCap:  msg.GasTipCap,
+			GasFeeCap:  msg.GasFeeCap,
+			Gas:        gasLimit,
+			To:         toAddr,
+			Value:      amountBigInt,
+			Data:       data,
+			AccessList: msg.AccessList,
+		})
+	}
+	signedTx, err := types.SignTx(rawTx, types.NewLondonSigner(chainID), c.SignPrikey)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ClientPara.Client.SendTransaction(*c.Ctx, signedTx); err != nil {
+		return nil, err
+	}
+	txHash := signedTx.Hash().Hex()
+	return &txHash, nil
+}
+
+// 计算动态费用交易的gasTipCap和gasFeeCap
+func (c *EthClient) suggestFees(opts *models.TxOptions) (*big.Int, *big.Int, error) {
+	tip := opts.GasTipCap
+	if tip == nil {
+		var err error
+		if tip, err = c.ClientPara.Client.SuggestGasTipCap(*c.Ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %v", err)
+		}
+	}
+	if opts.GasFeeCap != nil {
+		return tip, opts.GasFeeCap, nil
+	}
+	head, err := c.ClientPara.Client.HeaderByNumber(*c.Ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("node does not support EIP-1559 transactions")
+	}
+	feeCap := new(big.Int).Mul(head.BaseFee, big.NewInt(2))
+	return tip, feeCap.Add(feeCap, tip), nil
+}
+
+// 为交易生成访问列表, 返回访问列表及使用访问列表时消耗的gas, from为空时使用签名私钥的地址
+func (c *EthClient) CreateAccessList(from string, to string, amount string, data []byte) (types.AccessList, uint64, error) {
+	amountBigInt, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		amountBigInt = new(big.Int)
+	}
+	msg := ethclient.CallMsg{Value: amountBigInt, Data: data}
+	switch {
+	case from != "":
+		if !common.IsHexAddress(from) {
+			return nil, 0, fmt.Errorf("%s is not HexAddress", from)
+		}
+		msg.From = common.HexToAddress(from)
+	case c.SignPrikey != nil:
+		msg.From = crypto.PubkeyToAddress(c.SignPrikey.PublicKey)
+	}
+	if to != "" {
+		if !common.IsHexAddress(to) {
+			return nil, 0, fmt.Errorf("%s is not HexAddress", to)
+		}
+		addr := common.HexToAddress(to)
+		msg.To = &addr
+	}
+	accessList, gasUsed, vmErr, err := c.ClientPara.Client.CreateAccessList(*c.Ctx, msg)
+	if err != nil {
+		return nil, 0, err
+	}
+	if vmErr != "" {
+		return nil, 0, fmt.Errorf("execution reverted: %s", vmErr)
+	}
+	if accessList == nil {
+		return types.AccessList{}, gasUsed, nil
+	}
+	return *accessList, gasUsed, nil
+}
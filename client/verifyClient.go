@@ -0,0 +1,60 @@
+package Client
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/models"
+)
+
+// 开启或关闭块校验, 开启后 ClientPara.Client 的 BlockByNumber BlockByHash, GetBlockByBlockNumOrHash
+// 和 GetMixedBlockByBlockNumOrHash 会检查返回的是请求的块, 并重新计算块hash, 交易根, receipt根和bloom,
+// 不一致时返回 *ethclient.IntegrityError
+func (c *EthClient) SetBlockVerification(enabled bool) {
+	c.verifyBlocks = enabled
+	c.ClientPara.Client.SetBlockVerification(enabled)
+}
+
+// 用同一份块和receipt数据做校验和组装, 避免校验的数据和返回的数据不一致
+func verifiedMixedBlock(c *EthClient, blockInfo []byte, block *models.Block, method, arg string) (*models.MixedBlock, error) {
+	details, err := verifyBlockInfo(c, blockInfo, method, arg)
+	if err != nil {
+		return nil, err
+	}
+	return mixBlock(block, details)
+}
+
+// 校验节点返回的块数据: 必须是请求的块hash或块高, 块hash, 交易根, receipt根和bloom与块头一致
+// receipt通过ethclient批量获取, 返回校验时取得的receipt
+func verifyBlockInfo(c *EthClient, blockInfo []byte, method, arg string) ([]*models.Receipt, error) {
+	var (
+		wantHash   *common.Hash
+		wantNumber *big.Int
+	)
+	switch method {
+	case "eth_getBlockByHash":
+		hash := common.HexToHash(arg)
+		wantHash = &hash
+	case "eth_getBlockByNumber":
+		if number, err := hexutil.DecodeBig(arg); err == nil {
+			wantNumber = number
+		}
+	}
+	raws, err := c.ClientPara.Client.VerifyBlockJSON(*c.Ctx, blockInfo, wantHash, wantNumber)
+	if err != nil {
+		return nil, err
+	}
+	details := make([]*models.Receipt, len(raws))
+	for i, raw := range raws {
+		details[i] = new(models.Receipt)
+		if err := json.Unmarshal(raw, details[i]); err != nil {
+			return nil, err
+		}
+		if err := ExchangeBlockReceipt(details[i]); err != nil {
+			return nil, err
+		}
+	}
+	return details, nil
+}
@@ -50,6 +50,7 @@ type ContractInfo struct {
 	UserDoc         interface{} `json:"userDoc"`
 	DeveloperDoc    interface{} `json:"developerDoc"`
 	Metadata        string      `json:"metadata"`
+	StorageLayout   interface{} `json:"storageLayout,omitempty"`
 }
 
 func slurpFiles(files []string) (string, error) {
@@ -0,0 +1,386 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	importRegexp  = regexp.MustCompile(`\bimport\s+[^;"']*["']([^"']+)["']`)
+	commentRegexp = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+)
+
+// defaultOutputSelection requests everything ParseStandardJSON reads.
+var defaultOutputSelection = map[string]map[string][]string{
+	"*": {"*": {
+		"abi",
+		"evm.bytecode.object",
+		"evm.bytecode.sourceMap",
+		"evm.deployedBytecode.object",
+		"evm.deployedBytecode.sourceMap",
+		"evm.methodIdentifiers",
+		"metadata",
+		"storageLayout",
+		"devdoc",
+		"userdoc",
+	}},
+}
+
+// StandardInput is the input of solc --standard-json.
+type StandardInput struct {
+	Language string            `json:"language"`
+	Sources  map[string]Source `json:"sources"`
+	Settings Settings          `json:"settings"`
+}
+
+// Source is a source unit of a standard-JSON input.
+type Source struct {
+	Content string `json:"content"`
+}
+
+// Settings are the compiler settings of a standard-JSON input.
+type Settings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       Optimizer                      `json:"optimizer"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	ViaIR           bool                           `json:"viaIR,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection"`
+}
+
+// Optimizer are the optimizer settings of a standard-JSON input.
+type Optimizer struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs"`
+}
+
+// standard-json output format
+type standardOutput struct {
+	Errors []struct {
+		Severity         string
+		Message          string
+		FormattedMessage string
+	}
+	Contracts map[string]map[string]struct {
+		Abi           json.RawMessage
+		Metadata      string
+		Devdoc        json.RawMessage
+		Userdoc       json.RawMessage
+		StorageLayout json.RawMessage
+		Evm           struct {
+			Bytecode          standardBytecode
+			DeployedBytecode  standardBytecode
+			MethodIdentifiers map[string]string
+		}
+	}
+}
+
+type standardBytecode struct {
+	Object    string
+	SourceMap string
+}
+
+// Project is a multi-file Solidity project compiled through the standard-JSON
+// interface. Starting from Sources, imports are resolved like solc does:
+// relative imports against the importing source unit, then remappings, and
+// the resulting source unit names are looked up in BasePath and IncludePaths.
+type Project struct {
+	BasePath     string   // root of the source unit names, the working directory when empty
+	IncludePaths []string // further directories to look up source units in, e.g. node_modules
+	Sources      []string // files to compile
+	Remappings   []string // [context:]prefix=target
+	Optimize     bool
+	Runs         int    // optimizer runs, 200 when zero
+	EVMVersion   string // target EVM version, the compiler default when empty
+	ViaIR        bool   // compile through the Yul IR pipeline
+	CacheDir     string // directory of the artifact cache, caching is disabled when empty
+}
+
+// Input loads the project sources and their imports into a standard-JSON input.
+func (p *Project) Input() (*StandardInput, error) {
+	if len(p.Sources) == 0 {
+		return nil, errors.New("solc: no source files")
+	}
+	for _, r := range p.Remappings {
+		if !strings.Contains(r, "=") {
+			return nil, fmt.Errorf("solc: invalid remapping %q", r)
+		}
+	}
+	base, err := filepath.Abs(p.BasePath)
+	if err != nil {
+		return nil, err
+	}
+	input := &StandardInput{
+		Language: "Solidity",
+		Sources:  make(map[string]Source),
+		Settings: Settings{
+			Remappings:      p.Remappings,
+			Optimizer:       Optimizer{Enabled: p.Optimize, Runs: p.Runs},
+			EVMVersion:      p.EVMVersion,
+			ViaIR:           p.ViaIR,
+			OutputSelection: defaultOutputSelection,
+		},
+	}
+	if input.Settings.Optimizer.Runs == 0 {
+		input.Settings.Optimizer.Runs = 200
+	}
+	for _, file := range p.Sources {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(base, abs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("solc: %s is outside of the base path %s", file, base)
+		}
+		if err := p.load(base, filepath.ToSlash(rel), input.Sources); err != nil {
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
+// load adds the source unit and, recursively, its imports to sources.
+func (p *Project) load(base, unit string, sources map[string]Source) error {
+	if _, ok := sources[unit]; ok {
+		return nil
+	}
+	content, err := p.read(base, unit)
+	if err != nil {
+		return err
+	}
+	sources[unit] = Source{Content: content}
+	for _, match := range importRegexp.FindAllStringSubmatch(commentRegexp.ReplaceAllString(content, ""), -1) {
+		if err := p.load(base, p.resolve(unit, match[1]), sources); err != nil {
+			return fmt.Errorf("%s: %v", unit, err)
+		}
+	}
+	return nil
+}
+
+// read returns the content of a source unit.
+func (p *Project) read(base, unit string) (string, error) {
+	if path.IsAbs(unit) {
+		content, err := ioutil.ReadFile(filepath.FromSlash(unit))
+		return string(content), err
+	}
+	for _, dir := range append([]string{base}, p.IncludePaths...) {
+		content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(unit)))
+		if err == nil {
+			return string(content), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("solc: source %q not found in the base or include paths", unit)
+}
+
+// resolve returns the source unit name an import in unit refers to.
+func (p *Project) resolve(unit, imp string) string {
+	if strings.HasPrefix(imp, "./") || strings.HasPrefix(imp, "../") {
+		imp = path.Join(path.Dir(unit), imp)
+	}
+	// The longest matching context wins, then the longest prefix.
+	var (
+		found                   bool
+		target, context, prefix string
+	)
+	for _, r := range p.Remappings {
+		eq := strings.Index(r, "=")
+		ctx, pre := "", r[:eq]
+		if colon := strings.Index(pre, ":"); colon >= 0 {
+			ctx, pre = pre[:colon], pre[colon+1:]
+		}
+		if !strings.HasPrefix(unit, ctx) || !strings.HasPrefix(imp, pre) {
+			continue
+		}
+		if !found || len(ctx) > len(context) || len(ctx) == len(context) && len(pre) > len(prefix) {
+			found, target, context, prefix = true, r[eq+1:], ctx, pre
+		}
+	}
+	if found {
+		imp = target + imp[len(prefix):]
+	}
+	return imp
+}
+
+// CompileProject compiles a project through the standard-JSON interface.
+// With a cache directory set, the output is stored under a hash of the
+// compiler version and the input, so unchanged projects are not recompiled.
+func (s *Solidity) CompileProject(p *Project) (map[string]*Contract, error) {
+	input, err := p.Input()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		output []byte
+		cached string
+	)
+	if p.CacheDir != "" {
+		key, err := s.cacheKey(input)
+		if err != nil {
+			return nil, err
+		}
+		cached = filepath.Join(p.CacheDir, key+".json")
+		output, _ = ioutil.ReadFile(cached)
+	}
+	if output == nil {
+		if output, err = s.CompileStandard(input); err != nil {
+			return nil, err
+		}
+	}
+	contracts, err := ParseStandardJSON(output, input, s.Version, s.Version)
+	if err != nil {
+		return nil, err
+	}
+	if cached != "" {
+		if err := writeCache(cached, output); err != nil {
+			return nil, err
+		}
+	}
+	return contracts, nil
+}
+
+// CompileStandard runs solc --standard-json and returns its raw output.
+func (s *Solidity) CompileStandard(input *StandardInput) ([]byte, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	var stderr, stdout bytes.Buffer
+	cmd := exec.Command(s.Path, "--standard-json")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+	}
+	return stdout.Bytes(), nil
+}
+
+func (s *Solidity) cacheKey(input *StandardInput) (string, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(s.FullVersion))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeCache stores a compiler output through a temporary file, so readers
+// never see partial outputs.
+func writeCache(file string, output []byte) error {
+	if _, err := os.Stat(file); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(output); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// ParseStandardJSON takes the output of a solc --standard-json run on input
+// and parses it into a map of "source unit:contract name" to Contract
+// structs.
+//
+// Returns an error if the output reports compilation errors, or if the JSON
+// is malformed.
+func ParseStandardJSON(output []byte, input *StandardInput, languageVersion string, compilerVersion string) (map[string]*Contract, error) {
+	var out standardOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, e := range out.Errors {
+		if e.Severity != "error" {
+			continue
+		}
+		msg := e.FormattedMessage
+		if msg == "" {
+			msg = e.Message
+		}
+		errs = append(errs, strings.TrimSpace(msg))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("solc: %s", strings.Join(errs, "\n"))
+	}
+	settings, err := json.Marshal(input.Settings)
+	if err != nil {
+		return nil, err
+	}
+	contracts := make(map[string]*Contract)
+	for unit, named := range out.Contracts {
+		for name, info := range named {
+			var abi, userdoc, devdoc, layout interface{}
+			if err := json.Unmarshal(info.Abi, &abi); err != nil {
+				return nil, fmt.Errorf("solc: error reading abi definition of %s:%s (%v)", unit, name, err)
+			}
+			json.Unmarshal(info.Userdoc, &userdoc)
+			json.Unmarshal(info.Devdoc, &devdoc)
+			json.Unmarshal(info.StorageLayout, &layout)
+
+			contracts[unit+":"+name] = &Contract{
+				Code:        "0x" + info.Evm.Bytecode.Object,
+				RuntimeCode: "0x" + info.Evm.DeployedBytecode.Object,
+				Hashes:      info.Evm.MethodIdentifiers,
+				Info: ContractInfo{
+					Source:          input.Sources[unit].Content,
+					Language:        "Solidity",
+					LanguageVersion: languageVersion,
+					CompilerVersion: compilerVersion,
+					CompilerOptions: string(settings),
+					SrcMap:          info.Evm.Bytecode.SourceMap,
+					SrcMapRuntime:   info.Evm.DeployedBytecode.SourceMap,
+					AbiDefinition:   abi,
+					UserDoc:         userdoc,
+					DeveloperDoc:    devdoc,
+					Metadata:        info.Metadata,
+					StorageLayout:   layout,
+				},
+			}
+		}
+	}
+	return contracts, nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeSolc is a solc stand-in that records its input and the number of runs
+// next to itself and replies with output.json.
+const fakeSolc = `#!/bin/sh
+dir=$(dirname "$0")
+if [ "$1" = "--version" ]; then
+	echo "solc, the solidity compiler commandline interface"
+	echo "Version: 0.8.19+commit.7dd6d404.Linux.g++"
+	exit 0
+fi
+cat > "$dir/input.json"
+echo "$@" >> "$dir/runs"
+cat "$dir/output.json"
+`
+
+const fakeOutput = `{
+	"errors": [{"severity": "warning", "message": "unused variable"}],
+	"contracts": {"src/Token.sol": {"Token": {
+		"abi": [{"type": "function", "name": "total", "inputs": [], "outputs": [{"name": "", "type": "uint256"}], "stateMutability": "view"}],
+		"metadata": "{\"compiler\":{\"version\":\"0.8.19\"}}",
+		"devdoc": {"kind": "dev", "methods": {}},
+		"userdoc": {"kind": "user", "notice": "A token"},
+		"storageLayout": {"storage": [{"astId": 1, "contract": "src/Token.sol:Token", "label": "total", "offset": 0, "slot": "0", "type": "t_uint256"}], "types": {"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"}}},
+		"evm": {
+			"bytecode": {"object": "6080", "sourceMap": "1:2:0"},
+			"deployedBytecode": {"object": "6080ff", "sourceMap": "3:4:0"},
+			"methodIdentifiers": {"total()": "2ddbd13a"}
+		}
+	}}}
+}`
+
+func TestCompileProject(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake solc is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "solc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("bin/solc", fakeSolc)
+	write("bin/output.json", fakeOutput)
+	write("project/src/Token.sol", `pragma solidity ^0.8.0;
+import "./lib/Math.sol";
+import {ERC20} from "@oz/token/ERC20.sol";
+// import "./Missing.sol";
+/* import "./Missing.sol"; */
+contract Token is ERC20 { uint256 public total; }`)
+	write("project/src/lib/Math.sol", `library Math {}`)
+	write("deps/@openzeppelin/contracts/token/ERC20.sol", `import '../utils/Context.sol'; contract ERC20 is Context {}`)
+	write("deps/@openzeppelin/contracts/utils/Context.sol", `contract Context {}`)
+
+	solc, err := SolidityVersion(filepath.Join(dir, "bin", "solc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solc.Version != "0.8.19" || solc.Minor != 8 {
+		t.Fatalf("unexpected version %+v", solc)
+	}
+	project := &Project{
+		BasePath:     filepath.Join(dir, "project"),
+		IncludePaths: []string{filepath.Join(dir, "deps")},
+		Sources:      []string{filepath.Join(dir, "project", "src", "Token.sol")},
+		Remappings:   []string{"@oz/=@openzeppelin/contracts/"},
+		Optimize:     true,
+		EVMVersion:   "paris",
+		ViaIR:        true,
+		CacheDir:     filepath.Join(dir, "cache"),
+	}
+	contracts, err := solc.CompileProject(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := contracts["src/Token.sol:Token"]
+	if token == nil || token.Code != "0x6080" || token.RuntimeCode != "0x6080ff" || token.Hashes["total()"] != "2ddbd13a" {
+		t.Fatalf("unexpected contracts %+v", contracts)
+	}
+	if token.Info.StorageLayout == nil || token.Info.UserDoc.(map[string]interface{})["notice"] != "A token" {
+		t.Errorf("missing storage layout or user doc: %+v", token.Info)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "bin", "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input StandardInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatal(err)
+	}
+	var units []string
+	for unit := range input.Sources {
+		units = append(units, unit)
+	}
+	want := []string{"@openzeppelin/contracts/token/ERC20.sol", "@openzeppelin/contracts/utils/Context.sol", "src/Token.sol", "src/lib/Math.sol"}
+	if len(units) != len(want) {
+		t.Fatalf("got sources %v, want %v", units, want)
+	}
+	for _, unit := range want {
+		if _, ok := input.Sources[unit]; !ok {
+			t.Errorf("source %s missing from input, have %v", unit, units)
+		}
+	}
+	settings := input.Settings
+	if !settings.Optimizer.Enabled || settings.Optimizer.Runs != 200 || settings.EVMVersion != "paris" || !settings.ViaIR ||
+		!reflect.DeepEqual(settings.Remappings, project.Remappings) {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	// The second compilation is served from the cache, other settings recompile.
+	if _, err := solc.CompileProject(project); err != nil {
+		t.Fatal(err)
+	}
+	project.Runs = 1000
+	if _, err := solc.CompileProject(project); err != nil {
+		t.Fatal(err)
+	}
+	runs, _ := ioutil.ReadFile(filepath.Join(dir, "bin", "runs"))
+	if string(runs) != "--standard-json\n--standard-json\n" {
+		t.Errorf("unexpected solc runs %q", runs)
+	}
+
+	write("bin/output.json", `{"errors": [{"severity": "error", "formattedMessage": "ParserError: Expected ';'\n"}]}`)
+	project.Runs = 1
+	if _, err := solc.CompileProject(project); err == nil || err.Error() != "solc: ParserError: Expected ';'" {
+		t.Errorf("unexpected error %v", err)
+	}
+	project.Sources = append(project.Sources, filepath.Join(dir, "project", "src", "Other.sol"))
+	if _, err := solc.CompileProject(project); err == nil || !strings.Contains(err.Error(), `source "src/Other.sol" not found`) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestRemapping(t *testing.T) {
+	p := &Project{Remappings: []string{
+		"@oz/=lib/oz/",
+		"@oz/token/=lib/oz-token/",
+		"src/legacy:@oz/=lib/oz-v3/",
+	}}
+	for _, test := range []struct{ unit, imp, want string }{
+		{"src/A.sol", "@oz/access/Ownable.sol", "lib/oz/access/Ownable.sol"},
+		{"src/A.sol", "@oz/token/ERC20.sol", "lib/oz-token/ERC20.sol"},
+		{"src/legacy/B.sol", "@oz/token/ERC20.sol", "lib/oz-v3/token/ERC20.sol"},
+		{"src/a/A.sol", "../b/B.sol", "src/b/B.sol"},
+		{"src/A.sol", "other/C.sol", "other/C.sol"},
+	} {
+		if got := p.resolve(test.unit, test.imp); got != test.want {
+			t.Errorf("resolve(%s, %s) = %s, want %s", test.unit, test.imp, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethclient/common/flogging/metrics"
+)
+
+// ContentType is the content type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the histogram buckets used when HistogramOpts has none.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+const (
+	counterType   = "counter"
+	gaugeType     = "gauge"
+	histogramType = "histogram"
+)
+
+// Provider is a metrics.Provider that keeps all values in memory and exposes
+// them in the Prometheus text format. Creating a meter with the name of an
+// existing one returns a meter of the existing metric.
+type Provider struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewProvider creates an empty provider.
+func NewProvider() *Provider {
+	return &Provider{families: make(map[string]*family)}
+}
+
+func (p *Provider) NewCounter(o metrics.CounterOpts) metrics.Counter {
+	name := fullyQualifiedName(o.Namespace, o.Subsystem, o.Name)
+	return &Counter{meter{family: p.family(counterType, name, o.Help, o.LabelNames, nil)}}
+}
+
+func (p *Provider) NewGauge(o metrics.GaugeOpts) metrics.Gauge {
+	name := fullyQualifiedName(o.Namespace, o.Subsystem, o.Name)
+	return &Gauge{meter{family: p.family(gaugeType, name, o.Help, o.LabelNames, nil)}}
+}
+
+func (p *Provider) NewHistogram(o metrics.HistogramOpts) metrics.Histogram {
+	buckets := o.Buckets
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	name := fullyQualifiedName(o.Namespace, o.Subsystem, o.Name)
+	return &Histogram{meter{family: p.family(histogramType, name, o.Help, o.LabelNames, buckets)}}
+}
+
+// family returns the metric with the given name, creating it if needed. It
+// panics if the metric exists with a different type or labels.
+func (p *Provider) family(kind, name, help string, labelNames []string, buckets []float64) *family {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f, ok := p.families[name]; ok {
+		if f.kind != kind || strings.Join(f.labelNames, ",") != strings.Join(labelNames, ",") {
+			panic(fmt.Sprintf("metric %s registered twice with different type or labels", name))
+		}
+		return f
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: append([]string(nil), labelNames...),
+		buckets:    sorted,
+		series:     make(map[string]*series),
+	}
+	p.families[name] = f
+	return f
+}
+
+// WriteTo writes all metrics in the text exposition format.
+func (p *Provider) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	families := make([]*family, 0, len(p.families))
+	for _, f := range p.families {
+		families = append(families, f)
+	}
+	p.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	if cw.err == nil {
+		cw.err = cw.w.(*bufio.Writer).Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics, it makes the provider usable as scrape
+// endpoint.
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	p.WriteTo(w)
+}
+
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // counter and gauge value, histogram sum
+	counts      []uint64 // histogram bucket counts, not cumulative
+	count       uint64   // histogram observations
+}
+
+// get returns the series for the label values, creating it if needed. The
+// caller must hold f.mu.
+func (f *family) get(labelValues []string) *series {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues}
+		if f.kind == histogramType {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) write(w *countingWriter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.help != "" {
+		w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	}
+	w.printf("# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != histogramType {
+			w.printf("%s%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range f.buckets {
+			cumulative += s.counts[i]
+			w.printf("%s_bucket%s %d\n", f.name, f.labels(s.labelValues, formatFloat(bound)), cumulative)
+		}
+		w.printf("%s_bucket%s %d\n", f.name, f.labels(s.labelValues, "+Inf"), s.count)
+		w.printf("%s_sum%s %s\n", f.name, f.labels(s.labelValues, ""), formatFloat(s.value))
+		w.printf("%s_count%s %d\n", f.name, f.labels(s.labelValues, ""), s.count)
+	}
+}
+
+// labels formats the label set of a series, le is the histogram bucket label.
+func (f *family) labels(values []string, le string) string {
+	var pairs []string
+	for i, name := range f.labelNames {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// meter is a family bound to label values.
+type meter struct {
+	family      *family
+	labelValues map[string]string
+}
+
+// with returns a meter with the name/value pairs added to the labels.
+func (m meter) with(labelValues []string) meter {
+	values := make(map[string]string, len(m.labelValues)+len(labelValues)/2)
+	for k, v := range m.labelValues {
+		values[k] = v
+	}
+	for i := 0; i+1 < len(labelValues); i += 2 {
+		values[labelValues[i]] = labelValues[i+1]
+	}
+	return meter{family: m.family, labelValues: values}
+}
+
+// update runs fn on the series of the meter.
+func (m meter) update(fn func(s *series)) {
+	values := make([]string, len(m.family.labelNames))
+	for i, name := range m.family.labelNames {
+		values[i] = m.labelValues[name]
+	}
+	m.family.mu.Lock()
+	fn(m.family.get(values))
+	m.family.mu.Unlock()
+}
+
+type Counter struct{ meter }
+
+func (c *Counter) With(labelValues ...string) metrics.Counter {
+	return &Counter{c.with(labelValues)}
+}
+
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		panic("counter cannot decrease")
+	}
+	c.update(func(s *series) { s.value += delta })
+}
+
+type Gauge struct{ meter }
+
+func (g *Gauge) With(labelValues ...string) metrics.Gauge {
+	return &Gauge{g.with(labelValues)}
+}
+
+func (g *Gauge) Add(delta float64) {
+	g.update(func(s *series) { s.value += delta })
+}
+
+func (g *Gauge) Set(value float64) {
+	g.update(func(s *series) { s.value = value })
+}
+
+type Histogram struct{ meter }
+
+func (h *Histogram) With(labelValues ...string) metrics.Histogram {
+	return &Histogram{h.with(labelValues)}
+}
+
+func (h *Histogram) Observe(value float64) {
+	buckets := h.family.buckets
+	h.update(func(s *series) {
+		if i := sort.SearchFloat64s(buckets, value); i < len(buckets) {
+			s.counts[i]++
+		}
+		s.count++
+		s.value += value
+	})
+}
+
+func fullyQualifiedName(namespace, subsystem, name string) string {
+	var parts []string
+	for _, part := range []string{namespace, subsystem, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
+
+func escapeHelp(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
+
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
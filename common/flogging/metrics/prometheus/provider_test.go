@@ -0,0 +1,50 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package prometheus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethclient/common/flogging/metrics"
+)
+
+func TestProviderText(t *testing.T) {
+	p := NewProvider()
+	counter := p.NewCounter(metrics.CounterOpts{Namespace: "rpc", Name: "requests_total", Help: "Requests.", LabelNames: []string{"method", "code"}})
+	counter.With("method", "eth_call").With("code", `a"b`).Add(2)
+	counter.With("method", "eth_call", "code", `a"b`).Add(1)
+	gauge := p.NewGauge(metrics.GaugeOpts{Namespace: "rpc", Name: "inflight"})
+	gauge.Add(3)
+	gauge.Add(-1)
+	histogram := p.NewHistogram(metrics.HistogramOpts{Name: "latency", Buckets: []float64{1, 0.5}})
+	histogram.Observe(0.2)
+	histogram.Observe(0.7)
+	histogram.Observe(5)
+	// same name returns the existing metric
+	p.NewGauge(metrics.GaugeOpts{Namespace: "rpc", Name: "inflight"}).Add(1)
+
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# TYPE latency histogram
+latency_bucket{le="0.5"} 1
+latency_bucket{le="1"} 2
+latency_bucket{le="+Inf"} 3
+latency_sum 5.9
+latency_count 3
+# TYPE rpc_inflight gauge
+rpc_inflight 3
+# HELP rpc_requests_total Requests.
+# TYPE rpc_requests_total counter
+rpc_requests_total{method="eth_call",code="a\"b"} 3
+`
+	if buf.String() != want {
+		t.Fatalf("have\n%s\nwant\n%s", buf.String(), want)
+	}
+}
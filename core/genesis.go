@@ -11,9 +11,11 @@ import (
 	"github.com/ethclient/common"
 	"github.com/ethclient/common/hexutil"
 	"github.com/ethclient/common/math"
+	"github.com/ethclient/core/types"
 	"github.com/ethclient/crypto"
 	"github.com/ethclient/params"
 	"github.com/ethclient/rlp"
+	"github.com/ethclient/trie"
 )
 
 //go:generate gencodec -type Genesis -field-override genesisSpecMarshaling -out gen_genesis.go
@@ -165,3 +167,82 @@ func decodePrealloc(data string) GenesisAlloc {
 	}
 	return ga
 }
+
+// StateRoot computes the root hash of the state trie holding the genesis
+// allocation, without needing a state database.
+func (g *Genesis) StateRoot() (common.Hash, error) {
+	state := trie.New()
+	for addr, account := range g.Alloc {
+		storage := trie.New()
+		for key, value := range account.Storage {
+			if value == (common.Hash{}) {
+				continue
+			}
+			enc, err := rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
+			if err != nil {
+				return common.Hash{}, err
+			}
+			storage.Update(crypto.Keccak256(key[:]), enc)
+		}
+		balance := account.Balance
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		codeHash := types.EmptyCodeHash
+		if len(account.Code) > 0 {
+			codeHash = crypto.Keccak256Hash(account.Code)
+		}
+		enc, err := rlp.EncodeToBytes(&types.StateAccount{
+			Nonce:    account.Nonce,
+			Balance:  balance,
+			Root:     storage.Hash(),
+			CodeHash: codeHash[:],
+		})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		state.Update(crypto.Keccak256(addr[:]), enc)
+	}
+	return state.Hash(), nil
+}
+
+// ToBlock creates the genesis block described by g, computing the state root
+// offline.
+func (g *Genesis) ToBlock() (*types.Block, error) {
+	root, err := g.StateRoot()
+	if err != nil {
+		return nil, err
+	}
+	head := &types.Header{
+		Number:      new(big.Int).SetUint64(g.Number),
+		Nonce:       types.EncodeNonce(g.Nonce),
+		Time:        g.Timestamp,
+		ParentHash:  g.ParentHash,
+		Extra:       g.ExtraData,
+		GasLimit:    g.GasLimit,
+		GasUsed:     g.GasUsed,
+		Difficulty:  g.Difficulty,
+		MixDigest:   g.Mixhash,
+		Coinbase:    g.Coinbase,
+		Root:        root,
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		UncleHash:   types.EmptyUncleHash,
+	}
+	if g.GasLimit == 0 {
+		head.GasLimit = params.GenesisGasLimit
+	}
+	if g.Difficulty == nil {
+		head.Difficulty = params.GenesisDifficulty
+	}
+	return types.NewBlockWithHeader(head), nil
+}
+
+// Hash returns the hash of the genesis block described by g.
+func (g *Genesis) Hash() (common.Hash, error) {
+	block, err := g.ToBlock()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return block.Hash(), nil
+}
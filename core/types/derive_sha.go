@@ -0,0 +1,44 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package types
+
+import (
+	"bytes"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/rlp"
+	"github.com/ethclient/trie"
+)
+
+// DerivableList is the interface of lists whose root hash is committed to in
+// a block header, such as Transactions and Receipts.
+type DerivableList interface {
+	Len() int
+	GetRlp(i int) []byte
+}
+
+// DeriveSha computes the root hash of the trie mapping the RLP encoded index
+// of every element of list to the RLP encoding of that element.
+func DeriveSha(list DerivableList) common.Hash {
+	keybuf := new(bytes.Buffer)
+	t := trie.New()
+	for i := 0; i < list.Len(); i++ {
+		keybuf.Reset()
+		rlp.Encode(keybuf, uint(i))
+		t.Update(keybuf.Bytes(), list.GetRlp(i))
+	}
+	return t.Hash()
+}
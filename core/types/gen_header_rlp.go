@@ -0,0 +1,132 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"io"
+
+	"github.com/ethclient/rlp"
+)
+
+func (obj *Header) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp1 := w.List()
+	_tmp2 := obj.BaseFee != nil
+	w.WriteBytes(obj.ParentHash[:])
+	w.WriteBytes(obj.UncleHash[:])
+	w.WriteBytes(obj.Coinbase[:])
+	w.WriteBytes(obj.Root[:])
+	w.WriteBytes(obj.TxHash[:])
+	w.WriteBytes(obj.ReceiptHash[:])
+	w.WriteBytes(obj.Bloom[:])
+	if obj.Difficulty == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.Difficulty.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.Difficulty)
+	}
+	if obj.Number == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.Number.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.Number)
+	}
+	w.WriteUint64(obj.GasLimit)
+	w.WriteUint64(obj.GasUsed)
+	w.WriteUint64(obj.Time)
+	w.WriteBytes(obj.Extra)
+	w.WriteBytes(obj.MixDigest[:])
+	w.WriteBytes(obj.Nonce[:])
+	if _tmp2 {
+		if obj.BaseFee == nil {
+			w.Write(rlp.EmptyString)
+		} else {
+			if obj.BaseFee.Sign() == -1 {
+				return rlp.ErrNegativeBigInt
+			}
+			w.WriteBigInt(obj.BaseFee)
+		}
+	}
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *Header) DecodeRLP(dec *rlp.Stream) error {
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.ParentHash[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.UncleHash[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.Coinbase[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.Root[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.TxHash[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.ReceiptHash[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.Bloom[:]); err != nil {
+		return err
+	}
+	_tmp1, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.Difficulty = _tmp1
+	_tmp2, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.Number = _tmp2
+	_tmp3, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.GasLimit = _tmp3
+	_tmp4, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.GasUsed = _tmp4
+	_tmp5, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.Time = _tmp5
+	_tmp6, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	obj.Extra = _tmp6
+	if err := dec.ReadBytes(obj.MixDigest[:]); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.Nonce[:]); err != nil {
+		return err
+	}
+	if dec.MoreDataInList() {
+		_tmp7, err := dec.BigInt()
+		if err != nil {
+			return err
+		}
+		obj.BaseFee = _tmp7
+	} else {
+		obj.BaseFee = nil
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	return nil
+}
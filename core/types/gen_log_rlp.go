@@ -0,0 +1,57 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"io"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/rlp"
+)
+
+func (obj *rlpLog) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp1 := w.List()
+	w.WriteBytes(obj.Address[:])
+	_tmp2 := w.List()
+	for _tmp3 := range obj.Topics {
+		w.WriteBytes(obj.Topics[_tmp3][:])
+	}
+	w.ListEnd(_tmp2)
+	w.WriteBytes(obj.Data)
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *rlpLog) DecodeRLP(dec *rlp.Stream) error {
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.Address[:]); err != nil {
+		return err
+	}
+	_tmp1 := make([]common.Hash, 0)
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	for dec.MoreDataInList() {
+		var _tmp2 common.Hash
+		if err := dec.ReadBytes(_tmp2[:]); err != nil {
+			return err
+		}
+		_tmp1 = append(_tmp1, _tmp2)
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	obj.Topics = _tmp1
+	_tmp3, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	obj.Data = _tmp3
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"io"
+
+	"github.com/ethclient/rlp"
+)
+
+func (obj *receiptRLP) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp1 := w.List()
+	w.WriteBytes(obj.PostStateOrStatus)
+	w.WriteUint64(obj.CumulativeGasUsed)
+	w.WriteBytes(obj.Bloom[:])
+	_tmp2 := w.List()
+	for _tmp3 := range obj.Logs {
+		if obj.Logs[_tmp3] == nil {
+			w.Write(rlp.EmptyList)
+		} else {
+			if err := obj.Logs[_tmp3].EncodeRLP(&w); err != nil {
+				return err
+			}
+		}
+	}
+	w.ListEnd(_tmp2)
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *receiptRLP) DecodeRLP(dec *rlp.Stream) error {
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	_tmp1, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	obj.PostStateOrStatus = _tmp1
+	_tmp2, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.CumulativeGasUsed = _tmp2
+	if err := dec.ReadBytes(obj.Bloom[:]); err != nil {
+		return err
+	}
+	_tmp3 := make([]*Log, 0)
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	for dec.MoreDataInList() {
+		var _tmp4 *Log
+		var _tmp5 Log
+		if err := _tmp5.DecodeRLP(dec); err != nil {
+			return err
+		}
+		_tmp4 = &_tmp5
+		_tmp3 = append(_tmp3, _tmp4)
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	obj.Logs = _tmp3
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"io"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/rlp"
+)
+
+func (obj *AccessTuple) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp1 := w.List()
+	w.WriteBytes(obj.Address[:])
+	_tmp2 := w.List()
+	for _tmp3 := range obj.StorageKeys {
+		w.WriteBytes(obj.StorageKeys[_tmp3][:])
+	}
+	w.ListEnd(_tmp2)
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *AccessTuple) DecodeRLP(dec *rlp.Stream) error {
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	if err := dec.ReadBytes(obj.Address[:]); err != nil {
+		return err
+	}
+	_tmp1 := make([]common.Hash, 0)
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	for dec.MoreDataInList() {
+		var _tmp2 common.Hash
+		if err := dec.ReadBytes(_tmp2[:]); err != nil {
+			return err
+		}
+		_tmp1 = append(_tmp1, _tmp2)
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	obj.StorageKeys = _tmp1
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (obj *AccessListTx) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp1 := w.List()
+	if obj.ChainID == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.ChainID.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.ChainID)
+	}
+	w.WriteUint64(obj.Nonce)
+	if obj.GasPrice == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.GasPrice.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.GasPrice)
+	}
+	w.WriteUint64(obj.Gas)
+	if obj.To == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		w.WriteBytes((*obj.To)[:])
+	}
+	if obj.Value == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.Value.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.Value)
+	}
+	w.WriteBytes(obj.Data)
+	_tmp2 := w.List()
+	for _tmp3 := range obj.AccessList {
+		if err := obj.AccessList[_tmp3].EncodeRLP(&w); err != nil {
+			return err
+		}
+	}
+	w.ListEnd(_tmp2)
+	if obj.V == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.V.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.V)
+	}
+	if obj.R == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.R.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.R)
+	}
+	if obj.S == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.S.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.S)
+	}
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *AccessListTx) DecodeRLP(dec *rlp.Stream) error {
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	_tmp1, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.ChainID = _tmp1
+	_tmp2, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.Nonce = _tmp2
+	_tmp3, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.GasPrice = _tmp3
+	_tmp4, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.Gas = _tmp4
+	if _kind, _size, err := dec.Kind(); err != nil {
+		return err
+	} else if _kind == rlp.String && _size == 0 {
+		if _, err := dec.Bytes(); err != nil {
+			return err
+		}
+		obj.To = nil
+	} else {
+		var _tmp5 common.Address
+		if err := dec.ReadBytes(_tmp5[:]); err != nil {
+			return err
+		}
+		obj.To = &_tmp5
+	}
+	_tmp6, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.Value = _tmp6
+	_tmp7, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	obj.Data = _tmp7
+	_tmp8 := make(AccessList, 0)
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	for dec.MoreDataInList() {
+		var _tmp9 AccessTuple
+		if err := _tmp9.DecodeRLP(dec); err != nil {
+			return err
+		}
+		_tmp8 = append(_tmp8, _tmp9)
+	}
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	obj.AccessList = _tmp8
+	_tmp10, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.V = _tmp10
+	_tmp11, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.R = _tmp11
+	_tmp12, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.S = _tmp12
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Code generated by rlpgen. DO NOT EDIT.
+
+package types
+
+import (
+	"io"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/rlp"
+)
+
+func (obj *LegacyTx) EncodeRLP(_w io.Writer) error {
+	w := rlp.NewEncoderBuffer(_w)
+	_tmp1 := w.List()
+	w.WriteUint64(obj.Nonce)
+	if obj.GasPrice == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.GasPrice.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.GasPrice)
+	}
+	w.WriteUint64(obj.Gas)
+	if obj.To == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		w.WriteBytes((*obj.To)[:])
+	}
+	if obj.Value == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.Value.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.Value)
+	}
+	w.WriteBytes(obj.Data)
+	if obj.V == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.V.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.V)
+	}
+	if obj.R == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.R.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.R)
+	}
+	if obj.S == nil {
+		w.Write(rlp.EmptyString)
+	} else {
+		if obj.S.Sign() == -1 {
+			return rlp.ErrNegativeBigInt
+		}
+		w.WriteBigInt(obj.S)
+	}
+	w.ListEnd(_tmp1)
+	return w.Flush()
+}
+
+func (obj *LegacyTx) DecodeRLP(dec *rlp.Stream) error {
+	if _, err := dec.List(); err != nil {
+		return err
+	}
+	_tmp1, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.Nonce = _tmp1
+	_tmp2, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.GasPrice = _tmp2
+	_tmp3, err := dec.Uint64()
+	if err != nil {
+		return err
+	}
+	obj.Gas = _tmp3
+	if _kind, _size, err := dec.Kind(); err != nil {
+		return err
+	} else if _kind == rlp.String && _size == 0 {
+		if _, err := dec.Bytes(); err != nil {
+			return err
+		}
+		obj.To = nil
+	} else {
+		var _tmp4 common.Address
+		if err := dec.ReadBytes(_tmp4[:]); err != nil {
+			return err
+		}
+		obj.To = &_tmp4
+	}
+	_tmp5, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.Value = _tmp5
+	_tmp6, err := dec.Bytes()
+	if err != nil {
+		return err
+	}
+	obj.Data = _tmp6
+	_tmp7, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.V = _tmp7
+	_tmp8, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.R = _tmp8
+	_tmp9, err := dec.BigInt()
+	if err != nil {
+		return err
+	}
+	obj.S = _tmp9
+	if err := dec.ListEnd(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -25,6 +25,7 @@ import (
 )
 
 //go:generate gencodec -type Log -field-override logMarshaling -out gen_log_json.go
+//go:generate go run ../../rlp/rlpgen -type rlpLog -out gen_log_rlp.go
 
 // Log represents a contract log event. These events are generated by the LOG opcode and
 // stored/indexed by the node.
@@ -85,13 +86,14 @@ type legacyRlpStorageLog struct {
 
 // EncodeRLP implements rlp.Encoder.
 func (l *Log) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, rlpLog{Address: l.Address, Topics: l.Topics, Data: l.Data})
+	rl := rlpLog{Address: l.Address, Topics: l.Topics, Data: l.Data}
+	return rl.EncodeRLP(w)
 }
 
 // DecodeRLP implements rlp.Decoder.
 func (l *Log) DecodeRLP(s *rlp.Stream) error {
 	var dec rlpLog
-	err := s.Decode(&dec)
+	err := dec.DecodeRLP(s)
 	if err == nil {
 		l.Address, l.Topics, l.Data = dec.Address, dec.Topics, dec.Data
 	}
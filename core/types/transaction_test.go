@@ -0,0 +1,206 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
+	"github.com/ethclient/rlp"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+)
+
+// The legacy encodings were produced before typed transactions were added and
+// must not change.
+func TestLegacyTransactionEncoding(t *testing.T) {
+	tests := []struct {
+		signer Signer
+		want   string
+		hash   string
+	}{
+		{
+			HomesteadSigner{},
+			"f86103018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a8255441ca0a78bf1c2825c6331d1fcc120800ef28cdc6f4e36052f2a02a940d3e23244a6cea02736b0cda8515b74f080de5de7f9322e6ccb9018da893024834fc9604daf4620",
+			"0x36f93cadd01e2b39c153a7e1f702be4e9d652c501848ccdb500775a6cd90a015",
+		},
+		{
+			NewEIP155Signer(big.NewInt(18)),
+			"f86103018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a82554447a02a4010d297a1eee8bedb06ef86bfe1cbb9c4ff2581c468b1796752d7be8fe3d5a064fa0b932e9c7ffc17f77ff25ab86cbee997ca99e5483d0fe1fd21edad5abb6d",
+			"0x01c25ef1fb69fbb048a9e6e8ecfb3f4617035d39022176e78a5b189432e84d80",
+		},
+	}
+	for _, test := range tests {
+		tx, err := SignTx(NewTransaction(3, testAddr, big.NewInt(10), 2000, big.NewInt(1), common.FromHex("5544")), test.signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if common.Bytes2Hex(enc) != test.want {
+			t.Errorf("encoding mismatch:\ngot  %x\nwant %s", enc, test.want)
+		}
+		if tx.Hash().Hex() != test.hash {
+			t.Errorf("hash mismatch: got %s, want %s", tx.Hash().Hex(), test.hash)
+		}
+		bin, _ := tx.MarshalBinary()
+		if !bytes.Equal(bin, enc) {
+			t.Error("MarshalBinary differs from the RLP encoding of a legacy transaction")
+		}
+		// The London signer must recover legacy senders as well.
+		from, err := Sender(NewLondonSigner(big.NewInt(18)), tx)
+		if err != nil || from != crypto.PubkeyToAddress(testKey.PublicKey) {
+			t.Errorf("wrong sender %x, %v", from, err)
+		}
+	}
+}
+
+func TestTypedTransactions(t *testing.T) {
+	signer := NewLondonSigner(big.NewInt(18))
+	accessList := AccessList{{Address: testAddr, StorageKeys: []common.Hash{{1}}}}
+	txs := []TxData{
+		&AccessListTx{ChainID: big.NewInt(18), Nonce: 3, To: &testAddr, Value: big.NewInt(10), Gas: 25000, GasPrice: big.NewInt(1), Data: common.FromHex("5544"), AccessList: accessList},
+		&DynamicFeeTx{ChainID: big.NewInt(18), Nonce: 4, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(30), Gas: 60000, Data: common.FromHex("6000"), AccessList: accessList},
+	}
+	for _, inner := range txs {
+		tx, err := SignTx(NewTx(inner), signer, testKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bin, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bin[0] != tx.Type() {
+			t.Fatalf("encoding starts with %d, want type %d", bin[0], tx.Type())
+		}
+		if tx.Hash() != crypto.Keccak256Hash(bin) {
+			t.Error("hash is not the hash of the canonical encoding")
+		}
+
+		// Binary, RLP (as part of a list) and JSON round trips.
+		var dec Transaction
+		if err := dec.UnmarshalBinary(bin); err != nil {
+			t.Fatal(err)
+		}
+		checkTxEqual(t, tx, &dec)
+		list, _ := rlp.EncodeToBytes([]*Transaction{tx})
+		var decList []*Transaction
+		if err := rlp.DecodeBytes(list, &decList); err != nil {
+			t.Fatal(err)
+		}
+		checkTxEqual(t, tx, decList[0])
+		js, err := json.Marshal(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decJSON Transaction
+		if err := json.Unmarshal(js, &decJSON); err != nil {
+			t.Fatal(err)
+		}
+		checkTxEqual(t, tx, &decJSON)
+
+		from, err := Sender(signer, &dec)
+		if err != nil || from != crypto.PubkeyToAddress(testKey.PublicKey) {
+			t.Errorf("wrong sender %x, %v", from, err)
+		}
+		if _, err := Sender(NewLondonSigner(big.NewInt(1)), &dec); err != ErrInvalidChainId {
+			t.Errorf("expected %v for wrong chain id, got %v", ErrInvalidChainId, err)
+		}
+		if _, err := Sender(NewEIP155Signer(big.NewInt(18)), &dec); err != ErrTxTypeNotSupported {
+			t.Errorf("expected %v from EIP-155 signer, got %v", ErrTxTypeNotSupported, err)
+		}
+	}
+}
+
+func checkTxEqual(t *testing.T, want, got *Transaction) {
+	t.Helper()
+	if got.Hash() != want.Hash() || got.Type() != want.Type() {
+		t.Fatalf("decoded tx %x type %d, want %x type %d", got.Hash(), got.Type(), want.Hash(), want.Type())
+	}
+	if got.GasTipCap().Cmp(want.GasTipCap()) != 0 || got.GasFeeCap().Cmp(want.GasFeeCap()) != 0 {
+		t.Errorf("fee caps mismatch")
+	}
+	if len(got.AccessList()) != len(want.AccessList()) {
+		t.Errorf("access list mismatch")
+	}
+}
+
+func TestTypedReceiptEncoding(t *testing.T) {
+	receipt := &Receipt{Type: DynamicFeeTxType, Status: ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*Log{}}
+	bin, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bin[0] != DynamicFeeTxType {
+		t.Fatalf("encoding starts with %d", bin[0])
+	}
+	var dec Receipt
+	if err := dec.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Type != receipt.Type || dec.CumulativeGasUsed != receipt.CumulativeGasUsed || dec.Status != receipt.Status {
+		t.Errorf("decoded receipt %+v", dec)
+	}
+	legacy := &Receipt{Status: ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*Log{}}
+	enc, _ := rlp.EncodeToBytes(legacy)
+	if got := (Receipts{legacy}).GetRlp(0); !bytes.Equal(got, enc) {
+		t.Errorf("legacy receipt encoding changed")
+	}
+}
+
+func TestHeaderBaseFee(t *testing.T) {
+	header := &Header{Difficulty: big.NewInt(1), Number: big.NewInt(2), Extra: []byte{}}
+	legacy, _ := rlp.EncodeToBytes(header)
+	header.BaseFee = big.NewInt(7)
+	london, _ := rlp.EncodeToBytes(header)
+	if len(london) <= len(legacy) || !bytes.Equal(london[len(london)-1:], []byte{7}) {
+		t.Fatalf("base fee not encoded: %x", london)
+	}
+	var dec Header
+	if err := rlp.DecodeBytes(legacy, &dec); err != nil || dec.BaseFee != nil {
+		t.Fatalf("legacy header decoding: %v, base fee %v", err, dec.BaseFee)
+	}
+	if err := rlp.DecodeBytes(london, &dec); err != nil || dec.BaseFee.Int64() != 7 {
+		t.Fatalf("london header decoding: %v, base fee %v", err, dec.BaseFee)
+	}
+	if dec.Hash() != header.Hash() {
+		t.Error("hash of decoded header differs")
+	}
+}
+
+func TestNewMessage(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	legacy := NewMessage(common.Address{}, &to, 1, big.NewInt(2), 21000, big.NewInt(3), nil, true)
+	if legacy.GasFeeCap().Int64() != 3 || legacy.GasTipCap().Int64() != 3 || legacy.AccessList() != nil {
+		t.Errorf("legacy message: fee cap %v, tip cap %v, access list %v", legacy.GasFeeCap(), legacy.GasTipCap(), legacy.AccessList())
+	}
+	list := AccessList{{Address: to}}
+	typed := NewTypedMessage(common.Address{}, &to, 1, big.NewInt(2), 21000, big.NewInt(3), big.NewInt(5), big.NewInt(4), nil, list, true)
+	if typed.GasFeeCap().Int64() != 5 || typed.GasTipCap().Int64() != 4 || len(typed.AccessList()) != 1 {
+		t.Errorf("typed message: fee cap %v, tip cap %v, access list %v", typed.GasFeeCap(), typed.GasTipCap(), typed.AccessList())
+	}
+}
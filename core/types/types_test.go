@@ -0,0 +1,166 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/rlp"
+)
+
+// The reflect* types have the layout of the types with generated RLP methods,
+// but no methods, so package rlp encodes them through reflection.
+type (
+	reflectHeader       Header
+	reflectLegacyTx     LegacyTx
+	reflectAccessListTx AccessListTx
+	reflectDynamicFeeTx DynamicFeeTx
+	reflectReceiptRLP   receiptRLP
+	reflectRLPLog       rlpLog
+)
+
+var reflectTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(Header{}):       reflect.TypeOf(reflectHeader{}),
+	reflect.TypeOf(LegacyTx{}):     reflect.TypeOf(reflectLegacyTx{}),
+	reflect.TypeOf(AccessListTx{}): reflect.TypeOf(reflectAccessListTx{}),
+	reflect.TypeOf(DynamicFeeTx{}): reflect.TypeOf(reflectDynamicFeeTx{}),
+	reflect.TypeOf(receiptRLP{}):   reflect.TypeOf(reflectReceiptRLP{}),
+	reflect.TypeOf(rlpLog{}):       reflect.TypeOf(reflectRLPLog{}),
+}
+
+// reflective converts a pointer to a value with generated methods into a
+// pointer to its reflect* counterpart.
+func reflective(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	return rv.Convert(reflect.PtrTo(reflectTypes[rv.Type().Elem()])).Interface()
+}
+
+func rlpTestValues() []struct {
+	name  string
+	value interface{}
+} {
+	to := common.HexToAddress("0x095e7baea6a5c7c4c2e45f83ab11aa7e4f4bc56b")
+	accesses := AccessList{
+		{Address: to, StorageKeys: []common.Hash{{1}, {2}}},
+		{Address: testAddr},
+	}
+	logs := []*Log{
+		{Address: to, Topics: []common.Hash{{3}}, Data: []byte{1, 2, 3}},
+		{Address: testAddr, Topics: []common.Hash{}, Data: bytes.Repeat([]byte{0xff}, 100)},
+	}
+	return []struct {
+		name  string
+		value interface{}
+	}{
+		{"header", &Header{Difficulty: big.NewInt(131072), Number: big.NewInt(1000000), GasLimit: 8000000, GasUsed: 21000, Time: 1600000000, Extra: []byte("extra")}},
+		{"header-basefee", &Header{Difficulty: big.NewInt(1), Number: big.NewInt(12965000), Extra: []byte{}, BaseFee: big.NewInt(1000000000)}},
+		{"legacy-tx", &LegacyTx{Nonce: 3, GasPrice: big.NewInt(2000), Gas: 21000, To: &to, Value: big.NewInt(10), Data: []byte{}, V: big.NewInt(27), R: big.NewInt(1), S: big.NewInt(2)}},
+		{"contract-creation", &LegacyTx{GasPrice: new(big.Int), Value: new(big.Int), Data: bytes.Repeat([]byte{0x60}, 60), V: new(big.Int), R: new(big.Int), S: new(big.Int)}},
+		{"access-list-tx", &AccessListTx{ChainID: big.NewInt(18), Nonce: 1, GasPrice: big.NewInt(5), Gas: 50000, To: &to, Value: big.NewInt(1), Data: []byte{}, AccessList: accesses, V: big.NewInt(1), R: big.NewInt(3), S: big.NewInt(4)}},
+		{"dynamic-fee-tx", &DynamicFeeTx{ChainID: big.NewInt(18), GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(100), Gas: 21000, To: &to, Value: big.NewInt(1), Data: []byte{}, AccessList: AccessList{}, V: new(big.Int), R: big.NewInt(3), S: big.NewInt(4)}},
+		{"receipt", &receiptRLP{PostStateOrStatus: receiptStatusSuccessfulRLP, CumulativeGasUsed: 42000, Logs: logs}},
+		{"log", &rlpLog{Address: to, Topics: []common.Hash{{3}, {4}}, Data: []byte{}}},
+	}
+}
+
+func TestGeneratedRLP(t *testing.T) {
+	for _, test := range rlpTestValues() {
+		want, err := rlp.EncodeToBytes(reflective(test.value))
+		if err != nil {
+			t.Fatalf("%s: reflective encoding failed: %v", test.name, err)
+		}
+		got, err := rlp.EncodeToBytes(test.value)
+		if err != nil {
+			t.Fatalf("%s: generated encoding failed: %v", test.name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: encoding mismatch\ngot  %x\nwant %x", test.name, got, want)
+		}
+
+		typ := reflect.TypeOf(test.value)
+		dec := reflect.New(typ.Elem())
+		if err := rlp.DecodeBytes(want, dec.Interface()); err != nil {
+			t.Fatalf("%s: generated decoding failed: %v", test.name, err)
+		}
+		ref := reflect.New(reflectTypes[typ.Elem()])
+		if err := rlp.DecodeBytes(want, ref.Interface()); err != nil {
+			t.Fatalf("%s: reflective decoding failed: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(dec.Interface(), ref.Convert(typ).Interface()) {
+			t.Errorf("%s: decoding mismatch\ngot  %+v\nwant %+v", test.name, dec.Elem(), ref.Elem())
+		}
+	}
+}
+
+func TestGeneratedRLPErrors(t *testing.T) {
+	if _, err := rlp.EncodeToBytes(&LegacyTx{Value: big.NewInt(-1)}); err != rlp.ErrNegativeBigInt {
+		t.Errorf("negative value: got error %v", err)
+	}
+	// Headers before EIP-1559 have no base fee, decoding one must clear it.
+	legacy, _ := rlp.EncodeToBytes(&Header{Difficulty: big.NewInt(1), Number: big.NewInt(1)})
+	header := &Header{BaseFee: big.NewInt(7)}
+	if err := rlp.DecodeBytes(legacy, header); err != nil || header.BaseFee != nil {
+		t.Errorf("base fee not cleared: %v, %v", header.BaseFee, err)
+	}
+	// Address fields must have the exact size.
+	enc, _ := rlp.EncodeToBytes([]interface{}{uint64(0), uint64(0), uint64(0), []byte{1, 2}, uint64(0), []byte{}, uint64(0), uint64(0), uint64(0)})
+	if err := rlp.DecodeBytes(enc, new(LegacyTx)); err == nil {
+		t.Error("short address decoded without error")
+	}
+}
+
+func BenchmarkEncodeRLP(b *testing.B) {
+	for _, test := range rlpTestValues() {
+		value, ref := test.value, reflective(test.value)
+		b.Run(test.name+"/generated", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rlp.Encode(ioutil.Discard, value)
+			}
+		})
+		b.Run(test.name+"/reflect", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rlp.Encode(ioutil.Discard, ref)
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeRLP(b *testing.B) {
+	for _, test := range rlpTestValues() {
+		enc, _ := rlp.EncodeToBytes(test.value)
+		typ := reflect.TypeOf(test.value).Elem()
+		b.Run(test.name+"/generated", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rlp.DecodeBytes(enc, reflect.New(typ).Interface())
+			}
+		})
+		b.Run(test.name+"/reflect", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rlp.DecodeBytes(enc, reflect.New(reflectTypes[typ]).Interface())
+			}
+		})
+	}
+}
@@ -28,13 +28,13 @@ import (
 	"github.com/ethclient/common"
 	"github.com/ethclient/common/hexutil"
 	"github.com/ethclient/core/types"
-	"github.com/ethclient/rlp"
 	"github.com/ethclient/rpc"
 )
 
 // Client defines typed wrappers for the Ethereum RPC API.
 type Client struct {
-	c *rpc.Client
+	c            *rpc.Client
+	verifyBlocks bool
 }
 
 // CallMsg contains parameters for contract calls.
@@ -46,6 +46,10 @@ type CallMsg struct {
 	Value    *big.Int        // amount of wei sent along with the call
 	Data     []byte          // input data, usually an ABI-encoded contract method invocation
 	Nonce    uint64
+
+	GasFeeCap  *big.Int         // EIP-1559 fee cap per gas.
+	GasTipCap  *big.Int         // EIP-1559 tip per gas.
+	AccessList types.AccessList // EIP-2930 access list.
 }
 
 // Dial connects a client to the given URL.
@@ -63,7 +67,7 @@ func DialContext(ctx context.Context, rawurl string, certFile string, keyFile st
 
 // NewClient creates a client that uses the given RPC client.
 func NewClient(c *rpc.Client) *Client {
-	return &Client{c}
+	return &Client{c: c}
 }
 
 func (ec *Client) Close() {
@@ -87,7 +91,7 @@ func (ec *Client) ChainID(ctx context.Context) (*big.Int, error) {
 // Note that loading full blocks requires two requests. Use HeaderByHash
 // if you don't need all transactions or uncle headers.
 func (ec *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return ec.getBlock(ctx, "eth_getBlockByHash", hash, true)
+	return ec.getBlock(ctx, &hash, nil, "eth_getBlockByHash", hash, true)
 }
 
 // BlockByNumber returns a block from the current canonical chain. If number is nil, the
@@ -96,7 +100,7 @@ func (ec *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Blo
 // Note that loading full blocks requires two requests. Use HeaderByNumber
 // if you don't need all transactions or uncle headers.
 func (ec *Client) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
-	return ec.getBlock(ctx, "eth_getBlockByNumber", toBlockNumArg(number), true)
+	return ec.getBlock(ctx, nil, number, "eth_getBlockByNumber", toBlockNumArg(number), true)
 }
 
 type rpcBlock struct {
@@ -105,7 +109,9 @@ type rpcBlock struct {
 	UncleHashes  []common.Hash    `json:"uncles"`
 }
 
-func (ec *Client) getBlock(ctx context.Context, method string, args ...interface{}) (*types.Block, error) {
+// getBlock fetches a block. wantHash and wantNumber are the hash or number that
+// was requested, if any; a block served for anything else is rejected.
+func (ec *Client) getBlock(ctx context.Context, wantHash *common.Hash, wantNumber *big.Int, method string, args ...interface{}) (*types.Block, error) {
 	var raw json.RawMessage
 	err := ec.c.CallContext(ctx, &raw, method, args...)
 	if err != nil {
@@ -113,27 +119,48 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 	} else if len(raw) == 0 {
 		return nil, ethclient.NotFound
 	}
+	block, hash, err := ec.decodeBlock(ctx, raw, wantHash, wantNumber)
+	if err != nil {
+		return nil, err
+	}
+	if ec.verifyBlocks {
+		if _, err := ec.verifyReceipts(ctx, block, hash); err != nil {
+			return nil, err
+		}
+	}
+	return block, nil
+}
+
+// decodeBlock decodes a block with full transactions and loads its uncles. It
+// returns the block and the hash the node reported for it.
+func (ec *Client) decodeBlock(ctx context.Context, raw json.RawMessage, wantHash *common.Hash, wantNumber *big.Int) (*types.Block, common.Hash, error) {
 	// Decode header and transactions.
 	var head *types.Header
 	var body rpcBlock
 	if err := json.Unmarshal(raw, &head); err != nil {
-		return nil, err
+		return nil, common.Hash{}, err
 	}
 	if err := json.Unmarshal(raw, &body); err != nil {
-		return nil, err
+		return nil, common.Hash{}, err
+	}
+	if wantHash != nil && body.Hash != *wantHash {
+		return nil, common.Hash{}, &IntegrityError{Block: *wantHash, Field: "hash", Have: body.Hash.Hex(), Want: wantHash.Hex()}
+	}
+	if wantNumber != nil && wantNumber.Sign() >= 0 && (head.Number == nil || head.Number.Cmp(wantNumber) != 0) {
+		return nil, common.Hash{}, &IntegrityError{Block: body.Hash, Field: "number", Have: fmt.Sprint(head.Number), Want: wantNumber.String()}
 	}
 	// Quick-verify transaction and uncle lists. This mostly helps with debugging the server.
 	if head.UncleHash == types.EmptyUncleHash && len(body.UncleHashes) > 0 {
-		return nil, fmt.Errorf("server returned non-empty uncle list but block header indicates no uncles")
+		return nil, common.Hash{}, fmt.Errorf("server returned non-empty uncle list but block header indicates no uncles")
 	}
 	if head.UncleHash != types.EmptyUncleHash && len(body.UncleHashes) == 0 {
-		return nil, fmt.Errorf("server returned empty uncle list but block header indicates uncles")
+		return nil, common.Hash{}, fmt.Errorf("server returned empty uncle list but block header indicates uncles")
 	}
 	if head.TxHash == types.EmptyRootHash && len(body.Transactions) > 0 {
-		return nil, fmt.Errorf("server returned non-empty transaction list but block header indicates no transactions")
+		return nil, common.Hash{}, fmt.Errorf("server returned non-empty transaction list but block header indicates no transactions")
 	}
 	if head.TxHash != types.EmptyRootHash && len(body.Transactions) == 0 {
-		return nil, fmt.Errorf("server returned empty transaction list but block header indicates transactions")
+		return nil, common.Hash{}, fmt.Errorf("server returned empty transaction list but block header indicates transactions")
 	}
 	// Load uncles because they are not included in the block response.
 	var uncles []*types.Header
@@ -148,14 +175,14 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 			}
 		}
 		if err := ec.c.BatchCallContext(ctx, reqs); err != nil {
-			return nil, err
+			return nil, common.Hash{}, err
 		}
 		for i := range reqs {
 			if reqs[i].Error != nil {
-				return nil, reqs[i].Error
+				return nil, common.Hash{}, reqs[i].Error
 			}
 			if uncles[i] == nil {
-				return nil, fmt.Errorf("got null header for uncle %d of block %x", i, body.Hash[:])
+				return nil, common.Hash{}, fmt.Errorf("got null header for uncle %d of block %x", i, body.Hash[:])
 			}
 		}
 	}
@@ -167,7 +194,7 @@ func (ec *Client) getBlock(ctx context.Context, method string, args ...interface
 		}
 		txs[i] = tx.tx
 	}
-	return types.NewBlockWithHeader(head).WithBody(txs, uncles), nil
+	return types.NewBlockWithHeader(head).WithBody(txs, uncles), body.Hash, nil
 }
 
 // HeaderByHash returns the block header with the given hash.
@@ -503,6 +530,35 @@ func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+// SuggestGasTipCap retrieves the currently suggested gas tip cap after 1559 to
+// allow a timely execution of a transaction.
+func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	var hex hexutil.Big
+	if err := ec.c.CallContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&hex), nil
+}
+
+// accessListResult is the result of eth_createAccessList.
+type accessListResult struct {
+	Accesslist *types.AccessList `json:"accessList"`
+	Error      string            `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64    `json:"gasUsed"`
+}
+
+// CreateAccessList tries to create an access list for a specific transaction based on the
+// current pending state of the blockchain. It returns the access list, the gas used by
+// the transaction with the access list applied and the revert reason of the execution,
+// which is empty if the execution succeeded.
+func (ec *Client) CreateAccessList(ctx context.Context, msg CallMsg) (*types.AccessList, uint64, string, error) {
+	var result accessListResult
+	if err := ec.c.CallContext(ctx, &result, "eth_createAccessList", toCallArg(msg)); err != nil {
+		return nil, 0, "", err
+	}
+	return result.Accesslist, uint64(result.GasUsed), result.Error, nil
+}
+
 // EstimateGas tries to estimate the gas needed to execute a specific transaction based on
 // the current pending state of the backend blockchain. There is no guarantee that this is
 // the true gas limit requirement as other transactions may be added or removed by miners,
@@ -521,7 +577,7 @@ func (ec *Client) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error)
 // If the transaction was a contract creation use the TransactionReceipt method to get the
 // contract address after the transaction has been mined.
 func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -545,5 +601,14 @@ func toCallArg(msg CallMsg) interface{} {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
 	return arg
 }
@@ -0,0 +1,553 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/ethclient"
+	"github.com/ethclient/common"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rpc"
+)
+
+// SlowConsumerPolicy decides what a SubscriptionHub does with consumers that
+// do not keep up with their notifications.
+type SlowConsumerPolicy int
+
+const (
+	// DropSlow queues up to HubConfig.BufferSize notifications per consumer
+	// and drops the notifications that do not fit.
+	DropSlow SlowConsumerPolicy = iota
+	// BufferSlow queues up to HubConfig.BufferSize notifications per consumer
+	// and disconnects the consumer when the queue overflows.
+	BufferSlow
+	// DisconnectSlow disconnects a consumer as soon as its channel cannot take
+	// a notification. The channel needs room for the replay buffer.
+	DisconnectSlow
+)
+
+// ErrSlowConsumer is sent on the error channel of hub subscriptions that were
+// disconnected by the slow consumer policy.
+var ErrSlowConsumer = errors.New("subscription dropped: consumer too slow")
+
+var errHubClosed = errors.New("subscription hub closed")
+
+const defaultHubBufferSize = 256
+
+// HubConfig configures a SubscriptionHub.
+type HubConfig struct {
+	Policy     SlowConsumerPolicy
+	BufferSize int // queue size per consumer for DropSlow and BufferSlow, default 256
+	ReplaySize int // recent notifications per stream replayed to new consumers
+	// Resubscribe makes the upstream subscriptions resilient, see
+	// SubscribeNewHeadResilient. Without it an upstream failure ends all
+	// subscriptions of the stream.
+	Resubscribe *rpc.ResubscribeConfig
+}
+
+// SubscriptionHub multiplexes upstream subscriptions to any number of
+// consumers. There is one upstream subscription per distinct subscription
+// type and filter, it is created for the first consumer and removed with the
+// last one. Consumers are local channels or, through API, the subscribers of
+// an rpc.Server.
+type SubscriptionHub struct {
+	client *Client
+	config HubConfig
+
+	mu      sync.Mutex
+	streams map[string]*hubStream
+	pending map[string]chan struct{} // streams being subscribed upstream, closed when done
+	closed  bool
+}
+
+// NewSubscriptionHub creates a hub subscribing through client.
+func NewSubscriptionHub(client *Client, config HubConfig) *SubscriptionHub {
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultHubBufferSize
+	}
+	return &SubscriptionHub{
+		client:  client,
+		config:  config,
+		streams: make(map[string]*hubStream),
+		pending: make(map[string]chan struct{}),
+	}
+}
+
+// SubscribeNewHead delivers the new heads of the shared newHeads stream to ch.
+func (h *SubscriptionHub) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethclient.Subscription, error) {
+	return h.subscribe(ctx, "newHeads", reflect.ValueOf(ch), func(ctx context.Context) (reflect.Value, ethclient.Subscription, error) {
+		in := make(chan *types.Header, defaultHubBufferSize)
+		var sub ethclient.Subscription
+		var err error
+		if h.config.Resubscribe != nil {
+			sub, err = h.client.SubscribeNewHeadResilient(ctx, in, *h.config.Resubscribe)
+		} else {
+			sub, err = h.client.SubscribeNewHead(ctx, in)
+		}
+		return reflect.ValueOf(in), sub, err
+	})
+}
+
+// SubscribeFilterLogs delivers the logs of the shared stream for q to ch.
+// Streams are shared by queries with equal addresses and topics.
+func (h *SubscriptionHub) SubscribeFilterLogs(ctx context.Context, q ethclient.FilterQuery, ch chan<- types.Log) (ethclient.Subscription, error) {
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	key, err := json.Marshal(arg)
+	if err != nil {
+		return nil, err
+	}
+	return h.subscribe(ctx, "logs"+string(key), reflect.ValueOf(ch), func(ctx context.Context) (reflect.Value, ethclient.Subscription, error) {
+		in := make(chan types.Log, defaultHubBufferSize)
+		var sub ethclient.Subscription
+		var err error
+		if h.config.Resubscribe != nil {
+			sub, err = h.client.SubscribeFilterLogsResilient(ctx, q, in, *h.config.Resubscribe)
+		} else {
+			sub, err = h.client.SubscribeFilterLogs(ctx, q, in)
+		}
+		return reflect.ValueOf(in), sub, err
+	})
+}
+
+// Streams returns the number of upstream subscriptions.
+func (h *SubscriptionHub) Streams() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.streams)
+}
+
+// Close ends all upstream subscriptions. The consumers receive an error.
+func (h *SubscriptionHub) Close() {
+	h.mu.Lock()
+	streams := h.streams
+	h.streams = make(map[string]*hubStream)
+	h.closed = true
+	h.mu.Unlock()
+
+	for _, s := range streams {
+		s.fail(errHubClosed)
+	}
+}
+
+type upstreamFunc func(ctx context.Context) (reflect.Value, ethclient.Subscription, error)
+
+// subscribe adds ch to the stream of key, creating the upstream subscription
+// for the first consumer. The hub lock is not held during the upstream call;
+// concurrent consumers of the same key wait for it and subscribe upstream
+// themselves if it fails.
+func (h *SubscriptionHub) subscribe(ctx context.Context, key string, ch reflect.Value, upstream upstreamFunc) (ethclient.Subscription, error) {
+	for {
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return nil, errHubClosed
+		}
+		if s, ok := h.streams[key]; ok {
+			c := s.add(ch)
+			h.mu.Unlock()
+			return c, nil
+		}
+		done, ok := h.pending[key]
+		if !ok {
+			break
+		}
+		h.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	done := make(chan struct{})
+	h.pending[key] = done
+	h.mu.Unlock()
+
+	in, sub, err := upstream(ctx)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.pending, key)
+	close(done)
+	if err != nil {
+		return nil, err
+	}
+	if h.closed {
+		sub.Unsubscribe()
+		return nil, errHubClosed
+	}
+	s := &hubStream{hub: h, key: key, in: in, sub: sub, consumers: make(map[*hubConsumer]struct{}), quit: make(chan struct{})}
+	h.streams[key] = s
+	go s.run()
+	return s.add(ch), nil
+}
+
+// remove drops s from the hub if it is still registered.
+func (h *SubscriptionHub) remove(s *hubStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[s.key] == s {
+		delete(h.streams, s.key)
+	}
+}
+
+// hubStream is an upstream subscription and its consumers.
+type hubStream struct {
+	hub *SubscriptionHub
+	key string
+	in  reflect.Value // channel of the upstream subscription
+	sub ethclient.Subscription
+
+	mu        sync.Mutex
+	consumers map[*hubConsumer]struct{}
+	replay    []reflect.Value
+	ended     bool
+
+	quit     chan struct{} // closed when the stream ends
+	quitOnce sync.Once
+}
+
+// run distributes the upstream notifications until the stream ends.
+func (s *hubStream) run() {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.quit)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.sub.Err())},
+		{Dir: reflect.SelectRecv, Chan: s.in},
+	}
+	for {
+		chosen, item, ok := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			return
+		case 1:
+			err, _ := item.Interface().(error)
+			if !ok || err == nil {
+				err = errors.New("upstream subscription ended")
+			}
+			s.hub.remove(s)
+			s.fail(err)
+			return
+		case 2:
+			s.publish(item)
+		}
+	}
+}
+
+func (s *hubStream) publish(item reflect.Value) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if size := s.hub.config.ReplaySize; size > 0 {
+		if len(s.replay) == size {
+			s.replay = append(s.replay[:0], s.replay[1:]...)
+		}
+		s.replay = append(s.replay, item)
+	}
+	for c := range s.consumers {
+		if !c.deliver(item) {
+			delete(s.consumers, c)
+			go c.fail(ErrSlowConsumer)
+		}
+	}
+}
+
+// add registers a consumer and queues the replay buffer for it. The caller
+// holds the hub lock, so s has not ended.
+func (s *hubStream) add(ch reflect.Value) *hubConsumer {
+	c := &hubConsumer{
+		stream: s,
+		ch:     ch,
+		policy: s.hub.config.Policy,
+		limit:  s.hub.config.BufferSize,
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		err:    make(chan error, 1),
+	}
+	if c.policy != DisconnectSlow {
+		go c.loop()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.replay {
+		if !c.deliver(item) {
+			go c.fail(ErrSlowConsumer)
+			return c
+		}
+	}
+	s.consumers[c] = struct{}{}
+	return c
+}
+
+// removeConsumer unregisters c and ends the stream with its last consumer.
+func (s *hubStream) removeConsumer(c *hubConsumer) {
+	s.hub.mu.Lock()
+	s.mu.Lock()
+	delete(s.consumers, c)
+	last := len(s.consumers) == 0 && !s.ended
+	if last {
+		s.ended = true
+		if s.hub.streams[s.key] == s {
+			delete(s.hub.streams, s.key)
+		}
+	}
+	s.mu.Unlock()
+	s.hub.mu.Unlock()
+
+	if last {
+		s.stop()
+	}
+}
+
+// stop ends the distribution goroutine and the upstream subscription.
+func (s *hubStream) stop() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+		s.sub.Unsubscribe()
+	})
+}
+
+// fail ends the stream and the subscriptions of all consumers with err.
+func (s *hubStream) fail(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	consumers := s.consumers
+	s.consumers = make(map[*hubConsumer]struct{})
+	s.mu.Unlock()
+
+	s.stop()
+	for c := range consumers {
+		c.fail(err)
+	}
+}
+
+// hubConsumer is a subscription of the hub, it implements
+// ethclient.Subscription.
+type hubConsumer struct {
+	stream *hubStream
+	ch     reflect.Value
+	policy SlowConsumerPolicy
+	limit  int
+
+	mu    sync.Mutex
+	queue []reflect.Value
+	wake  chan struct{}
+
+	quit     chan struct{}
+	quitOnce sync.Once
+	err      chan error
+}
+
+// deliver queues or sends an item and reports whether the consumer keeps up.
+func (c *hubConsumer) deliver(item reflect.Value) bool {
+	if c.policy == DisconnectSlow {
+		return c.ch.TrySend(item)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.queue) >= c.limit {
+		return c.policy == DropSlow
+	}
+	c.queue = append(c.queue, item)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// loop sends the queued items to the consumer channel. Items stay queued
+// until they are sent, so the queue length is the consumer backlog.
+func (c *hubConsumer) loop() {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.quit)},
+		{Dir: reflect.SelectSend, Chan: c.ch},
+	}
+	for {
+		select {
+		case <-c.quit:
+			return
+		case <-c.wake:
+		}
+		for {
+			c.mu.Lock()
+			if len(c.queue) == 0 {
+				c.mu.Unlock()
+				break
+			}
+			cases[1].Send = c.queue[0]
+			c.mu.Unlock()
+			if chosen, _, _ := reflect.Select(cases); chosen == 0 {
+				return
+			}
+			c.mu.Lock()
+			c.queue[0] = reflect.Value{}
+			c.queue = c.queue[1:]
+			c.mu.Unlock()
+		}
+	}
+}
+
+// fail ends the subscription with err.
+func (c *hubConsumer) fail(err error) {
+	c.quitOnce.Do(func() {
+		close(c.quit)
+		c.err <- err
+		close(c.err)
+		c.stream.removeConsumer(c)
+	})
+}
+
+// Unsubscribe ends the subscription and closes the error channel.
+func (c *hubConsumer) Unsubscribe() {
+	c.quitOnce.Do(func() {
+		close(c.quit)
+		close(c.err)
+		c.stream.removeConsumer(c)
+	})
+}
+
+func (c *hubConsumer) Err() <-chan error {
+	return c.err
+}
+
+// HubAPI serves the streams of a hub as the newHeads and logs subscriptions
+// of the eth namespace:
+//
+//	server.RegisterName("eth", hub.API())
+//	http.Handle("/ws", server.WebsocketHandler([]string{"*"}))
+//
+// Subscribers disconnected by the slow consumer policy lose their connection.
+// With DisconnectSlow a subscriber is disconnected once it falls more than
+// ReplaySize+BufferSize notifications behind.
+type HubAPI struct {
+	hub *SubscriptionHub
+}
+
+// API returns the rpc service of the hub.
+func (h *SubscriptionHub) API() *HubAPI {
+	return &HubAPI{hub: h}
+}
+
+// NewHeads serves eth_subscribe("newHeads").
+func (api *HubAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	return api.serve(ctx, reflect.TypeOf((*types.Header)(nil)), func(ch interface{}) (ethclient.Subscription, error) {
+		return api.hub.SubscribeNewHead(context.Background(), ch.(chan *types.Header))
+	})
+}
+
+// Logs serves eth_subscribe("logs", {"address": ..., "topics": ...}).
+func (api *HubAPI) Logs(ctx context.Context, crit filterCriteria) (*rpc.Subscription, error) {
+	q := ethclient.FilterQuery{Addresses: crit.Addresses, Topics: crit.Topics}
+	return api.serve(ctx, reflect.TypeOf(types.Log{}), func(ch interface{}) (ethclient.Subscription, error) {
+		return api.hub.SubscribeFilterLogs(context.Background(), q, ch.(chan types.Log))
+	})
+}
+
+// serve subscribes a channel of the given element type to the hub and
+// forwards its items to the rpc subscriber.
+func (api *HubAPI) serve(ctx context.Context, elem reflect.Type, subscribe func(ch interface{}) (ethclient.Subscription, error)) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	// The channel is read only after subscribing, it holds the replayed
+	// notifications and the bursts arriving while a notification is written.
+	size := api.hub.config.ReplaySize + api.hub.config.BufferSize
+	ch := reflect.MakeChan(reflect.ChanOf(reflect.BothDir, elem), size)
+	sub, err := subscribe(ch.Interface())
+	if err != nil {
+		return nil, err
+	}
+	rpcSub := notifier.CreateSubscription()
+	go func() {
+		defer sub.Unsubscribe()
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(rpcSub.Err())},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(notifier.Closed())},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.Err())},
+			{Dir: reflect.SelectRecv, Chan: ch},
+		}
+		for {
+			chosen, item, _ := reflect.Select(cases)
+			switch chosen {
+			case 0, 1:
+				return
+			case 2:
+				if err, _ := item.Interface().(error); err == ErrSlowConsumer {
+					notifier.Disconnect()
+				}
+				return
+			case 3:
+				if err := notifier.Notify(rpcSub.ID, item.Interface()); err != nil {
+					return
+				}
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// filterCriteria is the filter argument of logs subscriptions.
+type filterCriteria struct {
+	Addresses []common.Address
+	Topics    [][]common.Hash
+}
+
+// UnmarshalJSON accepts a single address or a list of addresses, and topics
+// given as null, a hash or a list of hashes per position.
+func (c *filterCriteria) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Address json.RawMessage   `json:"address"`
+		Topics  []json.RawMessage `json:"topics"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Address) > 0 && string(raw.Address) != "null" {
+		var single common.Address
+		if err := json.Unmarshal(raw.Address, &single); err == nil {
+			c.Addresses = []common.Address{single}
+		} else if err := json.Unmarshal(raw.Address, &c.Addresses); err != nil {
+			return fmt.Errorf("invalid address: %v", err)
+		}
+	}
+	c.Topics = make([][]common.Hash, len(raw.Topics))
+	for i, topic := range raw.Topics {
+		if len(topic) == 0 || string(topic) == "null" {
+			continue
+		}
+		var single common.Hash
+		if err := json.Unmarshal(topic, &single); err == nil {
+			c.Topics[i] = []common.Hash{single}
+		} else if err := json.Unmarshal(topic, &c.Topics[i]); err != nil {
+			return fmt.Errorf("invalid topic %d: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethclient"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rpc"
+)
+
+func waitHead(t *testing.T, ch <-chan *types.Header, want uint64) {
+	t.Helper()
+	select {
+	case head := <-ch:
+		if head.Number.Uint64() != want {
+			t.Fatalf("head %d, want %d", head.Number.Uint64(), want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for head %d", want)
+	}
+}
+
+func TestSubscriptionHub(t *testing.T) {
+	node := &wsNode{t: t, chain: newTestChain(), addr: "127.0.0.1:0"}
+	node.start()
+	defer node.kill()
+	rpcClient, err := rpc.DialWebsocket(context.Background(), "ws://"+node.addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcClient.Close()
+	hub := NewSubscriptionHub(NewClient(rpcClient), HubConfig{Policy: BufferSlow, ReplaySize: 2})
+	defer hub.Close()
+
+	heads1, heads2 := make(chan *types.Header), make(chan *types.Header)
+	sub1, err := hub.SubscribeNewHead(context.Background(), heads1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub2, err := hub.SubscribeNewHead(context.Background(), heads2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logSub, err := hub.SubscribeFilterLogs(context.Background(), ethclient.FilterQuery{}, make(chan types.Log, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := hub.Streams(); n != 2 {
+		t.Fatalf("%d upstream subscriptions, want 2", n)
+	}
+
+	node.chain.mine(3)
+	for n := uint64(1); n <= 3; n++ {
+		waitHead(t, heads1, n)
+		waitHead(t, heads2, n)
+	}
+	// late consumers get the replay buffer first
+	heads3 := make(chan *types.Header, 10)
+	sub3, err := hub.SubscribeNewHead(context.Background(), heads3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitHead(t, heads3, 2)
+	waitHead(t, heads3, 3)
+
+	// stop reading heads2: BufferSlow disconnects it after BufferSize heads
+	node.chain.mine(defaultHubBufferSize + 2)
+	for n := uint64(4); n <= node.chain.head(); n++ {
+		waitHead(t, heads1, n)
+		waitHead(t, heads3, n)
+	}
+	select {
+	case err := <-sub2.Err():
+		if err != ErrSlowConsumer {
+			t.Fatalf("slow consumer error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow consumer not disconnected")
+	}
+
+	sub1.Unsubscribe()
+	if n := hub.Streams(); n != 2 {
+		t.Fatalf("%d upstream subscriptions after first unsubscribe, want 2", n)
+	}
+	sub3.Unsubscribe()
+	logSub.Unsubscribe()
+	if n := hub.Streams(); n != 0 {
+		t.Fatalf("%d upstream subscriptions after last unsubscribe, want 0", n)
+	}
+}
+
+func TestSubscriptionHubAPI(t *testing.T) {
+	node := &wsNode{t: t, chain: newTestChain(), addr: "127.0.0.1:0"}
+	node.start()
+	defer node.kill()
+	upstream, err := rpc.DialWebsocket(context.Background(), "ws://"+node.addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	hub := NewSubscriptionHub(NewClient(upstream), HubConfig{})
+	defer hub.Close()
+
+	// serve the hub to downstream websocket clients
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", hub.API()); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := &http.Server{Handler: server.WebsocketHandler([]string{"*"})}
+	go httpsrv.Serve(listener)
+	defer httpsrv.Close()
+
+	var subs []ethclient.Subscription
+	var chans []chan *types.Header
+	for i := 0; i < 3; i++ {
+		downstream, err := rpc.DialWebsocket(context.Background(), "ws://"+listener.Addr().String(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer downstream.Close()
+		ch := make(chan *types.Header, 10)
+		sub, err := NewClient(downstream).SubscribeNewHead(context.Background(), ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subs = append(subs, sub)
+		chans = append(chans, ch)
+	}
+	if n := hub.Streams(); n != 1 {
+		t.Fatalf("%d upstream subscriptions, want 1", n)
+	}
+	node.chain.mine(2)
+	for _, ch := range chans {
+		waitHead(t, ch, 1)
+		waitHead(t, ch, 2)
+	}
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.Streams() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("upstream subscription not released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscriptionHubAPIDisconnectSlow(t *testing.T) {
+	node := &wsNode{t: t, chain: newTestChain(), addr: "127.0.0.1:0"}
+	node.start()
+	defer node.kill()
+	upstream, err := rpc.DialWebsocket(context.Background(), "ws://"+node.addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstream.Close()
+	hub := NewSubscriptionHub(NewClient(upstream), HubConfig{Policy: DisconnectSlow, ReplaySize: 2})
+	defer hub.Close()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", hub.API()); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := &http.Server{Handler: server.WebsocketHandler([]string{"*"})}
+	go httpsrv.Serve(listener)
+	defer httpsrv.Close()
+
+	// a local consumer keeps the stream and fills the replay buffer
+	local := make(chan *types.Header, 100)
+	localSub, err := hub.SubscribeNewHead(context.Background(), local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localSub.Unsubscribe()
+	node.chain.mine(3)
+	for n := uint64(1); n <= 3; n++ {
+		waitHead(t, local, n)
+	}
+
+	downstream, err := rpc.DialWebsocket(context.Background(), "ws://"+listener.Addr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer downstream.Close()
+	heads := make(chan *types.Header, 100)
+	sub, err := NewClient(downstream).SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	// the replayed heads and a burst of new heads do not disconnect it
+	waitHead(t, heads, 2)
+	waitHead(t, heads, 3)
+	node.chain.mine(20)
+	for n := uint64(4); n <= 23; n++ {
+		waitHead(t, heads, n)
+	}
+	select {
+	case err := <-sub.Err():
+		t.Fatalf("subscriber disconnected: %v", err)
+	default:
+	}
+}
+
+func TestSubscriptionHubDisconnectSlow(t *testing.T) {
+	hub := NewSubscriptionHub(nil, HubConfig{Policy: DisconnectSlow, ReplaySize: 1})
+	defer hub.Close()
+	in := make(chan int)
+	upstream := func(ctx context.Context) (reflect.Value, ethclient.Subscription, error) {
+		return reflect.ValueOf(in), &fakeSub{err: make(chan error)}, nil
+	}
+	fast := make(chan int, 10)
+	if _, err := hub.subscribe(context.Background(), "test", reflect.ValueOf(fast), upstream); err != nil {
+		t.Fatal(err)
+	}
+	slow := make(chan int, 1)
+	slowSub, err := hub.subscribe(context.Background(), "test", reflect.ValueOf(slow), upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in <- 1
+	in <- 2
+	select {
+	case err := <-slowSub.Err():
+		if err != ErrSlowConsumer {
+			t.Fatalf("slow consumer error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow consumer not disconnected")
+	}
+	// a late consumer without room for the replay is disconnected at once
+	late, err := hub.subscribe(context.Background(), "test", reflect.ValueOf(make(chan int)), upstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-late.Err():
+		if err != ErrSlowConsumer {
+			t.Fatalf("late consumer error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("late consumer not disconnected")
+	}
+	if len(fast) != 2 {
+		t.Errorf("fast consumer got %d items, want 2", len(fast))
+	}
+}
+
+type fakeSub struct {
+	err  chan error
+	once sync.Once
+}
+
+func (s *fakeSub) Unsubscribe()      { s.once.Do(func() { close(s.err) }) }
+func (s *fakeSub) Err() <-chan error { return s.err }
+
+func TestSubscriptionHubPendingUpstream(t *testing.T) {
+	hub := NewSubscriptionHub(nil, HubConfig{})
+	defer hub.Close()
+
+	var calls int32
+	release := make(chan error)
+	upstream := func(ctx context.Context) (reflect.Value, ethclient.Subscription, error) {
+		atomic.AddInt32(&calls, 1)
+		if err := <-release; err != nil {
+			return reflect.Value{}, nil, err
+		}
+		return reflect.ValueOf(make(chan int)), &fakeSub{err: make(chan error)}, nil
+	}
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := hub.subscribe(context.Background(), "test", reflect.ValueOf(make(chan int)), upstream)
+			results <- err
+		}()
+	}
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the hub is not locked while the upstream call is pending
+	if n := hub.Streams(); n != 0 {
+		t.Fatalf("%d upstream subscriptions while pending, want 0", n)
+	}
+	// the waiting consumer subscribes upstream itself after a failure
+	release <- errors.New("upstream failed")
+	if err := <-results; err == nil {
+		t.Fatal("expected the failed upstream error")
+	}
+	release <- nil
+	if err := <-results; err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("%d upstream calls, want 2", n)
+	}
+	if _, err := hub.subscribe(context.Background(), "test", reflect.ValueOf(make(chan int)), upstream); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("%d upstream calls after joining the stream, want 2", n)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethclient"
+	"github.com/ethclient/common"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rpc"
+)
+
+// maxBackfillRange is the largest block range of a single FilterLogs call of
+// a log backfill, so that a long outage does not turn into one huge query.
+const maxBackfillRange = 1000
+
+// SubscribeNewHeadResilient is like SubscribeNewHead, but the subscription
+// survives connection loss: the client reconnects with backoff, subscribes
+// again and delivers the heads mined while the connection was down before
+// any new head. ch is never closed. If config.OnResubscribe is set, it is
+// called after the backfill.
+func (ec *Client) SubscribeNewHeadResilient(ctx context.Context, ch chan<- *types.Header, config rpc.ResubscribeConfig) (ethclient.Subscription, error) {
+	f := &headForwarder{ec: ec, in: make(chan *types.Header), out: ch}
+	return ec.subscribeResilient(ctx, f, config, "newHeads")
+}
+
+// SubscribeFilterLogsResilient is like SubscribeFilterLogs, but the
+// subscription survives connection loss: the client reconnects with backoff,
+// subscribes again and uses FilterLogs to deliver the matching logs of the
+// blocks mined while the connection was down before any new log. ch is never
+// closed. If config.OnResubscribe is set, it is called after the backfill.
+// The backfill queries the missed blocks in ranges of at most 1000 blocks.
+func (ec *Client) SubscribeFilterLogsResilient(ctx context.Context, q ethclient.FilterQuery, ch chan<- types.Log, config rpc.ResubscribeConfig) (ethclient.Subscription, error) {
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	f := &logForwarder{ec: ec, in: make(chan types.Log), out: ch, query: q, maxRange: maxBackfillRange}
+	// Start backfilling after the current head, if nothing has been delivered.
+	head, err := ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	f.cursor = head.Number.Uint64() + 1
+	return ec.subscribeResilient(ctx, f, config, "logs", arg)
+}
+
+// forwarder passes notifications of a resilient subscription on to the
+// caller and fills the gap after a reconnect.
+type forwarder interface {
+	channel() interface{} // channel the rpc subscription delivers to
+	run(quit <-chan struct{}, backfill chan backfillReq)
+}
+
+// backfillReq asks the forwarder goroutine to deliver missed notifications.
+type backfillReq struct {
+	ctx  context.Context
+	done chan error
+}
+
+// resilientSub implements ethclient.Subscription for resilient subscriptions.
+type resilientSub struct {
+	sub      *rpc.ResilientSubscription
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+func (s *resilientSub) Unsubscribe() {
+	s.quitOnce.Do(func() { close(s.quit) })
+	s.sub.Unsubscribe()
+}
+
+func (s *resilientSub) Err() <-chan error {
+	return s.sub.Err()
+}
+
+func (ec *Client) subscribeResilient(ctx context.Context, f forwarder, config rpc.ResubscribeConfig, args ...interface{}) (ethclient.Subscription, error) {
+	quit := make(chan struct{})
+	backfill := make(chan backfillReq)
+	hook := config.OnResubscribe
+	config.OnResubscribe = func(ctx context.Context) error {
+		req := backfillReq{ctx: ctx, done: make(chan error, 1)}
+		select {
+		case backfill <- req:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := <-req.done; err != nil {
+			return err
+		}
+		if hook != nil {
+			return hook(ctx)
+		}
+		return nil
+	}
+	sub, err := ec.c.SubscribeResilient(ctx, "eth", f.channel(), config, args...)
+	if err != nil {
+		return nil, err
+	}
+	go f.run(quit, backfill)
+	return &resilientSub{sub: sub, quit: quit}, nil
+}
+
+// headForwarder tracks the last delivered head to backfill missed heads.
+type headForwarder struct {
+	ec   *Client
+	in   chan *types.Header
+	out  chan<- *types.Header
+	last *big.Int // number of the last delivered head
+
+	// heads delivered by the last backfill, which might arrive again
+	backfilled   map[common.Hash]bool
+	backfilledTo uint64
+}
+
+func (f *headForwarder) channel() interface{} { return f.in }
+
+func (f *headForwarder) run(quit <-chan struct{}, backfill chan backfillReq) {
+	for {
+		select {
+		case head := <-f.in:
+			if f.backfilled[head.Hash()] {
+				continue
+			}
+			if head.Number.Uint64() > f.backfilledTo {
+				f.backfilled = nil
+			}
+			if !f.deliver(head, quit) {
+				return
+			}
+		case req := <-backfill:
+			req.done <- f.backfill(req.ctx, quit)
+		case <-quit:
+			return
+		}
+	}
+}
+
+func (f *headForwarder) deliver(head *types.Header, quit <-chan struct{}) bool {
+	select {
+	case f.out <- head:
+		f.last = head.Number
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+func (f *headForwarder) backfill(ctx context.Context, quit <-chan struct{}) error {
+	if f.last == nil {
+		return nil
+	}
+	head, err := f.ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if f.backfilled == nil {
+		f.backfilled = make(map[common.Hash]bool)
+	}
+	for n := new(big.Int).Add(f.last, common.Big1); n.Cmp(head.Number) <= 0; n = new(big.Int).Add(n, common.Big1) {
+		header := head
+		if n.Cmp(head.Number) < 0 {
+			if header, err = f.ec.HeaderByNumber(ctx, n); err != nil {
+				return err
+			}
+		}
+		if !f.deliver(header, quit) {
+			return nil
+		}
+		f.backfilled[header.Hash()] = true
+		f.backfilledTo = header.Number.Uint64()
+	}
+	return nil
+}
+
+// logForwarder tracks the position of the last delivered log and the first
+// block not yet covered to backfill missed logs.
+type logForwarder struct {
+	ec    *Client
+	in    chan types.Log
+	out   chan<- types.Log
+	query ethclient.FilterQuery
+
+	maxRange  uint64 // blocks per FilterLogs call of a backfill
+	cursor    uint64 // first block which might have undelivered logs
+	delivered bool   // whether lastBlock and lastIndex are set
+	lastBlock uint64
+	lastIndex uint
+}
+
+func (f *logForwarder) channel() interface{} { return f.in }
+
+func (f *logForwarder) run(quit <-chan struct{}, backfill chan backfillReq) {
+	for {
+		select {
+		case log := <-f.in:
+			if !f.deliver(log, quit) {
+				return
+			}
+		case req := <-backfill:
+			req.done <- f.backfill(req.ctx, quit)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// seen reports whether log has already been delivered, assuming logs arrive
+// in chain order.
+func (f *logForwarder) seen(log types.Log) bool {
+	if !f.delivered || log.Removed {
+		return false
+	}
+	return log.BlockNumber < f.lastBlock || (log.BlockNumber == f.lastBlock && log.Index <= f.lastIndex)
+}
+
+func (f *logForwarder) deliver(log types.Log, quit <-chan struct{}) bool {
+	if f.seen(log) {
+		return true
+	}
+	select {
+	case f.out <- log:
+	case <-quit:
+		return false
+	}
+	if !log.Removed {
+		f.delivered, f.lastBlock, f.lastIndex = true, log.BlockNumber, log.Index
+		if f.cursor < log.BlockNumber {
+			f.cursor = log.BlockNumber
+		}
+	}
+	return true
+}
+
+func (f *logForwarder) backfill(ctx context.Context, quit <-chan struct{}) error {
+	if f.query.BlockHash != nil {
+		return nil
+	}
+	head, err := f.ec.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	from, to := f.cursor, head.Number.Uint64()
+	if f.query.FromBlock != nil && f.query.FromBlock.Uint64() > from {
+		from = f.query.FromBlock.Uint64()
+	}
+	if f.query.ToBlock != nil && f.query.ToBlock.Uint64() < to {
+		to = f.query.ToBlock.Uint64()
+	}
+	// Query bounded ranges and advance the cursor after each, so a failed
+	// backfill resumes where it stopped.
+	for from <= to {
+		end := to
+		if end-from >= f.maxRange {
+			end = from + f.maxRange - 1
+		}
+		q := f.query
+		q.FromBlock, q.ToBlock = new(big.Int).SetUint64(from), new(big.Int).SetUint64(end)
+		logs, err := f.ec.FilterLogs(ctx, q)
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if !f.deliver(log, quit) {
+				return nil
+			}
+		}
+		f.cursor = end + 1
+		from = end + 1
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethclient"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rpc"
+)
+
+// testChain is a fake chain served by testEthAPI.
+type testChain struct {
+	mu      sync.Mutex
+	headers []*types.Header
+}
+
+func newTestChain() *testChain {
+	genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1)}
+	return &testChain{headers: []*types.Header{genesis}}
+}
+
+func (c *testChain) mine(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := 0; i < n; i++ {
+		parent := c.headers[len(c.headers)-1]
+		c.headers = append(c.headers, &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Difficulty: big.NewInt(1),
+		})
+	}
+}
+
+func (c *testChain) header(n uint64) *types.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[n]
+}
+
+func (c *testChain) head() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return uint64(len(c.headers) - 1)
+}
+
+// blockLog is the single log emitted by every block.
+func (c *testChain) blockLog(n uint64) types.Log {
+	header := c.header(n)
+	return types.Log{
+		Address:     common.HexToAddress("0x01"),
+		Topics:      []common.Hash{},
+		Data:        []byte{},
+		BlockNumber: n,
+		BlockHash:   header.Hash(),
+	}
+}
+
+type testEthAPI struct {
+	chain *testChain
+
+	mu     sync.Mutex
+	ranges [][2]uint64 // block ranges of GetLogs calls
+}
+
+func (api *testEthAPI) GetBlockByNumber(number rpc.BlockNumber, full bool) *types.Header {
+	if number < 0 {
+		return api.chain.header(api.chain.head())
+	}
+	return api.chain.header(uint64(number))
+}
+
+func (api *testEthAPI) GetLogs(crit map[string]interface{}) []types.Log {
+	from, _ := hexutil.DecodeUint64(crit["fromBlock"].(string))
+	to, _ := hexutil.DecodeUint64(crit["toBlock"].(string))
+	api.mu.Lock()
+	api.ranges = append(api.ranges, [2]uint64{from, to})
+	api.mu.Unlock()
+	logs := []types.Log{}
+	for n := from; n <= to && n <= api.chain.head(); n++ {
+		logs = append(logs, api.chain.blockLog(n))
+	}
+	return logs
+}
+
+func (api *testEthAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, func(n uint64) interface{} { return api.chain.header(n) })
+}
+
+func (api *testEthAPI) Logs(ctx context.Context, crit map[string]interface{}) (*rpc.Subscription, error) {
+	return api.subscribe(ctx, func(n uint64) interface{} { return api.chain.blockLog(n) })
+}
+
+// subscribe notifies about every block mined after the subscription.
+func (api *testEthAPI) subscribe(ctx context.Context, item func(n uint64) interface{}) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	next := api.chain.head() + 1
+	go func() {
+		for {
+			select {
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+			for ; next <= api.chain.head(); next++ {
+				notifier.Notify(sub.ID, item(next))
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// wsNode serves a testChain over websocket and can be killed and restarted
+// on the same address.
+type wsNode struct {
+	t      *testing.T
+	chain  *testChain
+	addr   string
+	rpc    *rpc.Server
+	server *http.Server
+}
+
+func (n *wsNode) start() {
+	listener, err := net.Listen("tcp", n.addr)
+	if err != nil {
+		n.t.Fatal(err)
+	}
+	n.addr = listener.Addr().String()
+	n.rpc = rpc.NewServer()
+	if err := n.rpc.RegisterName("eth", &testEthAPI{chain: n.chain}); err != nil {
+		n.t.Fatal(err)
+	}
+	n.server = &http.Server{Handler: n.rpc.WebsocketHandler([]string{"*"})}
+	go n.server.Serve(listener)
+}
+
+func (n *wsNode) kill() {
+	n.server.Close()
+	n.rpc.Stop() // closes the hijacked websocket connections
+}
+
+func TestResilientSubscription(t *testing.T) {
+	node := &wsNode{t: t, chain: newTestChain(), addr: "127.0.0.1:0"}
+	node.start()
+	defer func() { node.kill() }()
+
+	rpcClient, err := rpc.DialWebsocket(context.Background(), "ws://"+node.addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcClient.Close()
+	ec := NewClient(rpcClient)
+	config := rpc.ResubscribeConfig{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	heads := make(chan *types.Header, 100)
+	headSub, err := ec.SubscribeNewHeadResilient(context.Background(), heads, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer headSub.Unsubscribe()
+	logs := make(chan types.Log, 100)
+	logSub, err := ec.SubscribeFilterLogsResilient(context.Background(), ethclient.FilterQuery{}, logs, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logSub.Unsubscribe()
+
+	var nextHead, nextLog uint64 = 1, 1
+	expect := func(to uint64) {
+		timeout := time.After(5 * time.Second)
+		for nextHead <= to || nextLog <= to {
+			select {
+			case head := <-heads:
+				if head.Number.Uint64() != nextHead {
+					t.Fatalf("got head %d, want %d", head.Number, nextHead)
+				}
+				nextHead++
+			case log := <-logs:
+				if log.BlockNumber != nextLog {
+					t.Fatalf("got log of block %d, want %d", log.BlockNumber, nextLog)
+				}
+				nextLog++
+			case err := <-headSub.Err():
+				t.Fatalf("head subscription failed: %v", err)
+			case err := <-logSub.Err():
+				t.Fatalf("log subscription failed: %v", err)
+			case <-timeout:
+				t.Fatalf("timeout waiting for block %d (next head %d, next log %d)", to, nextHead, nextLog)
+			}
+		}
+	}
+	node.chain.mine(3)
+	expect(3)
+
+	// Kill the node mid-stream, mine blocks nobody is notified about and bring
+	// the node back on the same address.
+	node.kill()
+	node.chain.mine(3)
+	time.Sleep(50 * time.Millisecond)
+	node.start()
+	expect(6)
+
+	node.chain.mine(2)
+	expect(8)
+
+	select {
+	case head := <-heads:
+		t.Fatalf("unexpected head %d", head.Number)
+	case log := <-logs:
+		t.Fatalf("unexpected log of block %d", log.BlockNumber)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestLogBackfillRanges(t *testing.T) {
+	chain := newTestChain()
+	api := &testEthAPI{chain: chain}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	ec := NewClient(rpc.DialInProc(server))
+	defer ec.Close()
+
+	logs := make(chan types.Log, 100)
+	f := &logForwarder{ec: ec, in: make(chan types.Log), out: logs, maxRange: 4, cursor: 1}
+	chain.mine(10)
+	if err := f.backfill(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]uint64{{1, 4}, {5, 8}, {9, 10}}
+	if len(api.ranges) != len(want) {
+		t.Fatalf("got ranges %v, want %v", api.ranges, want)
+	}
+	for i := range want {
+		if api.ranges[i] != want[i] {
+			t.Fatalf("got ranges %v, want %v", api.ranges, want)
+		}
+	}
+	if len(logs) != 10 || f.cursor != 11 {
+		t.Errorf("got %d logs and cursor %d, want 10 logs and cursor 11", len(logs), f.cursor)
+	}
+}
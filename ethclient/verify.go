@@ -0,0 +1,147 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rpc"
+)
+
+// IntegrityError is returned when data served by the node does not match the
+// commitments in the block header, or the header does not match the hash the
+// node reported for it.
+type IntegrityError struct {
+	Block common.Hash // block hash reported by the node
+	Field string      // name of the mismatching commitment
+	Have  string      // value recomputed from the served data
+	Want  string      // value committed to by the block
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("block %x: %s mismatch (computed %s, block has %s)", e.Block, e.Field, e.Have, e.Want)
+}
+
+// SetBlockVerification enables or disables verification of the blocks
+// returned by BlockByHash and BlockByNumber. When enabled, the receipts of
+// the block are downloaded as well and a mismatch between the block and its
+// header is reported as an *IntegrityError. It must not be called
+// concurrently with other methods of the client.
+func (ec *Client) SetBlockVerification(enabled bool) {
+	ec.verifyBlocks = enabled
+}
+
+// VerifyBlock checks that the header of block hashes to hash and that the
+// uncles, the transactions and, unless receipts is nil, the receipts and the
+// logs bloom of block match the header.
+func VerifyBlock(block *types.Block, hash common.Hash, receipts types.Receipts) error {
+	mismatch := func(field string, have, want fmt.Stringer) error {
+		return &IntegrityError{Block: hash, Field: field, Have: have.String(), Want: want.String()}
+	}
+	if have := block.Hash(); have != hash {
+		return mismatch("hash", have, hash)
+	}
+	if have := types.CalcUncleHash(block.Uncles()); have != block.UncleHash() {
+		return mismatch("sha3Uncles", have, block.UncleHash())
+	}
+	if have := types.DeriveSha(block.Transactions()); have != block.TxHash() {
+		return mismatch("transactionsRoot", have, block.TxHash())
+	}
+	if receipts == nil {
+		return nil
+	}
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return &IntegrityError{Block: hash, Field: "receipts", Have: fmt.Sprint(len(receipts)), Want: fmt.Sprint(len(txs))}
+	}
+	for i, receipt := range receipts {
+		if receipt.TxHash != txs[i].Hash() {
+			return mismatch(fmt.Sprintf("receipt %d transactionHash", i), receipt.TxHash, txs[i].Hash())
+		}
+	}
+	if have := types.DeriveSha(receipts); have != block.ReceiptHash() {
+		return mismatch("receiptsRoot", have, block.ReceiptHash())
+	}
+	if have := types.CreateBloom(receipts); have != block.Bloom() {
+		return &IntegrityError{Block: hash, Field: "logsBloom", Have: fmt.Sprintf("%x", have), Want: fmt.Sprintf("%x", block.Bloom())}
+	}
+	return nil
+}
+
+// VerifyBlockJSON verifies a block served by eth_getBlockByHash or
+// eth_getBlockByNumber with full transactions, as BlockByHash does with block
+// verification enabled. wantHash and wantNumber are the hash or number that was
+// requested, if any. It returns the receipts of the block's transactions as
+// served by the node, so that callers can decode them in their own format.
+func (ec *Client) VerifyBlockJSON(ctx context.Context, raw json.RawMessage, wantHash *common.Hash, wantNumber *big.Int) ([]json.RawMessage, error) {
+	block, hash, err := ec.decodeBlock(ctx, raw, wantHash, wantNumber)
+	if err != nil {
+		return nil, err
+	}
+	return ec.verifyReceipts(ctx, block, hash)
+}
+
+// verifyReceipts fetches the receipts of block and verifies the block against
+// them, returning the receipts as served by the node.
+func (ec *Client) verifyReceipts(ctx context.Context, block *types.Block, hash common.Hash) ([]json.RawMessage, error) {
+	raws, err := ec.blockReceipts(ctx, hash, block.Transactions())
+	if err != nil {
+		return nil, err
+	}
+	receipts := make(types.Receipts, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &receipts[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := VerifyBlock(block, hash, receipts); err != nil {
+		return nil, err
+	}
+	return raws, nil
+}
+
+// blockReceipts fetches the receipts of all transactions in one batch.
+func (ec *Client) blockReceipts(ctx context.Context, block common.Hash, txs []*types.Transaction) ([]json.RawMessage, error) {
+	receipts := make([]json.RawMessage, len(txs))
+	if len(txs) == 0 {
+		return receipts, nil
+	}
+	reqs := make([]rpc.BatchElem, len(txs))
+	for i := range reqs {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{txs[i].Hash()},
+			Result: &receipts[i],
+		}
+	}
+	if err := ec.c.BatchCallContext(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i := range reqs {
+		if reqs[i].Error != nil {
+			return nil, reqs[i].Error
+		}
+		if len(receipts[i]) == 0 || string(receipts[i]) == "null" {
+			return nil, fmt.Errorf("got null receipt for transaction %d of block %x", i, block[:])
+		}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rpc"
+)
+
+func testBlock() (*types.Block, types.Receipts) {
+	var txs types.Transactions
+	var receipts types.Receipts
+	for i := uint64(0); i < 3; i++ {
+		txs = append(txs, types.NewTransaction(i, common.HexToAddress("0x01"), big.NewInt(int64(i)), 21000, big.NewInt(1), []byte{byte(i)}))
+		r := types.NewReceipt(nil, false, 21000*(i+1))
+		r.Logs = []*types.Log{{Address: common.HexToAddress("0x02"), Topics: []common.Hash{common.BigToHash(big.NewInt(int64(i)))}, Data: []byte{1}}}
+		r.Bloom = types.CreateBloom(types.Receipts{r})
+		r.TxHash = txs[i].Hash()
+		receipts = append(receipts, r)
+	}
+	header := &types.Header{
+		Number:      big.NewInt(1),
+		Difficulty:  big.NewInt(1),
+		UncleHash:   types.EmptyUncleHash,
+		TxHash:      types.DeriveSha(txs),
+		ReceiptHash: types.DeriveSha(receipts),
+		Bloom:       types.CreateBloom(receipts),
+	}
+	return types.NewBlockWithHeader(header).WithBody(txs, nil), receipts
+}
+
+func TestDeriveSha(t *testing.T) {
+	block, receipts := testBlock()
+	// reference values computed by go-ethereum
+	if want := common.HexToHash("0xa9b2788098a20cf4ad556517cc1b18c0931f91cdc5a0232dbc2ef0a5f859a8b6"); block.TxHash() != want {
+		t.Errorf("transactions root %x, want %x", block.TxHash(), want)
+	}
+	if want := common.HexToHash("0x6d73f601c29f98393da66af96f571f31f92f29e43bb6968b8b2c80bf4fdd5b53"); block.ReceiptHash() != want {
+		t.Errorf("receipts root %x, want %x", block.ReceiptHash(), want)
+	}
+	if root := types.DeriveSha(types.Transactions{}); root != types.EmptyRootHash {
+		t.Errorf("empty list root %x", root)
+	}
+	if err := VerifyBlock(block, block.Hash(), receipts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyBlockMismatch(t *testing.T) {
+	block, receipts := testBlock()
+	tampered := types.NewTransaction(9, common.HexToAddress("0x01"), big.NewInt(9), 21000, big.NewInt(1), nil)
+	header := block.Header()
+	header.Bloom = types.Bloom{}
+	badBloom := block.WithSeal(header)
+
+	tests := []struct {
+		field    string
+		block    *types.Block
+		hash     common.Hash
+		receipts types.Receipts
+	}{
+		{field: "hash", block: block, hash: common.Hash{1}},
+		{field: "transactionsRoot", block: block.WithBody(types.Transactions{tampered}, nil), hash: block.Hash()},
+		{field: "sha3Uncles", block: block.WithBody(block.Transactions(), []*types.Header{block.Header()}), hash: block.Hash()},
+		{field: "receipts", block: block, hash: block.Hash(), receipts: receipts[:2]},
+		{field: "receiptsRoot", block: block, hash: block.Hash(), receipts: types.Receipts{receipts[0], receipts[1], func() *types.Receipt {
+			r := *receipts[2]
+			r.CumulativeGasUsed++
+			return &r
+		}()}},
+		{field: "logsBloom", block: badBloom, hash: badBloom.Hash(), receipts: receipts},
+	}
+	for _, test := range tests {
+		err := VerifyBlock(test.block, test.hash, test.receipts)
+		ierr, ok := err.(*IntegrityError)
+		if !ok || ierr.Field != test.field {
+			t.Errorf("%s: got error %v", test.field, err)
+		}
+	}
+}
+
+// servedBlockAPI serves the same self-consistent block for every request.
+type servedBlockAPI struct{ block *types.Block }
+
+func (api *servedBlockAPI) fields() (map[string]interface{}, error) {
+	data, err := json.Marshal(api.block.Header())
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["hash"] = api.block.Hash()
+	fields["transactions"] = []interface{}{}
+	fields["uncles"] = []interface{}{}
+	return fields, nil
+}
+
+func (api *servedBlockAPI) GetBlockByHash(hash common.Hash, full bool) (map[string]interface{}, error) {
+	return api.fields()
+}
+
+func (api *servedBlockAPI) GetBlockByNumber(number string, full bool) (map[string]interface{}, error) {
+	return api.fields()
+}
+
+func TestGetBlockRejectsOtherBlock(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(7), Difficulty: big.NewInt(1), UncleHash: types.EmptyUncleHash, TxHash: types.EmptyRootHash, ReceiptHash: types.EmptyRootHash}
+	block := types.NewBlockWithHeader(header)
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &servedBlockAPI{block}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := NewClient(rpc.DialInProc(server))
+	defer client.Close()
+	ctx := context.Background()
+
+	if _, err := client.BlockByHash(ctx, block.Hash()); err != nil {
+		t.Fatalf("requested block rejected: %v", err)
+	}
+	if _, err := client.BlockByNumber(ctx, big.NewInt(7)); err != nil {
+		t.Fatalf("requested block rejected: %v", err)
+	}
+	if _, err := client.BlockByHash(ctx, common.Hash{1}); err == nil || err.(*IntegrityError).Field != "hash" {
+		t.Errorf("expected hash mismatch, got %v", err)
+	}
+	if _, err := client.BlockByNumber(ctx, big.NewInt(8)); err == nil || err.(*IntegrityError).Field != "number" {
+		t.Errorf("expected number mismatch, got %v", err)
+	}
+}
+
+// servedReceiptsAPI serves the receipts of testBlock.
+type servedReceiptsAPI struct{ receipts types.Receipts }
+
+func (api *servedReceiptsAPI) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	for _, r := range api.receipts {
+		if r.TxHash == hash {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestVerifyBlockJSON(t *testing.T) {
+	block, receipts := testBlock()
+	fields, err := (&servedBlockAPI{block}).fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields["transactions"] = block.Transactions()
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := rpc.NewServer()
+	api := &servedReceiptsAPI{receipts}
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	client := NewClient(rpc.DialInProc(server))
+	defer client.Close()
+	ctx := context.Background()
+
+	hash := block.Hash()
+	raws, err := client.VerifyBlockJSON(ctx, raw, &hash, block.Number())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raws) != len(receipts) {
+		t.Fatalf("got %d receipts, want %d", len(raws), len(receipts))
+	}
+	var first types.Receipt
+	if err := json.Unmarshal(raws[0], &first); err != nil || first.TxHash != receipts[0].TxHash {
+		t.Errorf("unexpected first receipt %s: %v", raws[0], err)
+	}
+
+	if _, err := client.VerifyBlockJSON(ctx, raw, nil, big.NewInt(2)); err == nil || err.(*IntegrityError).Field != "number" {
+		t.Errorf("expected number mismatch, got %v", err)
+	}
+	api.receipts = receipts[:2]
+	if _, err := client.VerifyBlockJSON(ctx, raw, &hash, nil); err == nil {
+		t.Error("expected an error for a missing receipt")
+	}
+}
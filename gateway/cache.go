@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// 按字节数限制大小的LRU缓存
+type lruCache struct {
+	maxSize int64
+
+	mu        sync.Mutex
+	size      int64
+	items     map[string]*list.Element
+	order     *list.List // 队首为最近使用
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheEntry struct {
+	key     string
+	value   json.RawMessage
+	expires time.Time // 零值表示不过期
+}
+
+func newLRUCache(maxSize int64) *lruCache {
+	return &lruCache{maxSize: maxSize, items: make(map[string]*list.Element), order: list.New()}
+}
+
+func entrySize(key string, value json.RawMessage) int64 {
+	return int64(len(key) + len(value))
+}
+
+// 查询缓存, 命中时移到队首, 已过期的条目删除后按未命中处理
+func (c *lruCache) get(key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if ok {
+		if expires := elem.Value.(*cacheEntry).expires; !expires.IsZero() && time.Now().After(expires) {
+			c.remove(elem)
+			ok = false
+		}
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+// 写入缓存, ttl为0时不过期, 超出大小时淘汰最久未使用的条目, 大于缓存上限的值不缓存
+func (c *lruCache) add(key string, value json.RawMessage, ttl time.Duration) {
+	size := entrySize(key, value)
+	if size > c.maxSize {
+		return
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		c.size += size - entrySize(entry.key, entry.value)
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(elem)
+	} else {
+		c.items[key] = c.order.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+		c.size += size
+	}
+	for c.size > c.maxSize {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+func (c *lruCache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.order.Remove(elem)
+	delete(c.items, entry.key)
+	c.size -= entrySize(entry.key, entry.value)
+}
@@ -0,0 +1,422 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethclient/common/flogging"
+	"github.com/ethclient/rpc"
+)
+
+var log = flogging.MustGetLogger("sipcclient.gateway")
+
+// 默认缓存大小 64MB
+const DefaultCacheSize = 64 << 20
+
+// 按区块号或交易哈希缓存的结果在链重组后可能变化, 默认只缓存一段时间
+const DefaultReorgTTL = 15 * time.Second
+
+// 合并后的上游请求不受发起者的ctx影响, 使用独立的超时
+const upstreamTimeout = 30 * time.Second
+
+var errNoUpstream = errors.New("no upstream available")
+
+// 写请求及依赖节点本地状态的请求, 只发往主节点
+var primaryMethods = map[string]bool{
+	"eth_sendRawTransaction":          true,
+	"eth_sendTransaction":             true,
+	"eth_sign":                        true,
+	"eth_signTransaction":             true,
+	"eth_signTypedData":               true,
+	"eth_newFilter":                   true,
+	"eth_newBlockFilter":              true,
+	"eth_newPendingTransactionFilter": true,
+	"eth_getFilterChanges":            true,
+	"eth_getFilterLogs":               true,
+	"eth_uninstallFilter":             true,
+	"eth_submitWork":                  true,
+	"eth_submitHashrate":              true,
+	"eth_pendingTransactions":         true,
+}
+
+// 结果由区块哈希确定的方法, 非null结果可以一直缓存
+var immutableMethods = map[string]bool{
+	"eth_getBlockByHash":                    true,
+	"eth_getBlockTransactionCountByHash":    true,
+	"eth_getUncleCountByBlockHash":          true,
+	"eth_getUncleByBlockHashAndIndex":       true,
+	"eth_getTransactionByBlockHashAndIndex": true,
+	"eth_chainId":                           true,
+	"net_version":                           true,
+}
+
+// 按交易哈希查询的方法, 交易所在的区块可能被重组, 结果只缓存reorgTTL
+var reorgableMethods = map[string]bool{
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionReceipt": true,
+}
+
+// 缓存策略
+type cachePolicy int
+
+const (
+	noCache      cachePolicy = iota
+	cacheForever             // 按区块哈希或earliest确定的结果
+	cacheReorg               // 按区块号或交易哈希确定的结果, 链重组后可能变化
+)
+
+// 带区块参数的方法及区块参数的位置, 指定了确定区块时结果可以缓存
+var blockParamIndex = map[string]int{
+	"eth_getBalance":          1,
+	"eth_getCode":             1,
+	"eth_getTransactionCount": 1,
+	"eth_call":                1,
+	"eth_estimateGas":         1,
+	"eth_getStorageAt":        2,
+	"eth_getProof":            2,
+}
+
+// 其余常用的只读方法
+var readMethods = []string{
+	"eth_blockNumber",
+	"eth_getBlockByNumber",
+	"eth_getBlockTransactionCountByNumber",
+	"eth_getUncleCountByBlockNumber",
+	"eth_getUncleByBlockNumberAndIndex",
+	"eth_getTransactionByBlockNumberAndIndex",
+	"eth_getLogs",
+	"eth_gasPrice",
+	"eth_maxPriorityFeePerGas",
+	"eth_feeHistory",
+	"eth_syncing",
+	"eth_mining",
+	"eth_hashrate",
+	"eth_coinbase",
+	"eth_accounts",
+	"eth_protocolVersion",
+	"net_listening",
+	"net_peerCount",
+	"web3_clientVersion",
+	"web3_sha3",
+}
+
+// 网关已知的方法, 服务端监控按方法名统计, 其余方法统一记为unknown
+func knownMethods() []string {
+	var methods []string
+	for _, set := range []map[string]bool{primaryMethods, immutableMethods, reorgableMethods} {
+		for method := range set {
+			methods = append(methods, method)
+		}
+	}
+	for method := range blockParamIndex {
+		methods = append(methods, method)
+	}
+	return append(methods, readMethods...)
+}
+
+// 缓存及转发统计
+type Stats struct {
+	Hits         uint64 `json:"hits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	Entries      int    `json:"entries"`
+	Size         int64  `json:"size"`
+	MaxSize      int64  `json:"maxSize"`
+	Deduplicated uint64 `json:"deduplicated"`
+	Forwarded    uint64 `json:"forwarded"`
+	Failovers    uint64 `json:"failovers"`
+}
+
+// 缓存JSON-RPC网关
+// 读请求轮询发往上游节点池, 写请求发往主节点, 按哈希确定的结果缓存在LRU中,
+// 按区块号确定的结果只缓存reorgTTL,
+// 并发的相同读请求只转发一次
+type Gateway struct {
+	primary   *rpc.Client
+	upstreams []*rpc.Client
+	next      uint32
+	cache     *lruCache
+	reorgTTL  time.Duration
+
+	mu       sync.Mutex
+	inflight map[string]*flight
+
+	deduplicated uint64
+	forwarded    uint64
+	failovers    uint64
+}
+
+// 进行中的请求
+type flight struct {
+	done   chan struct{}
+	result json.RawMessage
+	err    error
+}
+
+// 创建网关, replicas为空时读请求也发往主节点, cacheSize为缓存字节数, 0时使用默认大小
+func New(primary *rpc.Client, replicas []*rpc.Client, cacheSize int64) *Gateway {
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	upstreams := replicas
+	if len(upstreams) == 0 {
+		upstreams = []*rpc.Client{primary}
+	}
+	return &Gateway{
+		primary:   primary,
+		upstreams: upstreams,
+		cache:     newLRUCache(cacheSize),
+		reorgTTL:  DefaultReorgTTL,
+		inflight:  make(map[string]*flight),
+	}
+}
+
+// 设置按区块号或交易哈希缓存的结果的有效期, 0表示不缓存这类结果, 需在开始服务前调用
+func (g *Gateway) SetReorgTTL(ttl time.Duration) {
+	g.reorgTTL = ttl
+}
+
+// 创建对外服务的rpc.Server, 未注册的方法都转发到上游, gateway_stats返回统计
+func (g *Gateway) Server() *rpc.Server {
+	srv := rpc.NewServer()
+	srv.SetFallback(g.Handle)
+	srv.SetFallbackMethods(knownMethods())
+	if err := srv.RegisterName("gateway", &API{g}); err != nil {
+		panic(err)
+	}
+	return srv
+}
+
+// 处理一次调用, 可作为rpc.FallbackFunc
+func (g *Gateway) Handle(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	args, err := splitParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if isPrimaryCall(method, args) {
+		atomic.AddUint64(&g.forwarded, 1)
+		return call(ctx, g.primary, method, args)
+	}
+	key := cacheKey(method, params)
+	policy := cachePolicyOf(method, args)
+	if policy == cacheReorg && g.reorgTTL <= 0 {
+		policy = noCache
+	}
+	if policy != noCache {
+		if result, ok := g.cache.get(key); ok {
+			return result, nil
+		}
+	}
+	result, err := g.dedup(ctx, key, func(ctx context.Context) (json.RawMessage, error) {
+		return g.read(ctx, method, args)
+	})
+	if err == nil && policy != noCache && cacheableResult(method, result) {
+		var ttl time.Duration
+		if policy == cacheReorg {
+			ttl = g.reorgTTL
+		}
+		g.cache.add(key, result, ttl)
+	}
+	return result, err
+}
+
+// 相同的并发请求只执行一次, 所有请求等待并共享结果.
+// 上游请求在独立的ctx上执行, 发起者取消不会使其他等待者失败
+func (g *Gateway) dedup(ctx context.Context, key string, fn func(context.Context) (json.RawMessage, error)) (json.RawMessage, error) {
+	g.mu.Lock()
+	f, ok := g.inflight[key]
+	if ok {
+		atomic.AddUint64(&g.deduplicated, 1)
+	} else {
+		f = &flight{done: make(chan struct{})}
+		g.inflight[key] = f
+		go g.run(key, f, fn)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// 执行共享的上游请求
+func (g *Gateway) run(key string, f *flight, fn func(context.Context) (json.RawMessage, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+	f.result, f.err = fn(ctx)
+	g.mu.Lock()
+	delete(g.inflight, key)
+	g.mu.Unlock()
+	close(f.done)
+}
+
+// 轮询上游节点, 连接错误时换下一个节点重试, 节点返回的rpc错误直接返回
+func (g *Gateway) read(ctx context.Context, method string, args []json.RawMessage) (json.RawMessage, error) {
+	start := atomic.AddUint32(&g.next, 1)
+	err := errNoUpstream
+	for i := 0; i < len(g.upstreams); i++ {
+		if i > 0 {
+			atomic.AddUint64(&g.failovers, 1)
+		}
+		upstream := g.upstreams[(int(start)+i)%len(g.upstreams)]
+		atomic.AddUint64(&g.forwarded, 1)
+		var result json.RawMessage
+		result, err = call(ctx, upstream, method, args)
+		if _, ok := err.(rpc.Error); ok || err == nil || ctx.Err() != nil {
+			return result, err
+		}
+		log.Warnw("upstream call failed", "method", method, "err", err)
+	}
+	return nil, err
+}
+
+// 返回统计信息
+func (g *Gateway) Stats() Stats {
+	g.cache.mu.Lock()
+	stats := Stats{
+		Hits:      g.cache.hits,
+		Misses:    g.cache.misses,
+		Evictions: g.cache.evictions,
+		Entries:   len(g.cache.items),
+		Size:      g.cache.size,
+		MaxSize:   g.cache.maxSize,
+	}
+	g.cache.mu.Unlock()
+	stats.Deduplicated = atomic.LoadUint64(&g.deduplicated)
+	stats.Forwarded = atomic.LoadUint64(&g.forwarded)
+	stats.Failovers = atomic.LoadUint64(&g.failovers)
+	return stats
+}
+
+// gateway命名空间的rpc服务
+type API struct {
+	g *Gateway
+}
+
+// 返回缓存统计
+func (api *API) Stats() Stats {
+	return api.g.Stats()
+}
+
+func call(ctx context.Context, c *rpc.Client, method string, args []json.RawMessage) (json.RawMessage, error) {
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		params[i] = arg
+	}
+	var result json.RawMessage
+	err := c.CallContext(ctx, &result, method, params...)
+	return result, err
+}
+
+// 参数不是数组时返回的错误
+type invalidParamsError struct{ message string }
+
+func (e *invalidParamsError) Error() string  { return e.message }
+func (e *invalidParamsError) ErrorCode() int { return -32602 }
+
+// 拆分位置参数
+func splitParams(params json.RawMessage) ([]json.RawMessage, error) {
+	params = json.RawMessage(strings.TrimSpace(string(params)))
+	if len(params) == 0 || string(params) == "null" {
+		return nil, nil
+	}
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &invalidParamsError{"non-array params are not supported"}
+	}
+	return args, nil
+}
+
+func cacheKey(method string, params json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, params); err != nil {
+		return method + " " + string(params)
+	}
+	return method + " " + buf.String()
+}
+
+// 是否必须发往主节点
+func isPrimaryCall(method string, args []json.RawMessage) bool {
+	if primaryMethods[method] || strings.HasPrefix(method, "personal_") {
+		return true
+	}
+	// pending状态只有主节点的交易池中有
+	if i, ok := blockParamIndex[method]; ok && i < len(args) {
+		var tag string
+		return json.Unmarshal(args[i], &tag) == nil && tag == "pending"
+	}
+	return false
+}
+
+// 按方法和参数判断结果的缓存策略
+func cachePolicyOf(method string, args []json.RawMessage) cachePolicy {
+	if immutableMethods[method] {
+		return cacheForever
+	}
+	if reorgableMethods[method] {
+		return cacheReorg
+	}
+	if i, ok := blockParamIndex[method]; ok && i < len(args) {
+		return blockPolicy(args[i])
+	}
+	return noCache
+}
+
+// 按结果判断是否可以缓存, null表示还不存在, 未打包的交易之后会变化
+func cacheableResult(method string, result json.RawMessage) bool {
+	if len(result) == 0 || string(result) == "null" {
+		return false
+	}
+	if method == "eth_getTransactionByHash" {
+		var tx struct {
+			BlockHash *string `json:"blockHash"`
+		}
+		return json.Unmarshal(result, &tx) == nil && tx.BlockHash != nil
+	}
+	return true
+}
+
+// 区块参数的缓存策略: 区块哈希和earliest一直缓存, 区块号按reorgTTL缓存, latest等标签不缓存
+func blockPolicy(arg json.RawMessage) cachePolicy {
+	var tag string
+	if err := json.Unmarshal(arg, &tag); err == nil {
+		return tagPolicy(tag)
+	}
+	var obj struct {
+		BlockHash   *string `json:"blockHash"`
+		BlockNumber *string `json:"blockNumber"`
+	}
+	if err := json.Unmarshal(arg, &obj); err != nil {
+		return noCache
+	}
+	if obj.BlockHash != nil {
+		return cacheForever
+	}
+	if obj.BlockNumber != nil {
+		return tagPolicy(*obj.BlockNumber)
+	}
+	return noCache
+}
+
+func tagPolicy(tag string) cachePolicy {
+	switch {
+	case tag == "earliest":
+		return cacheForever
+	case !strings.HasPrefix(tag, "0x"):
+		return noCache
+	case len(tag) == 66:
+		// 与节点一致, 32字节的十六进制串按区块哈希处理
+		return cacheForever
+	default:
+		return cacheReorg
+	}
+}
@@ -0,0 +1,248 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethclient/common/flogging/metrics/prometheus"
+	"github.com/ethclient/rpc"
+)
+
+// 模拟上游节点, 记录各方法被调用的次数
+type upstreamService struct {
+	calls   sync.Map
+	release chan struct{}
+}
+
+func (s *upstreamService) count(method string) int64 {
+	v, _ := s.calls.LoadOrStore(method, new(int64))
+	return atomic.LoadInt64(v.(*int64))
+}
+
+func (s *upstreamService) inc(method string) {
+	v, _ := s.calls.LoadOrStore(method, new(int64))
+	atomic.AddInt64(v.(*int64), 1)
+}
+
+func (s *upstreamService) GetBlockByHash(hash string, full bool) map[string]interface{} {
+	s.inc("getBlockByHash")
+	if hash == "0x00" {
+		return nil
+	}
+	return map[string]interface{}{"hash": hash, "number": "0x1"}
+}
+
+func (s *upstreamService) GetCode(addr string, block json.RawMessage) string {
+	s.inc("getCode")
+	return "0x6000"
+}
+
+func (s *upstreamService) BlockNumber() string {
+	s.inc("blockNumber")
+	if s.release != nil {
+		<-s.release
+	}
+	return "0x10"
+}
+
+func (s *upstreamService) SendRawTransaction(data string) string {
+	s.inc("sendRawTransaction")
+	return "0xabcd"
+}
+
+func newUpstream(t *testing.T) (*upstreamService, *rpc.Server) {
+	service := new(upstreamService)
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	return service, srv
+}
+
+func TestGateway(t *testing.T) {
+	primary, primarySrv := newUpstream(t)
+	defer primarySrv.Stop()
+	replica, replicaSrv := newUpstream(t)
+	defer replicaSrv.Stop()
+	gw := New(rpc.DialInProc(primarySrv), []*rpc.Client{rpc.DialInProc(replicaSrv)}, 0)
+	client := rpc.DialInProc(gw.Server())
+	defer client.Close()
+
+	// 按哈希查询区块只转发一次, null结果不缓存
+	var block map[string]interface{}
+	for i := 0; i < 3; i++ {
+		if err := client.Call(&block, "eth_getBlockByHash", "0x01", false); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Call(&block, "eth_getBlockByHash", "0x00", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := replica.count("getBlockByHash"); n != 4 {
+		t.Errorf("getBlockByHash forwarded %d times, want 4", n)
+	}
+
+	// 确定区块的结果缓存, latest不缓存
+	var code string
+	for i := 0; i < 2; i++ {
+		client.Call(&code, "eth_getCode", "0x01", "0x5")
+		client.Call(&code, "eth_getCode", "0x01", "latest")
+	}
+	if n := replica.count("getCode"); n != 3 {
+		t.Errorf("getCode forwarded %d times, want 3", n)
+	}
+
+	// 写请求发往主节点
+	var hash string
+	if err := client.Call(&hash, "eth_sendRawTransaction", "0x01"); err != nil {
+		t.Fatal(err)
+	}
+	if primary.count("sendRawTransaction") != 1 || replica.count("sendRawTransaction") != 0 {
+		t.Error("write was not routed to the primary")
+	}
+
+	stats := gw.Stats()
+	if stats.Hits != 3 || stats.Entries != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	var apiStats Stats
+	if err := client.Call(&apiStats, "gateway_stats"); err != nil {
+		t.Fatal(err)
+	}
+	if apiStats.Hits != stats.Hits {
+		t.Errorf("gateway_stats hits %d, want %d", apiStats.Hits, stats.Hits)
+	}
+}
+
+func TestGatewayMetrics(t *testing.T) {
+	_, primarySrv := newUpstream(t)
+	defer primarySrv.Stop()
+	gw := New(rpc.DialInProc(primarySrv), nil, 0)
+	provider := prometheus.NewProvider()
+	srv := gw.Server()
+	srv.SetMetricsProvider(provider)
+	client := rpc.DialInProc(srv)
+	defer client.Close()
+
+	// 已知方法按方法名统计, 其余方法记为unknown
+	var result string
+	client.Call(&result, "eth_blockNumber")
+	client.Call(&result, "eth_getCode", "0x01", "latest")
+	client.Call(&result, "eth_someRandomMethod")
+
+	var buf bytes.Buffer
+	provider.WriteTo(&buf)
+	text := buf.String()
+	for _, line := range []string{
+		`rpc_server_requests_total{method="eth_blockNumber"} 1`,
+		`rpc_server_requests_total{method="eth_getCode"} 1`,
+		`rpc_server_requests_total{method="unknown"} 1`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, text)
+		}
+	}
+}
+
+func TestGatewayReorgTTL(t *testing.T) {
+	_, primarySrv := newUpstream(t)
+	defer primarySrv.Stop()
+	replica, replicaSrv := newUpstream(t)
+	defer replicaSrv.Stop()
+	gw := New(rpc.DialInProc(primarySrv), []*rpc.Client{rpc.DialInProc(replicaSrv)}, 0)
+	gw.SetReorgTTL(20 * time.Millisecond)
+	client := rpc.DialInProc(gw.Server())
+	defer client.Close()
+
+	hash := "0x" + strings.Repeat("ab", 32)
+	var code string
+	callAll := func() {
+		client.Call(&code, "eth_getCode", "0x01", "0x5")
+		client.Call(&code, "eth_getCode", "0x01", hash)
+		client.Call(&code, "eth_getCode", "0x01", map[string]string{"blockHash": hash})
+	}
+	callAll()
+	callAll()
+	if n := replica.count("getCode"); n != 3 {
+		t.Errorf("getCode forwarded %d times, want 3", n)
+	}
+	// 过期后只有按区块号的结果重新查询
+	time.Sleep(30 * time.Millisecond)
+	callAll()
+	if n := replica.count("getCode"); n != 4 {
+		t.Errorf("getCode forwarded %d times after expiry, want 4", n)
+	}
+
+	gw.SetReorgTTL(0)
+	client.Call(&code, "eth_getCode", "0x01", "0x6")
+	client.Call(&code, "eth_getCode", "0x01", "0x6")
+	if n := replica.count("getCode"); n != 6 {
+		t.Errorf("getCode forwarded %d times without reorg ttl, want 6", n)
+	}
+}
+
+func TestGatewayDedup(t *testing.T) {
+	_, primarySrv := newUpstream(t)
+	defer primarySrv.Stop()
+	replica, replicaSrv := newUpstream(t)
+	defer replicaSrv.Stop()
+	replica.release = make(chan struct{})
+	gw := New(rpc.DialInProc(primarySrv), []*rpc.Client{rpc.DialInProc(replicaSrv)}, 0)
+
+	// 第一个请求取消后, 其余请求仍然得到结果
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := gw.Handle(ctx, "eth_blockNumber", nil)
+		first <- err
+	}()
+	for replica.count("blockNumber") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := gw.Handle(context.Background(), "eth_blockNumber", nil)
+			if err != nil || string(result) != `"0x10"` {
+				t.Errorf("got %s, %v", result, err)
+			}
+		}()
+	}
+	for gw.Stats().Deduplicated < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-first; err != context.Canceled {
+		t.Errorf("first caller got %v, want %v", err, context.Canceled)
+	}
+	close(replica.release)
+	wg.Wait()
+	if n := replica.count("blockNumber"); n != 1 {
+		t.Errorf("blockNumber forwarded %d times, want 1", n)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	c := newLRUCache(30)
+	c.add("a", json.RawMessage("0123456789"), 0)
+	c.add("b", json.RawMessage("0123456789"), 0)
+	c.get("a")
+	c.add("c", json.RawMessage("0123456789"), 0)
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Error("recently used entry was evicted")
+	}
+	if c.evictions != 1 || c.size != 22 {
+		t.Errorf("evictions %d size %d, want 1 and 22", c.evictions, c.size)
+	}
+}
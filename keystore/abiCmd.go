@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethclient/abi"
+	Client "github.com/ethclient/client"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/models"
+	"github.com/ethclient/sigdb"
+)
+
+var abiCommand = &command{
+	Name:  "abi",
+	Usage: "manage the local abi registry and decode call data",
+	Commands: []*command{
+		{Name: "register", Usage: "register the abi of a contract address", Action: abiRegister},
+		{Name: "list", Usage: "list registered contract addresses", Action: abiList},
+		{Name: "remove", Usage: "remove the abi of a contract address", Action: abiRemove},
+		{Name: "decode", Usage: "decode transaction input by -address, -abi or known signatures", Action: abiDecode},
+		{Name: "lookup", Usage: "look up known signatures of a selector or event topic", Action: abiLookup},
+		{Name: "encode", Usage: "encode call data from -args json or positional arguments", Action: abiEncode},
+	},
+}
+
+func abiRegister(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("abi register")
+	address := fs.String("address", "", "contract address")
+	file := fs.String("abi", "", "abi json or human-readable abi file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	data, err := readAbiFile(*file)
+	if err != nil {
+		return err
+	}
+	registry, err := Client.LoadAbiRegistry(ctx.Config.AbiRegistry)
+	if err != nil {
+		return err
+	}
+	return registry.Register(*address, data)
+}
+
+func abiList(ctx *cmdContext, args []string) error {
+	if err := newFlagSet("abi list").Parse(args); err != nil {
+		return err
+	}
+	registry, err := Client.LoadAbiRegistry(ctx.Config.AbiRegistry)
+	if err != nil {
+		return err
+	}
+	return ctx.print(registry.Addresses())
+}
+
+func abiRemove(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("abi remove")
+	address := fs.String("address", "", "contract address")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	registry, err := Client.LoadAbiRegistry(ctx.Config.AbiRegistry)
+	if err != nil {
+		return err
+	}
+	return registry.Unregister(*address)
+}
+
+// -abi 优先, 其次使用注册表中 -address 的ABI, 都没有时按签名库解码并输出所有候选
+func abiDecode(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("abi decode")
+	input := fs.String("input", "", "hex encoded transaction input")
+	address := fs.String("address", "", "registered contract address")
+	file := fs.String("abi", "", "abi json or human-readable abi file")
+	human := fs.Bool("human", false, "print the call as name(type name=value, ...)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	data, err := hexutil.Decode(*input)
+	if err != nil {
+		return fmt.Errorf("invalid -input: %v", err)
+	}
+	if *file == "" && *address == "" {
+		db, err := loadSignatures(ctx)
+		if err != nil {
+			return err
+		}
+		calls, err := db.DecodeCall(data)
+		if err != nil {
+			return err
+		}
+		if *human {
+			for _, call := range calls {
+				fmt.Println(call)
+			}
+			return nil
+		}
+		out := make([]*models.DecodedCall, len(calls))
+		for i, call := range calls {
+			out[i] = Client.ToDecodedCall(call)
+		}
+		return ctx.print(out)
+	}
+	var contractAbi *abi.ABI
+	if *file != "" {
+		abiData, err := readAbiFile(*file)
+		if err != nil {
+			return err
+		}
+		parsed, err := abi.JSON(bytes.NewReader(abiData))
+		if err != nil {
+			return err
+		}
+		contractAbi = &parsed
+	} else {
+		registry, err := Client.LoadAbiRegistry(ctx.Config.AbiRegistry)
+		if err != nil {
+			return err
+		}
+		parsed, ok := registry.Lookup(*address)
+		if !ok {
+			return fmt.Errorf("no abi registered for %q", *address)
+		}
+		contractAbi = parsed
+	}
+	call, err := abi.DecodeCalldata(contractAbi, data)
+	if err != nil {
+		return err
+	}
+	if *human {
+		fmt.Println(call)
+		return nil
+	}
+	return ctx.print(Client.ToDecodedCall(call))
+}
+
+// -args 为json数组或按参数名的json对象, 否则使用位置参数
+func abiEncode(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("abi encode")
+	file := fs.String("abi", "", "abi json or human-readable abi file")
+	name := fs.String("method", "", "method name")
+	jsonArgs := fs.String("args", "", "arguments as json array or object")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	abiData, err := readAbiFile(*file)
+	if err != nil {
+		return err
+	}
+	contractAbi, err := abi.JSON(bytes.NewReader(abiData))
+	if err != nil {
+		return err
+	}
+	method, ok := contractAbi.Methods[*name]
+	if !ok {
+		return fmt.Errorf("method %q not found in abi", *name)
+	}
+	var data []byte
+	if *jsonArgs != "" {
+		data, err = abi.PackFromJSON(method, json.RawMessage(*jsonArgs))
+	} else {
+		var values []interface{}
+		if values, err = abi.ParseArgs(method.Inputs, fs.Args()); err == nil {
+			data, err = contractAbi.Pack(*name, values...)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(hexutil.Encode(data))
+	return nil
+}
+
+func abiLookup(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("abi lookup")
+	id := fs.String("id", "", "4-byte function selector or 32-byte event topic")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	data, err := hexutil.Decode(*id)
+	if err != nil {
+		return fmt.Errorf("invalid -id: %v", err)
+	}
+	db, err := loadSignatures(ctx)
+	if err != nil {
+		return err
+	}
+	var sigs []*sigdb.Signature
+	switch len(data) {
+	case 4:
+		sigs = db.Lookup(data)
+	case common.HashLength:
+		sigs = db.LookupEvent(common.BytesToHash(data))
+	default:
+		return fmt.Errorf("-id must be 4 or 32 bytes")
+	}
+	out := make([]string, len(sigs))
+	for i, sig := range sigs {
+		out[i] = sig.String()
+	}
+	return ctx.print(out)
+}
+
+// 读取ABI文件, 不是json时按每行一个可读签名解析, 返回json格式的ABI
+func readAbiFile(file string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		return trimmed, nil
+	}
+	return abi.HumanReadableJSON(strings.Split(string(data), "\n"))
+}
+
+// 内置签名库加上配置的签名文件
+func loadSignatures(ctx *cmdContext) (*sigdb.DB, error) {
+	db := sigdb.Default()
+	for _, file := range ctx.Config.SignatureFiles {
+		if _, err := db.AddFile(file); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
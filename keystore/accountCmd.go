@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
+	key2 "github.com/ethclient/keystore/key"
+)
+
+var accountCommand = &command{
+	Name:  "account",
+	Usage: "manage keystore accounts",
+	Commands: []*command{
+		{Name: "new", Usage: "create a new account", Action: accountNew},
+		{Name: "list", Usage: "list accounts in the keystore dir", Action: accountList},
+		{Name: "import", Usage: "import a hex private key", Action: accountImport},
+		{Name: "export", Usage: "export the private key of a key file", Action: accountExport},
+		{Name: "password", Usage: "change the password of a key file", Action: accountPassword},
+		{Name: "proof", Usage: "fetch and verify the state proof of an account", Action: accountProof},
+	},
+}
+
+type accountInfo struct {
+	Address string `json:"address"`
+	File    string `json:"file"`
+}
+
+// 读取密码文件
+func readPasswd(file string) (string, error) {
+	if file == "" {
+		return "", fmt.Errorf("password file is required")
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// 加密并写入账户目录
+func storeKey(dir string, key *key2.Key, passwd string, light bool) (*accountInfo, error) {
+	scryptN, scryptP := key2.StandardScryptN, key2.StandardScryptP
+	if light {
+		scryptN, scryptP = key2.LightScryptN, key2.LightScryptP
+	}
+	keyjson, err := key2.EncryptKey(key, passwd, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	file := filepath.Join(dir, key2.KeyFileName(key.Address))
+	if err := ioutil.WriteFile(file, keyjson, 0600); err != nil {
+		return nil, err
+	}
+	return &accountInfo{Address: key.Address.Hex(), File: file}, nil
+}
+
+func accountNew(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("account new")
+	passwdFile := fs.String("passwd", ctx.Config.PasswdFile, "password file")
+	light := fs.Bool("light", false, "use light scrypt parameters")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	passwd, err := readPasswd(*passwdFile)
+	if err != nil {
+		return err
+	}
+	key, err := key2.NewKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	info, err := storeKey(ctx.Config.KeystoreDir, key, passwd, *light)
+	if err != nil {
+		return err
+	}
+	return ctx.print(info)
+}
+
+func accountList(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("account list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(ctx.Config.KeystoreDir)
+	if err != nil {
+		return err
+	}
+	accounts := make([]accountInfo, 0)
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(ctx.Config.KeystoreDir, f.Name())
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var keyJSON struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(data, &keyJSON); err != nil || !common.IsHexAddress(keyJSON.Address) {
+			log.Debugf("skip non key file %s", file)
+			continue
+		}
+		accounts = append(accounts, accountInfo{Address: common.HexToAddress(keyJSON.Address).Hex(), File: file})
+	}
+	return ctx.print(accounts)
+}
+
+func accountImport(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("account import")
+	passwdFile := fs.String("passwd", ctx.Config.PasswdFile, "password file for the new key file")
+	privateKey := fs.String("key", "", "hex private key")
+	privateKeyFile := fs.String("keyfile", "", "file containing the hex private key")
+	light := fs.Bool("light", false, "use light scrypt parameters")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	hexKey := *privateKey
+	if *privateKeyFile != "" {
+		data, err := ioutil.ReadFile(*privateKeyFile)
+		if err != nil {
+			return err
+		}
+		hexKey = strings.TrimSpace(string(data))
+	}
+	if hexKey == "" {
+		return fmt.Errorf("one of -key or -keyfile is required")
+	}
+	ecdsaKey, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return err
+	}
+	passwd, err := readPasswd(*passwdFile)
+	if err != nil {
+		return err
+	}
+	info, err := storeKey(ctx.Config.KeystoreDir, key2.NewKeyFromECDSA(ecdsaKey), passwd, *light)
+	if err != nil {
+		return err
+	}
+	return ctx.print(info)
+}
+
+func accountExport(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("account export")
+	keyFile := fs.String("file", ctx.Config.KeyFile, "key file")
+	passwdFile := fs.String("passwd", ctx.Config.PasswdFile, "password file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := key2.GetKey(*keyFile, *passwdFile)
+	if err != nil {
+		return err
+	}
+	return ctx.print(map[string]string{
+		"address":    key.Address.Hex(),
+		"privateKey": hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)),
+	})
+}
+
+func accountPassword(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("account password")
+	keyFile := fs.String("file", ctx.Config.KeyFile, "key file")
+	passwdFile := fs.String("passwd", ctx.Config.PasswdFile, "current password file")
+	newPasswdFile := fs.String("newpasswd", "", "new password file")
+	light := fs.Bool("light", false, "use light scrypt parameters")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	key, err := key2.GetKey(*keyFile, *passwdFile)
+	if err != nil {
+		return err
+	}
+	passwd, err := readPasswd(*newPasswdFile)
+	if err != nil {
+		return err
+	}
+	scryptN, scryptP := key2.StandardScryptN, key2.StandardScryptP
+	if *light {
+		scryptN, scryptP = key2.LightScryptN, key2.LightScryptP
+	}
+	keyjson, err := key2.EncryptKey(key, passwd, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	// 先写临时文件再替换, 避免写入中断损坏原文件
+	tmp := *keyFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, keyjson, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, *keyFile); err != nil {
+		return err
+	}
+	return ctx.print(accountInfo{Address: key.Address.Hex(), File: *keyFile})
+}
+
+func accountProof(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("account proof")
+	address := fs.String("address", "", "account address")
+	keys := fs.String("keys", "", "comma separated storage slots")
+	block := fs.String("block", "latest", "block number or latest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	number, err := parseBlockFlag(*block)
+	if err != nil {
+		return err
+	}
+	var storageKeys []string
+	if *keys != "" {
+		storageKeys = strings.Split(*keys, ",")
+	}
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	result, header, err := c.GetVerifiedProof(*address, storageKeys, number)
+	if err != nil {
+		return err
+	}
+	return ctx.print(map[string]interface{}{
+		"block":     header.Number.String(),
+		"stateRoot": header.Root.Hex(),
+		"verified":  true,
+		"proof":     result,
+	})
+}
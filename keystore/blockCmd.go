@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	Client "github.com/ethclient/client"
+)
+
+var blockCommand = &command{
+	Name:  "block",
+	Usage: "query blocks",
+	Commands: []*command{
+		{Name: "get", Usage: "get a block by number, hash or latest", Action: blockGet},
+	},
+}
+
+func blockGet(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("block get")
+	id := fs.String("id", "latest", "block number, block hash or latest")
+	mixed := fs.Bool("receipts", false, "include receipt status and fee of each transaction")
+	verify := fs.Bool("verify", false, "verify block hash, transactions root, receipts root and logs bloom")
+	decode := fs.Bool("decode", false, "decode calls by the abi registry or known signatures, implies -receipts")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	c.SetBlockVerification(*verify)
+	input := *id
+	if input == "latest" {
+		number, err := c.BlockNumber()
+		if err != nil {
+			return err
+		}
+		input = fmt.Sprint(number)
+	}
+	if *decode {
+		registry, err := Client.LoadAbiRegistry(ctx.Config.AbiRegistry)
+		if err != nil {
+			return err
+		}
+		db, err := loadSignatures(ctx)
+		if err != nil {
+			return err
+		}
+		registry.SetFallback(db)
+		c.SetAbiRegistry(registry)
+	}
+	if *mixed || *decode {
+		block, err := c.GetMixedBlockByBlockNumOrHash(input)
+		if err != nil {
+			return err
+		}
+		return ctx.print(block)
+	}
+	// 开启 -verify 时 GetBlockByBlockNumOrHash 校验的就是返回的这份块数据
+	block, err := c.GetBlockByBlockNumOrHash(input)
+	if err != nil {
+		return err
+	}
+	return ctx.print(block)
+}
+
+var rpcCommand = &command{
+	Name:  "rpc",
+	Usage: "raw json-rpc access",
+	Commands: []*command{
+		{Name: "raw", Usage: "call <method> [json params...]", Action: rpcRaw},
+	},
+}
+
+// 参数是合法json时按json传递, 否则按字符串传递
+func rpcRaw(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("rpc raw")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: rpc raw <method> [params...]")
+	}
+	params := make([]interface{}, 0, fs.NArg()-1)
+	for _, arg := range fs.Args()[1:] {
+		if json.Valid([]byte(arg)) {
+			params = append(params, json.RawMessage(arg))
+		} else {
+			params = append(params, arg)
+		}
+	}
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	var result json.RawMessage
+	if err := c.ClientPara.RpcClient.CallContext(*c.Ctx, &result, fs.Arg(0), params...); err != nil {
+		return err
+	}
+	var value interface{}
+	if err := json.Unmarshal(result, &value); err != nil {
+		return err
+	}
+	return ctx.print(value)
+}
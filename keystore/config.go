@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	Client "github.com/ethclient/client"
+	"github.com/ethclient/ethclient"
+	"github.com/ethclient/models"
+	"github.com/ethclient/rpc"
+)
+
+// 配置文件
+type Config struct {
+	Node        string `json:"node"`        // 节点rpc地址 ip:port, ws:// ipc:// 地址或ipc文件路径
+	KeyFile     string `json:"keyFile"`     // 交易签名私钥文件
+	PasswdFile  string `json:"passwdFile"`  // 私钥密码文件
+	KeystoreDir string `json:"keystoreDir"` // 账户目录
+	Solc        string `json:"solc"`        // solc路径, 为空时使用PATH中的solc
+	Output      string `json:"output"`      // 输出格式 json table
+	AbiRegistry string `json:"abiRegistry"` // ABI注册表目录
+	SolcCache   string `json:"solcCache"`   // standard-json编译产物缓存目录, 为空时不缓存
+	// 额外的函数/事件签名文件, ABI json, 编译产物或每行一个签名的文本
+	SignatureFiles []string `json:"signatureFiles"`
+}
+
+func defaultConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".sipcclient", "config.json")
+}
+
+// 读取配置文件, 文件不存在时使用默认配置
+func LoadConfig(file string) (*Config, error) {
+	cfg := &Config{
+		Node:        "127.0.0.1:8545",
+		KeystoreDir: "keystore",
+		AbiRegistry: "abis",
+		Output:      outputJSON,
+	}
+	if file == "" {
+		return cfg, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("config %s: %v", file, err)
+	}
+	return cfg, nil
+}
+
+// 连接节点, needKey为true时加载签名私钥
+func (ctx *cmdContext) dial(needKey bool) (*Client.EthClient, error) {
+	if needKey {
+		if ctx.Config.KeyFile == "" || ctx.Config.PasswdFile == "" {
+			return nil, fmt.Errorf("keyFile and passwdFile must be set in config")
+		}
+		return Client.NewClient(ctx.Config.Node, &models.SignTxPara{
+			SignPrikeyFile: ctx.Config.KeyFile,
+			PasswdFile:     ctx.Config.PasswdFile,
+		})
+	}
+	cli, err := rpc.Dial(Client.Endpoint(ctx.Config.Node), "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	c, cancel := context.WithCancel(context.Background())
+	return &Client.EthClient{
+		Address: ctx.Config.Node,
+		ClientPara: &models.ClientPara{
+			RpcClient: cli,
+			Client:    ethclient.NewClient(cli),
+		},
+		Ctx:    &c,
+		Cancel: &cancel,
+	}, nil
+}
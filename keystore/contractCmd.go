@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethclient/abi"
+	Client "github.com/ethclient/client"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/compiler"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/models"
+)
+
+var contractCommand = &command{
+	Name:  "contract",
+	Usage: "compile, deploy and interact with contracts",
+	Commands: []*command{
+		{Name: "compile", Usage: "compile a solidity source file", Action: contractCompile},
+		{Name: "deploy", Usage: "compile and deploy a solidity source file", Action: contractDeploy},
+		{Name: "call", Usage: "call a contract method (eth_call)", Action: contractCall},
+		{Name: "send", Usage: "send a transaction to a contract method", Action: contractSend},
+	},
+}
+
+type compiledContract struct {
+	Name          string          `json:"name"`
+	Abi           json.RawMessage `json:"abi"`
+	Code          string          `json:"code"`
+	RuntimeCode   string          `json:"runtimeCode,omitempty"`
+	StorageLayout interface{}     `json:"storageLayout,omitempty"`
+	DevDoc        interface{}     `json:"devdoc,omitempty"`
+	UserDoc       interface{}     `json:"userdoc,omitempty"`
+	Metadata      string          `json:"metadata,omitempty"`
+}
+
+// 可重复的字符串参数
+type stringsFlag []string
+
+func (s *stringsFlag) String() string     { return strings.Join(*s, ",") }
+func (s *stringsFlag) Set(v string) error { *s = append(*s, v); return nil }
+
+// 指定 -standard 或多个源文件时使用 solc --standard-json 编译, 支持import、remapping和产物缓存
+func contractCompile(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("contract compile")
+	file := fs.String("file", "", "solidity source file, more files may follow the flags")
+	standard := fs.Bool("standard", false, "compile with solc --standard-json")
+	base := fs.String("base", "", "base path of source unit names, default the working directory")
+	optimize := fs.Bool("optimize", true, "enable the optimizer")
+	runs := fs.Int("runs", 200, "optimizer runs")
+	evm := fs.String("evm", "", "target evm version, e.g. paris")
+	viaIR := fs.Bool("via-ir", false, "compile through the yul ir pipeline")
+	cache := fs.String("cache", ctx.Config.SolcCache, "artifact cache directory, empty disables caching")
+	var includes, remaps stringsFlag
+	fs.Var(&includes, "include", "include path for imports, repeatable")
+	fs.Var(&remaps, "remap", "import remapping [context:]prefix=target, repeatable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *standard || fs.NArg() > 0 {
+		sources := fs.Args()
+		if *file != "" {
+			sources = append([]string{*file}, sources...)
+		}
+		solc, err := compiler.SolidityVersion(ctx.Config.Solc)
+		if err != nil {
+			return err
+		}
+		contracts, err := solc.CompileProject(&compiler.Project{
+			BasePath:     *base,
+			IncludePaths: includes,
+			Sources:      sources,
+			Remappings:   remaps,
+			Optimize:     *optimize,
+			Runs:         *runs,
+			EVMVersion:   *evm,
+			ViaIR:        *viaIR,
+			CacheDir:     *cache,
+		})
+		if err != nil {
+			return err
+		}
+		out := make([]compiledContract, 0, len(contracts))
+		for name, contract := range contracts {
+			abiValue, err := json.Marshal(contract.Info.AbiDefinition)
+			if err != nil {
+				return err
+			}
+			out = append(out, compiledContract{
+				Name:          name,
+				Abi:           abiValue,
+				Code:          contract.Code,
+				RuntimeCode:   contract.RuntimeCode,
+				StorageLayout: contract.Info.StorageLayout,
+				DevDoc:        contract.Info.DeveloperDoc,
+				UserDoc:       contract.Info.UserDoc,
+				Metadata:      contract.Info.Metadata,
+			})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+		return ctx.print(out)
+	}
+	source, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	contracts, err := Client.CompileContract(ctx.Config.Solc, string(source))
+	if err != nil {
+		return err
+	}
+	out := make([]compiledContract, 0, len(contracts))
+	for name, contract := range contracts {
+		out = append(out, compiledContract{Name: name, Abi: contract.AbiData, Code: contract.ContractCode})
+	}
+	return ctx.print(out)
+}
+
+// 指定 -artifact 时部署Hardhat, Foundry或Truffle的编译产物, 剩余参数为构造函数参数
+func contractDeploy(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("contract deploy")
+	file := fs.String("file", "", "solidity source file")
+	name := fs.String("name", "", "contract name, for logging, or the contract to pick from an artifact directory")
+	artifact := fs.String("artifact", "", "hardhat, foundry or truffle artifact file or directory")
+	format := fs.String("format", "hardhat", "format of an artifact directory: hardhat, foundry or truffle")
+	var libs stringsFlag
+	fs.Var(&libs, "lib", "library address name=address, repeatable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *artifact != "" {
+		return deployArtifact(ctx, *artifact, *format, *name, libs, fs.Args())
+	}
+	source, err := ioutil.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	c, err := ctx.dial(true)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	address, err := c.DeployContractAddress(string(source), *name)
+	if err != nil {
+		return err
+	}
+	return ctx.print(map[string]string{"name": *name, "address": address})
+}
+
+func deployArtifact(ctx *cmdContext, path, format, name string, libs []string, params []string) error {
+	config, err := loadArtifactConfig(path, format, name)
+	if err != nil {
+		return err
+	}
+	libraries := make(map[string]common.Address, len(libs))
+	for _, lib := range libs {
+		eq := strings.LastIndex(lib, "=")
+		if eq < 0 || !common.IsHexAddress(lib[eq+1:]) {
+			return fmt.Errorf("invalid -lib %q, want name=address", lib)
+		}
+		libraries[lib[:eq]] = common.HexToAddress(lib[eq+1:])
+	}
+	linked, err := Client.LinkContract(*config, libraries)
+	if err != nil {
+		return err
+	}
+	contractAbi, err := abi.JSON(bytes.NewReader(linked.AbiData))
+	if err != nil {
+		return err
+	}
+	values, err := abi.ParseArgs(contractAbi.Constructor.Inputs, params)
+	if err != nil {
+		return err
+	}
+	c, err := ctx.dial(true)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	address, err := c.DeployContractConfig(linked, values...)
+	if err != nil {
+		return err
+	}
+	return ctx.print(map[string]string{"name": linked.Name, "address": address, "metadataHash": linked.MetadataHash})
+}
+
+// 读取产物文件, 或从产物目录中按 源文件:合约名 或合约名选出一个合约
+func loadArtifactConfig(path, format, name string) (*models.ContractConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		config, err := Client.LoadArtifact(path)
+		if err == nil && config == nil {
+			err = fmt.Errorf("%s is not a contract artifact", path)
+		}
+		return config, err
+	}
+	var contracts map[string]models.ContractConfig
+	switch format {
+	case "hardhat":
+		contracts, err = Client.LoadHardhatArtifacts(path)
+	case "foundry":
+		contracts, err = Client.LoadFoundryArtifacts(path)
+	case "truffle":
+		contracts, err = Client.LoadTruffleArtifacts(path)
+	default:
+		return nil, fmt.Errorf("unknown artifact format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for key, config := range contracts {
+		if key == name {
+			return &config, nil
+		}
+		if config.Name == name {
+			matches = append(matches, key)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("contract %q not found in %s", name, path)
+	case 1:
+		config := contracts[matches[0]]
+		return &config, nil
+	}
+	sort.Strings(matches)
+	return nil, fmt.Errorf("contract name %q is ambiguous, use one of %s", name, strings.Join(matches, ", "))
+}
+
+// 合约调用的公共参数
+type contractFlags struct {
+	address *string
+	abiFile *string
+	method  *string
+}
+
+func parseContractFlags(name string, args []string, extra func(fs *flag.FlagSet)) (*contractFlags, *abi.ABI, string, []string, error) {
+	fs := newFlagSet(name)
+	flags := &contractFlags{
+		address: fs.String("address", "", "contract address"),
+		abiFile: fs.String("abi", "", "abi json or human-readable abi file"),
+		method:  fs.String("method", "", "method name"),
+	}
+	if extra != nil {
+		extra(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, "", nil, err
+	}
+	if !common.IsHexAddress(*flags.address) {
+		return nil, nil, "", nil, fmt.Errorf("invalid -address %q", *flags.address)
+	}
+	abiData, err := readAbiFile(*flags.abiFile)
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	contractAbi, err := abi.JSON(bytes.NewReader(abiData))
+	if err != nil {
+		return nil, nil, "", nil, err
+	}
+	return flags, &contractAbi, string(abiData), fs.Args(), nil
+}
+
+func contractCall(ctx *cmdContext, args []string) error {
+	flags, contractAbi, abiData, params, err := parseContractFlags("contract call", args, nil)
+	if err != nil {
+		return err
+	}
+	method, ok := contractAbi.Methods[*flags.method]
+	if !ok {
+		return fmt.Errorf("method %q not found in abi", *flags.method)
+	}
+	values, err := abi.ParseArgs(method.Inputs, params)
+	if err != nil {
+		return err
+	}
+	return queryContract(ctx, flags, method, abiData, values)
+}
+
+// 通过eth_call调用合约方法并输出结果
+func queryContract(ctx *cmdContext, flags *contractFlags, method abi.Method, abiData string, values []interface{}) error {
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	results := make([]interface{}, len(method.Outputs))
+	var dst interface{} = &results
+	if len(results) == 1 {
+		dst = &results[0]
+	}
+	if err := c.QueryContract(*flags.address, abiData, dst, *flags.method, values...); err != nil {
+		return err
+	}
+	return ctx.print(outputValues(method, results))
+}
+
+// 按输出参数名整理调用结果, 未命名的输出为 output<i>
+func outputValues(method abi.Method, results []interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(results))
+	for i, output := range method.Outputs {
+		name := output.Name
+		if name == "" {
+			name = fmt.Sprintf("output%d", i)
+		}
+		out[name] = abi.DecodedArgument{Type: output.Type, Value: results[i]}.JSONValue()
+	}
+	return out
+}
+
+func contractSend(ctx *cmdContext, args []string) error {
+	var nonce *int64
+	var value *string
+	var wait *bool
+	var timeout *time.Duration
+	flags, contractAbi, abiData, params, err := parseContractFlags("contract send", args, func(fs *flag.FlagSet) {
+		nonce = fs.Int64("nonce", -1, "nonce, -1 uses the pending nonce")
+		value = fs.String("value", "0", "wei sent with the call, the method must be payable")
+		wait = fs.Bool("wait", false, "wait for the receipt")
+		timeout = fs.Duration("timeout", time.Minute, "receipt wait timeout")
+	})
+	if err != nil {
+		return err
+	}
+	method, ok := contractAbi.Methods[*flags.method]
+	if !ok {
+		return fmt.Errorf("method %q not found in abi", *flags.method)
+	}
+	values, err := abi.ParseArgs(method.Inputs, params)
+	if err != nil {
+		return err
+	}
+	amount, err := abi.ParseInteger(*value)
+	if err != nil || amount.Sign() < 0 {
+		return fmt.Errorf("invalid -value %q", *value)
+	}
+	if amount.Sign() > 0 && !method.IsPayable() {
+		return fmt.Errorf("method %s is not payable", *flags.method)
+	}
+	c, err := ctx.dial(true)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	txNonce := uint64(*nonce)
+	if *nonce < 0 {
+		if txNonce, err = c.GetNonce(); err != nil {
+			return err
+		}
+	}
+	result, err := c.InvokeContractWithValue(*flags.address, abiData, txNonce, amount, *flags.method, values...)
+	if err != nil {
+		return err
+	}
+	// view和pure方法通过eth_call执行, 返回的是调用结果
+	if method.IsConstant() {
+		data, err := hexutil.Decode(result)
+		if err != nil {
+			return err
+		}
+		results, err := method.Outputs.UnpackValues(data)
+		if err != nil {
+			return err
+		}
+		return ctx.print(outputValues(method, results))
+	}
+	if !*wait {
+		return ctx.print(map[string]string{"txHash": result})
+	}
+	receipt, err := waitReceipt(c, result, *timeout)
+	if err != nil {
+		return err
+	}
+	return ctx.print(receipt)
+}
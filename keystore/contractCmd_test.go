@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethclient/abi"
+)
+
+// 命令行参数解析后打包, 解包结果按输出参数名转换为json
+func TestContractArgs(t *testing.T) {
+	parsed, err := abi.ParseHumanReadable([]string{
+		"function echo(address owner, uint256 amount, bytes32 tag, uint8[] ids, (string name, bool ok) info) view returns (address owner, uint256, bytes32 tag, uint8[] ids, (string name, bool ok) info)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := parsed.Methods["echo"]
+	params := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0x3e8",
+		"0x" + strings.Repeat("ab", 32),
+		"[1, 2, 3]",
+		`["x", true]`,
+	}
+	values, err := abi.ParseArgs(method.Inputs, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := method.Inputs.Pack(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := method.Outputs.UnpackValues(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(outputValues(method, results))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"ids":[1,2,3],"info":{"name":"x","ok":true},"output1":"1000","owner":"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed","tag":"0x` + strings.Repeat("ab", 32) + `"}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+
+	if _, err := abi.ParseArgs(method.Inputs, params[:4]); err == nil {
+		t.Error("expected an error for a missing argument")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	Client "github.com/ethclient/client"
+	"github.com/ethclient/common/flogging/metrics/prometheus"
+	"github.com/ethclient/ethclient"
+	"github.com/ethclient/gateway"
+	"github.com/ethclient/rpc"
+)
+
+var gatewayCommand = &command{
+	Name:   "gateway",
+	Usage:  "run a caching json-rpc gateway in front of the nodes",
+	Action: gatewayRun,
+}
+
+// 启动网关, 同一端口同时提供http和websocket服务
+// 主节点为websocket地址时, 网关的eth_subscribe订阅由主节点的订阅复用
+func gatewayRun(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("gateway")
+	listen := fs.String("listen", "127.0.0.1:8645", "listen address ip:port")
+	primaryAddr := fs.String("primary", "", "primary node for writes, defaults to the configured node")
+	upstreams := fs.String("upstreams", "", "comma separated read nodes, defaults to the primary")
+	cacheMB := fs.Int("cache", gateway.DefaultCacheSize>>20, "cache size in MB")
+	metricsAddr := fs.String("metrics", "", "serve prometheus metrics on ip:port")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *primaryAddr == "" {
+		*primaryAddr = ctx.Config.Node
+	}
+	primary, err := rpc.DialContext(context.Background(), Client.Endpoint(*primaryAddr), "", "", nil)
+	if err != nil {
+		return fmt.Errorf("dial primary %s: %v", *primaryAddr, err)
+	}
+	defer primary.Close()
+	var replicas []*rpc.Client
+	for _, addr := range strings.Split(*upstreams, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		c, err := rpc.DialContext(context.Background(), Client.Endpoint(addr), "", "", nil)
+		if err != nil {
+			return fmt.Errorf("dial upstream %s: %v", addr, err)
+		}
+		defer c.Close()
+		replicas = append(replicas, c)
+	}
+
+	gw := gateway.New(primary, replicas, int64(*cacheMB)<<20)
+	srv := gw.Server()
+	defer srv.Stop()
+	if endpoint := Client.Endpoint(*primaryAddr); strings.HasPrefix(endpoint, "ws") {
+		hub := ethclient.NewSubscriptionHub(ethclient.NewClient(primary), ethclient.HubConfig{})
+		defer hub.Close()
+		if err := srv.RegisterName("eth", hub.API()); err != nil {
+			return err
+		}
+	}
+	if *metricsAddr != "" {
+		provider := prometheus.NewProvider()
+		srv.SetMetricsProvider(provider)
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, provider); err != nil {
+				log.Errorf("metrics server: %v", err)
+			}
+		}()
+	}
+
+	ws := srv.WebsocketHandler([]string{"*"})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	})
+	log.Infof("gateway listening on %s, primary %s, %d read upstreams", *listen, *primaryAddr, len(replicas))
+	return http.ListenAndServe(*listen, handler)
+}
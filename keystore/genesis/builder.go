@@ -0,0 +1,299 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/core"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/models"
+	"github.com/ethclient/params"
+	"github.com/ethclient/rlp"
+)
+
+// 默认参数
+const (
+	DefaultCliquePeriod uint64 = 15
+	DefaultEpoch        uint64 = 30000
+	DefaultGasLimit     uint64 = 4712388
+)
+
+// 默认给每个挖矿账号分配的余额 2^256 / 128
+var DefaultSealerBalance = new(big.Int).Lsh(big.NewInt(1), 256-7)
+
+// 创世区块构造器
+type GenesisBuilder struct {
+	consensus      int
+	chainID        *big.Int
+	timestamp      *uint64
+	gasLimit       uint64
+	difficulty     *big.Int
+	period         uint64
+	epoch          uint64
+	proposerPolicy uint64
+	sealers        []common.Address
+	sealerBalance  *big.Int
+	precompiles    bool
+	alloc          core.GenesisAlloc
+}
+
+// 构造器选项
+type Option func(b *GenesisBuilder) error
+
+// 新建构造器, consensus 取值 models.RAFT POW POA PBFT
+func NewGenesisBuilder(consensus int, opts ...Option) (*GenesisBuilder, error) {
+	switch consensus {
+	case models.RAFT, models.POW, models.POA, models.PBFT:
+	default:
+		return nil, fmt.Errorf("invalid consensus engine choice %d", consensus)
+	}
+	b := &GenesisBuilder{
+		consensus:     consensus,
+		gasLimit:      DefaultGasLimit,
+		period:        DefaultCliquePeriod,
+		epoch:         DefaultEpoch,
+		sealerBalance: DefaultSealerBalance,
+		alloc:         make(core.GenesisAlloc),
+	}
+	for _, opt := range opts {
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// 链id
+func WithChainID(chainID *big.Int) Option {
+	return func(b *GenesisBuilder) error {
+		if chainID == nil || chainID.Sign() <= 0 {
+			return fmt.Errorf("chainId must be positive")
+		}
+		b.chainID = new(big.Int).Set(chainID)
+		return nil
+	}
+}
+
+// 创世时间戳, 默认为构造时的当前时间
+func WithTimestamp(timestamp uint64) Option {
+	return func(b *GenesisBuilder) error {
+		b.timestamp = &timestamp
+		return nil
+	}
+}
+
+// 每个块的gaslimit
+func WithGasLimit(gasLimit uint64) Option {
+	return func(b *GenesisBuilder) error {
+		b.gasLimit = gasLimit
+		return nil
+	}
+}
+
+// 创世难度, 默认 poa pbft 为1, raft pow 为0
+func WithDifficulty(difficulty *big.Int) Option {
+	return func(b *GenesisBuilder) error {
+		if difficulty == nil || difficulty.Sign() < 0 {
+			return fmt.Errorf("difficulty must not be negative")
+		}
+		b.difficulty = new(big.Int).Set(difficulty)
+		return nil
+	}
+}
+
+// clique 参数: 出块间隔(秒) 和 epoch
+func WithClique(period, epoch uint64) Option {
+	return func(b *GenesisBuilder) error {
+		if b.consensus != models.POA {
+			return fmt.Errorf("clique options require poa consensus")
+		}
+		b.period, b.epoch = period, epoch
+		return nil
+	}
+}
+
+// pbft 参数: epoch 和 proposer 选择策略
+func WithPbft(epoch, proposerPolicy uint64) Option {
+	return func(b *GenesisBuilder) error {
+		if b.consensus != models.PBFT {
+			return fmt.Errorf("pbft options require pbft consensus")
+		}
+		b.epoch, b.proposerPolicy = epoch, proposerPolicy
+		return nil
+	}
+}
+
+// 挖矿账号(poa签名者/pbft验证者)
+func WithSealers(sealers ...string) Option {
+	return func(b *GenesisBuilder) error {
+		for _, sealer := range sealers {
+			if !common.IsHexAddress(sealer) {
+				return fmt.Errorf("sealer %q is not a hex address", sealer)
+			}
+			b.sealers = append(b.sealers, common.HexToAddress(sealer))
+		}
+		return nil
+	}
+}
+
+// 挖矿账号的初始余额, nil 表示不分配
+func WithSealerBalance(balance *big.Int) Option {
+	return func(b *GenesisBuilder) error {
+		b.sealerBalance = balance
+		return nil
+	}
+}
+
+// 是否给 0x00..0xff 预编译地址分配 1 wei, 避免被清理
+func WithPrecompileBalances(enable bool) Option {
+	return func(b *GenesisBuilder) error {
+		b.precompiles = enable
+		return nil
+	}
+}
+
+// 自定义账户(余额, 代码, 存储), 覆盖默认分配
+func WithAlloc(alloc core.GenesisAlloc) Option {
+	return func(b *GenesisBuilder) error {
+		for addr, account := range alloc {
+			if account.Balance == nil {
+				return fmt.Errorf("alloc %s has no balance", addr.Hex())
+			}
+			b.alloc[addr] = account
+		}
+		return nil
+	}
+}
+
+// 从文件读取自定义账户, 格式同创世文件的 alloc 字段
+func WithAllocFile(file string) Option {
+	return func(b *GenesisBuilder) error {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var alloc core.GenesisAlloc
+		if err := json.Unmarshal(data, &alloc); err != nil {
+			return fmt.Errorf("alloc file %s: %v", file, err)
+		}
+		return WithAlloc(alloc)(b)
+	}
+}
+
+// 生成并校验创世区块
+func (b *GenesisBuilder) Build() (*core.Genesis, error) {
+	if b.chainID == nil {
+		return nil, fmt.Errorf("chainId is not set")
+	}
+	timestamp := uint64(time.Now().Unix())
+	if b.timestamp != nil {
+		timestamp = *b.timestamp
+	}
+	genesis := &core.Genesis{
+		Timestamp:  timestamp,
+		GasLimit:   b.gasLimit,
+		Difficulty: big.NewInt(0),
+		Alloc:      make(core.GenesisAlloc),
+		Config: &params.ChainConfig{
+			ChainID:          new(big.Int).Set(b.chainID),
+			SingularityBlock: big.NewInt(0),
+		},
+	}
+	switch b.consensus {
+	case models.RAFT:
+		genesis.Config.Raft = true
+		genesis.ExtraData = make([]byte, 32)
+	case models.POW:
+		genesis.Config.Ethash = new(params.EthashConfig)
+		genesis.ExtraData = make([]byte, 32)
+	case models.PBFT:
+		genesis.Difficulty = big.NewInt(1)
+		genesis.Config.Pbft = &params.PbftConfig{
+			ProposerPolicy: b.proposerPolicy,
+			Epoch:          b.epoch,
+		}
+		extra, err := pbftExtra(b.sealers)
+		if err != nil {
+			return nil, err
+		}
+		genesis.ExtraData = extra
+	case models.POA:
+		genesis.Difficulty = big.NewInt(1)
+		genesis.Config.Clique = &params.CliqueConfig{
+			Period: b.period,
+			Epoch:  b.epoch,
+		}
+		genesis.ExtraData = cliqueExtra(b.sealers)
+	}
+	if b.difficulty != nil {
+		genesis.Difficulty = new(big.Int).Set(b.difficulty)
+	}
+	if b.precompiles {
+		// Add a batch of precompile balances to avoid them getting deleted
+		for i := int64(0); i < 256; i++ {
+			genesis.Alloc[common.BigToAddress(big.NewInt(i))] = core.GenesisAccount{Balance: big.NewInt(1)}
+		}
+	}
+	if b.sealerBalance != nil {
+		for _, sealer := range b.sealers {
+			genesis.Alloc[sealer] = core.GenesisAccount{Balance: new(big.Int).Set(b.sealerBalance)}
+		}
+	}
+	for addr, account := range b.alloc {
+		genesis.Alloc[addr] = account
+	}
+	if err := Validate(genesis); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// 生成创世区块并写入文件
+func (b *GenesisBuilder) WriteFile(genesisFilePath string) (*core.Genesis, error) {
+	genesis, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	out, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(genesisFilePath, out, 0644); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// clique extra-data: 32字节vanity + 升序签名者 + 65字节签名
+func cliqueExtra(sealers []common.Address) []byte {
+	signers := make([]common.Address, len(sealers))
+	copy(signers, sealers)
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	extra := make([]byte, cliqueExtraVanity+len(signers)*common.AddressLength+cliqueExtraSeal)
+	for i, signer := range signers {
+		copy(extra[cliqueExtraVanity+i*common.AddressLength:], signer[:])
+	}
+	return extra
+}
+
+// pbft extra-data: 32字节vanity + rlp(ByzantineExtra)
+func pbftExtra(validators []common.Address) ([]byte, error) {
+	ist := &types.ByzantineExtra{
+		Validators:    validators,
+		Seal:          []byte{},
+		CommittedSeal: [][]byte{},
+	}
+	payload, err := rlp.EncodeToBytes(&ist)
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]byte, types.ByzantineExtraVanity), payload...), nil
+}
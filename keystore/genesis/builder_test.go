@@ -0,0 +1,148 @@
+package genesis
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/models"
+	"github.com/ethclient/rlp"
+)
+
+const (
+	sealerA = "0x00000000000000000000000000000000000000bb"
+	sealerB = "0x00000000000000000000000000000000000000aa"
+)
+
+func TestBuildClique(t *testing.T) {
+	builder, err := NewGenesisBuilder(models.POA,
+		WithChainID(big.NewInt(99)),
+		WithClique(5, 100),
+		WithSealers(sealerA, sealerB),
+		WithTimestamp(1234),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genesis.Config.ChainID.Int64() != 99 || genesis.Timestamp != 1234 {
+		t.Fatalf("chainId %v timestamp %d", genesis.Config.ChainID, genesis.Timestamp)
+	}
+	if genesis.Config.Clique.Period != 5 || genesis.Config.Clique.Epoch != 100 {
+		t.Fatalf("clique config %+v", genesis.Config.Clique)
+	}
+	if len(genesis.ExtraData) != 32+2*common.AddressLength+65 {
+		t.Fatalf("extra length %d", len(genesis.ExtraData))
+	}
+	// signers must be sorted
+	if common.BytesToAddress(genesis.ExtraData[32:52]) != common.HexToAddress(sealerB) {
+		t.Fatalf("signers not sorted: %x", genesis.ExtraData)
+	}
+	if genesis.Alloc[common.HexToAddress(sealerA)].Balance.Cmp(DefaultSealerBalance) != 0 {
+		t.Fatal("sealer not funded")
+	}
+}
+
+func TestBuildPbft(t *testing.T) {
+	builder, err := NewGenesisBuilder(models.PBFT,
+		WithChainID(big.NewInt(7)),
+		WithPbft(500, 1),
+		WithSealers(sealerA),
+		WithSealerBalance(nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extra types.ByzantineExtra
+	if err := rlp.DecodeBytes(genesis.ExtraData[types.ByzantineExtraVanity:], &extra); err != nil {
+		t.Fatal(err)
+	}
+	if len(extra.Validators) != 1 || extra.Validators[0] != common.HexToAddress(sealerA) {
+		t.Fatalf("validators %v", extra.Validators)
+	}
+	if genesis.Config.Pbft.Epoch != 500 || genesis.Config.Pbft.ProposerPolicy != 1 {
+		t.Fatalf("pbft config %+v", genesis.Config.Pbft)
+	}
+	if len(genesis.Alloc) != 0 {
+		t.Fatalf("unexpected alloc %v", genesis.Alloc)
+	}
+}
+
+func TestBuildAllocFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	allocFile := filepath.Join(dir, "alloc.json")
+	alloc := `{
+		"0x00000000000000000000000000000000000000bb": {"balance": "0x10"},
+		"0x0000000000000000000000000000000000000100": {"balance": "1", "code": "0x6000", "storage": {"0x01": "0x02"}, "nonce": "0x3"}
+	}`
+	if err := ioutil.WriteFile(allocFile, []byte(alloc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	builder, err := NewGenesisBuilder(models.RAFT,
+		WithChainID(big.NewInt(1)),
+		WithSealers(sealerA),
+		WithAllocFile(allocFile),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "genesis.json")
+	genesis, err := builder.WriteFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// explicit alloc overrides the default sealer balance
+	if genesis.Alloc[common.HexToAddress(sealerA)].Balance.Int64() != 16 {
+		t.Fatal("alloc file did not override sealer balance")
+	}
+	contract := genesis.Alloc[common.HexToAddress("0x0000000000000000000000000000000000000100")]
+	if contract.Nonce != 3 || len(contract.Code) != 2 || contract.Storage[common.BigToHash(big.NewInt(1))] != common.BigToHash(big.NewInt(2)) {
+		t.Fatalf("contract account %+v", contract)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	tests := []struct {
+		consensus int
+		opts      []Option
+		err       string
+	}{
+		{consensus: 9, err: "invalid consensus"},
+		{consensus: models.POA, opts: []Option{WithSealers(sealerA)}, err: "chainId is not set"},
+		{consensus: models.POA, opts: []Option{WithChainID(big.NewInt(1))}, err: "no signers"},
+		{consensus: models.POA, opts: []Option{WithChainID(big.NewInt(1)), WithSealers(sealerA, sealerA)}, err: "ascending"},
+		{consensus: models.PBFT, opts: []Option{WithChainID(big.NewInt(1))}, err: "no validators"},
+		{consensus: models.RAFT, opts: []Option{WithChainID(big.NewInt(1)), WithGasLimit(10)}, err: "gasLimit"},
+		{consensus: models.RAFT, opts: []Option{WithClique(1, 1)}, err: "require poa"},
+		{consensus: models.RAFT, opts: []Option{WithSealers("0xzz")}, err: "not a hex address"},
+		{consensus: models.RAFT, opts: []Option{WithChainID(big.NewInt(0))}, err: "positive"},
+	}
+	for i, test := range tests {
+		builder, err := NewGenesisBuilder(test.consensus, test.opts...)
+		if err == nil {
+			_, err = builder.Build()
+		}
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: error %v, want %q", i, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package genesis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/core"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/rlp"
+)
+
+const (
+	MinGasLimit uint64 = 5000               // 创世区块最小gaslimit
+	MaxGasLimit uint64 = 0x7fffffffffffffff // 创世区块最大gaslimit
+
+	cliqueExtraVanity = 32 // clique extra-data 前缀长度
+	cliqueExtraSeal   = 65 // clique extra-data 签名长度
+)
+
+// 校验创世区块配置
+func Validate(genesis *core.Genesis) error {
+	if genesis.Config == nil {
+		return errors.New("genesis has no chain config")
+	}
+	if genesis.Config.ChainID == nil || genesis.Config.ChainID.Sign() <= 0 {
+		return errors.New("genesis chainId must be set and positive")
+	}
+	if genesis.GasLimit < MinGasLimit || genesis.GasLimit > MaxGasLimit {
+		return fmt.Errorf("genesis gasLimit %d out of bounds [%d, %d]", genesis.GasLimit, MinGasLimit, MaxGasLimit)
+	}
+	if genesis.Difficulty == nil {
+		return errors.New("genesis difficulty must be set")
+	}
+	engines := 0
+	if genesis.Config.Ethash != nil {
+		engines++
+	}
+	if genesis.Config.Clique != nil {
+		engines++
+	}
+	if genesis.Config.Scrypt != nil {
+		engines++
+	}
+	if genesis.Config.Raft {
+		engines++
+	}
+	if genesis.Config.Pbft != nil {
+		engines++
+	}
+	if engines != 1 {
+		return fmt.Errorf("genesis must configure exactly one consensus engine, have %d", engines)
+	}
+	switch {
+	case genesis.Config.Clique != nil:
+		return validateCliqueExtra(genesis)
+	case genesis.Config.Pbft != nil:
+		return validatePbftExtra(genesis)
+	}
+	return nil
+}
+
+// clique: 32字节vanity + n*20字节签名者(升序) + 65字节签名
+func validateCliqueExtra(genesis *core.Genesis) error {
+	if genesis.Config.Clique.Epoch == 0 {
+		return errors.New("clique epoch must be positive")
+	}
+	extra := genesis.ExtraData
+	if len(extra) < cliqueExtraVanity+cliqueExtraSeal {
+		return fmt.Errorf("clique extraData too short: %d bytes", len(extra))
+	}
+	signersBytes := len(extra) - cliqueExtraVanity - cliqueExtraSeal
+	if signersBytes%common.AddressLength != 0 {
+		return fmt.Errorf("clique extraData signer section is %d bytes, not a multiple of %d", signersBytes, common.AddressLength)
+	}
+	if signersBytes == 0 {
+		return errors.New("clique extraData contains no signers")
+	}
+	var prev []byte
+	for i := 0; i < signersBytes/common.AddressLength; i++ {
+		signer := extra[cliqueExtraVanity+i*common.AddressLength : cliqueExtraVanity+(i+1)*common.AddressLength]
+		if prev != nil && bytes.Compare(prev, signer) >= 0 {
+			return fmt.Errorf("clique signers not strictly ascending at index %d", i)
+		}
+		prev = signer
+	}
+	return nil
+}
+
+// pbft: 32字节vanity + rlp(ByzantineExtra)
+func validatePbftExtra(genesis *core.Genesis) error {
+	if genesis.Config.Pbft.Epoch == 0 {
+		return errors.New("pbft epoch must be positive")
+	}
+	if len(genesis.ExtraData) < types.ByzantineExtraVanity {
+		return fmt.Errorf("pbft extraData too short: %d bytes", len(genesis.ExtraData))
+	}
+	var extra types.ByzantineExtra
+	if err := rlp.DecodeBytes(genesis.ExtraData[types.ByzantineExtraVanity:], &extra); err != nil {
+		return fmt.Errorf("pbft extraData: %v", err)
+	}
+	if len(extra.Validators) == 0 {
+		return errors.New("pbft extraData contains no validators")
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/ethclient/core"
+	"github.com/ethclient/keystore/genesis"
+	"github.com/ethclient/models"
+)
+
+var genesisCommand = &command{
+	Name:  "genesis",
+	Usage: "create and check genesis files",
+	Commands: []*command{
+		{Name: "make", Usage: "write a new genesis file", Action: genesisMake},
+		{Name: "validate", Usage: "validate a genesis file", Action: genesisValidate},
+		{Name: "hash", Usage: "compute the genesis state root and block hash", Action: genesisHash},
+	},
+}
+
+func genesisMake(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("genesis make")
+	consensus := fs.String("consensus", "poa", "consensus engine: poa, pbft, raft or pow")
+	chainID := fs.Int64("chainid", 1, "chain id")
+	period := fs.Uint64("period", genesis.DefaultCliquePeriod, "block period in seconds (poa)")
+	epoch := fs.Uint64("epoch", genesis.DefaultEpoch, "epoch length (poa, pbft)")
+	policy := fs.Uint64("policy", 0, "proposer policy (pbft)")
+	sealers := fs.String("sealers", "", "comma separated sealer addresses")
+	sealerBalance := fs.String("sealerbalance", genesis.DefaultSealerBalance.String(), "balance of each sealer in wei, empty for none")
+	precompiles := fs.Bool("precompiles", true, "pre-fund the 256 precompile addresses with 1 wei")
+	gasLimit := fs.Uint64("gaslimit", genesis.DefaultGasLimit, "block gas limit")
+	timestamp := fs.Int64("timestamp", -1, "genesis timestamp, -1 for now")
+	allocFile := fs.String("alloc", "", "json file with extra accounts (balance, code, storage, nonce)")
+	out := fs.String("out", "genesis.json", "output file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	engine, ok := genesis.GenesisMap[*consensus]
+	if !ok {
+		return fmt.Errorf("unknown consensus %q", *consensus)
+	}
+	opts := []genesis.Option{
+		genesis.WithChainID(big.NewInt(*chainID)),
+		genesis.WithGasLimit(*gasLimit),
+		genesis.WithPrecompileBalances(*precompiles),
+	}
+	if *sealers != "" {
+		opts = append(opts, genesis.WithSealers(strings.Split(*sealers, ",")...))
+	}
+	if *sealerBalance == "" {
+		opts = append(opts, genesis.WithSealerBalance(nil))
+	} else {
+		balance, ok := new(big.Int).SetString(*sealerBalance, 0)
+		if !ok {
+			return fmt.Errorf("invalid -sealerbalance %q", *sealerBalance)
+		}
+		opts = append(opts, genesis.WithSealerBalance(balance))
+	}
+	switch engine {
+	case models.POA:
+		opts = append(opts, genesis.WithClique(*period, *epoch))
+	case models.PBFT:
+		opts = append(opts, genesis.WithPbft(*epoch, *policy))
+	}
+	if *timestamp >= 0 {
+		opts = append(opts, genesis.WithTimestamp(uint64(*timestamp)))
+	}
+	if *allocFile != "" {
+		opts = append(opts, genesis.WithAllocFile(*allocFile))
+	}
+	builder, err := genesis.NewGenesisBuilder(engine, opts...)
+	if err != nil {
+		return err
+	}
+	g, err := builder.WriteFile(*out)
+	if err != nil {
+		return err
+	}
+	return ctx.print(map[string]interface{}{"file": *out, "consensus": *consensus, "chainId": g.Config.ChainID, "alloc": len(g.Alloc)})
+}
+
+func genesisValidate(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("genesis validate")
+	file := fs.String("file", "genesis.json", "genesis file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	g, err := readGenesis(*file)
+	if err != nil {
+		return err
+	}
+	if err := genesis.Validate(g); err != nil {
+		return fmt.Errorf("%s: %v", *file, err)
+	}
+	return ctx.print(map[string]interface{}{"file": *file, "valid": true, "chainId": g.Config.ChainID, "alloc": len(g.Alloc)})
+}
+
+func genesisHash(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("genesis hash")
+	file := fs.String("file", "genesis.json", "genesis file")
+	verify := fs.Bool("verify", false, "compare with block 0 of the configured node")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	g, err := readGenesis(*file)
+	if err != nil {
+		return err
+	}
+	block, err := g.ToBlock()
+	if err != nil {
+		return err
+	}
+	out := map[string]interface{}{"file": *file, "stateRoot": block.Root().Hex(), "hash": block.Hash().Hex()}
+	if *verify {
+		c, err := ctx.dial(false)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		if _, err := c.VerifyGenesis(g); err != nil {
+			return err
+		}
+		out["verified"] = true
+	}
+	return ctx.print(out)
+}
+
+func readGenesis(file string) (*core.Genesis, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	g := new(core.Genesis)
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return g, nil
+}
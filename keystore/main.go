@@ -1,12 +1,108 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/ethclient/common/flogging"
 )
 
 var log = flogging.MustGetLogger("sipcclient.keystore.main")
 
+// 子命令
+type command struct {
+	Name     string
+	Usage    string
+	Action   func(ctx *cmdContext, args []string) error
+	Commands []*command
+}
+
+// 命令执行上下文
+type cmdContext struct {
+	Config *Config
+}
+
+var commands = []*command{
+	accountCommand,
+	genesisCommand,
+	txCommand,
+	contractCommand,
+	blockCommand,
+	rpcCommand,
+	gatewayCommand,
+	abiCommand,
+	storageCommand,
+}
+
 func main() {
+	fs := flag.NewFlagSet("keystore", flag.ContinueOnError)
+	configFile := fs.String("config", defaultConfigFile(), "config file (json)")
+	node := fs.String("node", "", "node rpc address ip:port, overrides config")
+	output := fs.String("output", "", "output format: json or table, overrides config")
+	fs.Usage = func() { printUsage(fs, commands) }
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	cfg, err := LoadConfig(*configFile)
+	if err != nil {
+		fatal(err)
+	}
+	if *node != "" {
+		cfg.Node = *node
+	}
+	if *output != "" {
+		cfg.Output = *output
+	}
+	if err := run(&cmdContext{Config: cfg}, commands, fs.Args(), nil); err != nil {
+		fatal(err)
+	}
+}
+
+// 按参数找到子命令并执行
+func run(ctx *cmdContext, cmds []*command, args []string, path []string) error {
+	if len(args) == 0 {
+		printCommands(path, cmds)
+		return fmt.Errorf("missing command")
+	}
+	for _, cmd := range cmds {
+		if cmd.Name != args[0] {
+			continue
+		}
+		if cmd.Action != nil {
+			return cmd.Action(ctx, args[1:])
+		}
+		return run(ctx, cmd.Commands, args[1:], append(path, cmd.Name))
+	}
+	printCommands(path, cmds)
+	return fmt.Errorf("unknown command %q", strings.Join(append(path, args[0]), " "))
+}
+
+func printUsage(fs *flag.FlagSet, cmds []*command) {
+	fmt.Fprintf(os.Stderr, "usage: keystore [global flags] <command> [subcommand] [flags]\n\nglobal flags:\n")
+	fs.PrintDefaults()
+	printCommands(nil, cmds)
+}
+
+func printCommands(path []string, cmds []*command) {
+	fmt.Fprintf(os.Stderr, "\ncommands:\n")
+	for _, cmd := range cmds {
+		fmt.Fprintf(os.Stderr, "  %-30s %s\n", strings.Join(append(append([]string{}, path...), cmd.Name), " "), cmd.Usage)
+		for _, sub := range cmd.Commands {
+			fmt.Fprintf(os.Stderr, "  %-30s %s\n", strings.Join(append(append([]string{}, path...), cmd.Name, sub.Name), " "), sub.Usage)
+		}
+	}
+}
+
+// 子命令的flag集合
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
 
-	log.Info("success")
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	os.Exit(1)
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var called []string
+	action := func(name string) func(*cmdContext, []string) error {
+		return func(ctx *cmdContext, args []string) error {
+			called = append([]string{name}, args...)
+			return nil
+		}
+	}
+	cmds := []*command{
+		{Name: "account", Commands: []*command{
+			{Name: "new", Action: action("account new")},
+			{Name: "list", Action: action("account list")},
+		}},
+		{Name: "block", Action: action("block")},
+	}
+	tests := []struct {
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{args: []string{"block", "-verify", "0x1"}, want: []string{"block", "-verify", "0x1"}},
+		{args: []string{"account", "list"}, want: []string{"account list"}},
+		{args: []string{"account", "new", "-password", "pw"}, want: []string{"account new", "-password", "pw"}},
+		{args: nil, wantErr: "missing command"},
+		{args: []string{"account"}, wantErr: "missing command"},
+		{args: []string{"acount"}, wantErr: `unknown command "acount"`},
+		{args: []string{"account", "remove"}, wantErr: `unknown command "account remove"`},
+	}
+	for _, test := range tests {
+		called = nil
+		err := run(&cmdContext{}, cmds, test.args, nil)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%q: got error %v, want %q", test.args, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+		} else if !reflect.DeepEqual(called, test.want) {
+			t.Errorf("%q: called %q, want %q", test.args, called, test.want)
+		}
+	}
+}
+
+// 所有子命令都要有名称、说明和处理函数
+func TestCommands(t *testing.T) {
+	names := make(map[string]bool)
+	for _, cmd := range commands {
+		if names[cmd.Name] {
+			t.Errorf("duplicate command %q", cmd.Name)
+		}
+		names[cmd.Name] = true
+		if cmd.Action == nil && len(cmd.Commands) == 0 {
+			t.Errorf("command %q has neither an action nor subcommands", cmd.Name)
+		}
+		for _, sub := range cmd.Commands {
+			if sub.Name == "" || sub.Usage == "" || sub.Action == nil {
+				t.Errorf("incomplete subcommand %q of %q", sub.Name, cmd.Name)
+			}
+		}
+	}
+}
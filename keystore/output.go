@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// 按配置的格式输出结果
+func (ctx *cmdContext) print(v interface{}) error {
+	switch ctx.Config.Output {
+	case outputJSON, "":
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	case outputTable:
+		return printTable(os.Stdout, v)
+	default:
+		return fmt.Errorf("unknown output format %q", ctx.Config.Output)
+	}
+}
+
+// 表格输出: 对象输出为 key/value 两列, 对象数组输出为多列
+func printTable(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	switch value := generic.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			fmt.Fprintf(tw, "%s\t%s\n", key, cell(value[key]))
+		}
+	case []interface{}:
+		var columns []string
+		seen := make(map[string]bool)
+		for _, row := range value {
+			if obj, ok := row.(map[string]interface{}); ok {
+				for _, key := range sortedKeys(obj) {
+					if !seen[key] {
+						seen[key] = true
+						columns = append(columns, key)
+					}
+				}
+			}
+		}
+		if len(columns) == 0 {
+			for _, row := range value {
+				fmt.Fprintf(tw, "%s\n", cell(row))
+			}
+			break
+		}
+		for i, column := range columns {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, column)
+		}
+		fmt.Fprintln(tw)
+		for _, row := range value {
+			obj, _ := row.(map[string]interface{})
+			for i, column := range columns {
+				if i > 0 {
+					fmt.Fprint(tw, "\t")
+				}
+				fmt.Fprint(tw, cell(obj[column]))
+			}
+			fmt.Fprintln(tw)
+		}
+	default:
+		fmt.Fprintf(tw, "%s\n", cell(value))
+	}
+	return tw.Flush()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// 单元格内容, 嵌套结构输出为紧凑json
+func cell(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case map[string]interface{}, []interface{}:
+		out, _ := json.Marshal(value)
+		return string(out)
+	default:
+		return fmt.Sprint(value)
+	}
+}
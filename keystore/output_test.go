@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintTable(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "object",
+			v:    map[string]interface{}{"number": 12, "hash": "0xab", "uncles": []string{"0x01"}, "miner": nil},
+			want: "hash    0xab\nminer   \nnumber  12\nuncles  [\"0x01\"]\n",
+		},
+		{
+			name: "rows",
+			v: []map[string]interface{}{
+				{"name": "alice", "balance": "100"},
+				{"name": "bob", "nonce": 2},
+			},
+			want: "balance  name   nonce\n100      alice  \n         bob    2\n",
+		},
+		{
+			name: "list",
+			v:    []string{"0x01", "0x02"},
+			want: "0x01\n0x02\n",
+		},
+		{
+			name: "scalar",
+			v:    uint64(1 << 60),
+			want: "1152921504606846976\n",
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := printTable(&buf, test.v); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if buf.String() != test.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", test.name, buf.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+	"github.com/ethclient/storage"
+)
+
+var storageCommand = &command{
+	Name:  "storage",
+	Usage: "decode contract storage by the solc storage layout",
+	Commands: []*command{
+		{Name: "read", Usage: "read state variables, all but mappings by default", Action: storageRead},
+		{Name: "slot", Usage: "print the slot and offset of a variable", Action: storageSlot},
+		{Name: "proxy", Usage: "read the ERC-1967 implementation, admin and beacon slots", Action: storageProxy},
+	},
+}
+
+func storageRead(ctx *cmdContext, args []string) error {
+	return withStorage(ctx, "storage read", args, func(rctx context.Context, c *storage.Contract, paths []string) error {
+		if len(paths) == 0 {
+			values, err := c.ReadAll(rctx)
+			if err != nil {
+				return err
+			}
+			return ctx.print(values)
+		}
+		values := make(map[string]interface{}, len(paths))
+		for _, path := range paths {
+			value, err := c.Read(rctx, path)
+			if err != nil {
+				return err
+			}
+			values[path] = value
+		}
+		return ctx.print(values)
+	})
+}
+
+func storageSlot(ctx *cmdContext, args []string) error {
+	return withStorage(ctx, "storage slot", args, func(rctx context.Context, c *storage.Contract, paths []string) error {
+		if len(paths) != 1 {
+			return fmt.Errorf("usage: storage slot -address <address> -layout <file> <variable>")
+		}
+		slot, offset, err := c.Slot(rctx, paths[0])
+		if err != nil {
+			return err
+		}
+		return ctx.print(map[string]interface{}{"slot": slot, "offset": offset})
+	})
+}
+
+func storageProxy(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("storage proxy")
+	address := fs.String("address", "", "proxy contract address")
+	block := fs.String("block", "latest", "block number or latest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(*address) {
+		return fmt.Errorf("invalid -address %q", *address)
+	}
+	number, err := parseBlockFlag(*block)
+	if err != nil {
+		return err
+	}
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	proxy, err := storage.ReadProxy(*c.Ctx, c.ClientPara.Client, common.HexToAddress(*address), number)
+	if err != nil {
+		return err
+	}
+	return ctx.print(proxy)
+}
+
+// 解析 -address -layout -block 参数并连接节点, 剩余参数为变量路径
+func withStorage(ctx *cmdContext, name string, args []string, fn func(rctx context.Context, c *storage.Contract, paths []string) error) error {
+	fs := newFlagSet(name)
+	address := fs.String("address", "", "contract address, for a proxy use the proxy address with the implementation layout")
+	layoutFile := fs.String("layout", "", "storage layout json, or solc output or artifact containing storageLayout")
+	block := fs.String("block", "latest", "block number or latest")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(*address) {
+		return fmt.Errorf("invalid -address %q", *address)
+	}
+	data, err := ioutil.ReadFile(*layoutFile)
+	if err != nil {
+		return err
+	}
+	layout, err := storage.ParseLayout(data)
+	if err != nil {
+		return err
+	}
+	number, err := parseBlockFlag(*block)
+	if err != nil {
+		return err
+	}
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	contract := storage.NewContract(c.ClientPara.Client, common.HexToAddress(*address), layout).At(number)
+	return fn(*c.Ctx, contract, fs.Args())
+}
+
+// latest 返回 nil
+func parseBlockFlag(s string) (*big.Int, error) {
+	if s == "latest" {
+		return nil, nil
+	}
+	number, err := abi.ParseInteger(s)
+	if err != nil || number.Sign() < 0 {
+		return nil, fmt.Errorf("invalid -block %q", s)
+	}
+	return number, nil
+}
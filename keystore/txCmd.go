@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	Client "github.com/ethclient/client"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/core/types"
+	"github.com/ethclient/models"
+)
+
+var txCommand = &command{
+	Name:  "tx",
+	Usage: "send, decode and wait for transactions",
+	Commands: []*command{
+		{Name: "send", Usage: "sign and send a transaction", Action: txSend},
+		{Name: "decode", Usage: "decode a raw signed transaction", Action: txDecode},
+		{Name: "wait", Usage: "wait for a transaction receipt", Action: txWait},
+		{Name: "accesslist", Usage: "create the access list of a call with eth_createAccessList", Action: txAccessList},
+	},
+}
+
+func txSend(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("tx send")
+	to := fs.String("to", "", "recipient address, empty creates a contract")
+	value := fs.String("value", "0", "value in wei")
+	data := fs.String("data", "", "hex call data")
+	nonce := fs.Int64("nonce", -1, "nonce, -1 uses the pending nonce")
+	wait := fs.Bool("wait", false, "wait for the receipt")
+	timeout := fs.Duration("timeout", time.Minute, "receipt wait timeout")
+	txType := fs.Uint("type", types.LegacyTxType, "transaction type: 0 legacy, 1 access list, 2 dynamic fee")
+	tip := fs.String("tip", "", "maxPriorityFeePerGas in wei for type 2, defaults to the node suggestion")
+	feeCap := fs.String("feecap", "", "maxFeePerGas in wei for type 2, defaults to 2*baseFee+tip")
+	accessListFile := fs.String("accesslist", "", "json file with the access list")
+	autoAccessList := fs.Bool("auto-accesslist", false, "create the access list with eth_createAccessList")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	payload, err := hexutil.Decode(hexPrefix(*data))
+	if err != nil {
+		return fmt.Errorf("invalid -data: %v", err)
+	}
+	var opts *models.TxOptions
+	if *txType != types.LegacyTxType {
+		if opts, err = txOptions(uint8(*txType), *tip, *feeCap, *accessListFile, *autoAccessList); err != nil {
+			return err
+		}
+	}
+	c, err := ctx.dial(true)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	txNonce := uint64(*nonce)
+	if *nonce < 0 {
+		if txNonce, err = c.GetNonce(); err != nil {
+			return err
+		}
+	}
+	opType := models.NORMAL_TRANSACTION
+	if *to == "" {
+		opType = models.CREATE_CONTRACT
+	}
+	var txHash *string
+	if opts != nil {
+		txHash, err = c.SendTypedTransaction(opts, txNonce, *to, *value, payload)
+	} else {
+		txHash, err = c.SendTransaction(opType, txNonce, *to, *value, payload)
+	}
+	if err != nil {
+		return err
+	}
+	if !*wait {
+		return ctx.print(map[string]string{"txHash": *txHash})
+	}
+	receipt, err := waitReceipt(c, *txHash, *timeout)
+	if err != nil {
+		return err
+	}
+	return ctx.print(receipt)
+}
+
+// 解析类型交易的参数
+func txOptions(txType uint8, tip, feeCap, accessListFile string, auto bool) (*models.TxOptions, error) {
+	opts := &models.TxOptions{Type: txType, CreateAccessList: auto}
+	var ok bool
+	if tip != "" {
+		if opts.GasTipCap, ok = new(big.Int).SetString(tip, 10); !ok {
+			return nil, fmt.Errorf("invalid -tip %q", tip)
+		}
+	}
+	if feeCap != "" {
+		if opts.GasFeeCap, ok = new(big.Int).SetString(feeCap, 10); !ok {
+			return nil, fmt.Errorf("invalid -feecap %q", feeCap)
+		}
+	}
+	if accessListFile != "" {
+		data, err := ioutil.ReadFile(accessListFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &opts.AccessList); err != nil {
+			return nil, fmt.Errorf("invalid access list: %v", err)
+		}
+	}
+	return opts, nil
+}
+
+func txAccessList(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("tx accesslist")
+	from := fs.String("from", "", "sender address, defaults to the configured key")
+	to := fs.String("to", "", "recipient address, empty for contract creation")
+	value := fs.String("value", "0", "value in wei")
+	data := fs.String("data", "", "hex call data")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	payload, err := hexutil.Decode(hexPrefix(*data))
+	if err != nil {
+		return fmt.Errorf("invalid -data: %v", err)
+	}
+	c, err := ctx.dial(*from == "")
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	accessList, gasUsed, err := c.CreateAccessList(*from, *to, *value, payload)
+	if err != nil {
+		return err
+	}
+	return ctx.print(map[string]interface{}{"accessList": accessList, "gasUsed": gasUsed})
+}
+
+// 解码的交易
+type decodedTx struct {
+	Type       uint8            `json:"type"`
+	Hash       string           `json:"hash"`
+	From       string           `json:"from"`
+	To         *common.Address  `json:"to"`
+	Nonce      uint64           `json:"nonce"`
+	Value      string           `json:"value"`
+	Gas        uint64           `json:"gas"`
+	GasPrice   string           `json:"gasPrice,omitempty"`
+	GasTipCap  string           `json:"maxPriorityFeePerGas,omitempty"`
+	GasFeeCap  string           `json:"maxFeePerGas,omitempty"`
+	ChainId    string           `json:"chainId,omitempty"`
+	AccessList types.AccessList `json:"accessList,omitempty"`
+	Data       hexutil.Bytes    `json:"data"`
+}
+
+func txDecode(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("tx decode")
+	raw := fs.String("raw", "", "hex encoded signed transaction")
+	file := fs.String("file", "", "file containing the hex encoded transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	input := *raw
+	if *file != "" {
+		data, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		input = strings.TrimSpace(string(data))
+	}
+	b, err := hexutil.Decode(hexPrefix(input))
+	if err != nil {
+		return err
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(b); err != nil {
+		return err
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	decoded := decodedTx{
+		Type:       tx.Type(),
+		Hash:       tx.Hash().Hex(),
+		To:         tx.To(),
+		Nonce:      tx.Nonce(),
+		Value:      tx.Value().String(),
+		Gas:        tx.Gas(),
+		AccessList: tx.AccessList(),
+		Data:       tx.Data(),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		decoded.GasTipCap = tx.GasTipCap().String()
+		decoded.GasFeeCap = tx.GasFeeCap().String()
+	} else {
+		decoded.GasPrice = tx.GasPrice().String()
+	}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+		decoded.ChainId = tx.ChainId().String()
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	decoded.From = from.Hex()
+	return ctx.print(decoded)
+}
+
+func txWait(ctx *cmdContext, args []string) error {
+	fs := newFlagSet("tx wait")
+	hash := fs.String("hash", "", "transaction hash")
+	timeout := fs.Duration("timeout", time.Minute, "wait timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *hash == "" {
+		return fmt.Errorf("-hash is required")
+	}
+	c, err := ctx.dial(false)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	receipt, err := waitReceipt(c, *hash, *timeout)
+	if err != nil {
+		return err
+	}
+	return ctx.print(receipt)
+}
+
+// 轮询交易receipt直到上链或超时
+func waitReceipt(c *Client.EthClient, txHash string, timeout time.Duration) (*types.Receipt, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		receipt, err := c.GetTransactionReceipt(txHash)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("transaction %s not mined after %v", txHash, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func hexPrefix(s string) string {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return s
+	}
+	return "0x" + s
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"math/big"
+
 	"github.com/ethclient/common"
 	"github.com/ethclient/common/hexutil"
 	"github.com/ethclient/core/types"
@@ -54,6 +56,7 @@ type Header struct {
 	TotalDifficulty string `json:"totalDifficulty"`
 	Size            string `json:"size"`
 	Hash            string `json:"hash"`
+	BaseFee         string `json:"baseFeePerGas,omitempty"`
 }
 
 type Transaction struct {
@@ -71,6 +74,22 @@ type Transaction struct {
 	S                string `json:"s"`
 	Hash             string `json:"hash"`
 	From             string `json:"from"`
+
+	// EIP-2718类型交易的字段, 旧交易为空
+	Type                 string           `json:"type,omitempty"`
+	ChainId              string           `json:"chainId,omitempty"`
+	MaxFeePerGas         string           `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string           `json:"maxPriorityFeePerGas,omitempty"`
+	AccessList           types.AccessList `json:"accessList,omitempty"`
+}
+
+// 发送EIP-2718类型交易的参数
+type TxOptions struct {
+	Type             uint8            `json:"type"`             // 交易类型 types.AccessListTxType 或 types.DynamicFeeTxType
+	GasTipCap        *big.Int         `json:"gasTipCap"`        // maxPriorityFeePerGas, 为空时使用节点建议值
+	GasFeeCap        *big.Int         `json:"gasFeeCap"`        // maxFeePerGas, 为空时使用 2*baseFee+gasTipCap
+	AccessList       types.AccessList `json:"accessList"`       // 访问列表
+	CreateAccessList bool             `json:"createAccessList"` // 是否通过eth_createAccessList生成访问列表
 }
 
 type Log struct {
@@ -126,6 +145,26 @@ type MixTransaction struct {
 	Status          string      `json:"status"`
 	ContractAddress string      `json:"contractAddress"`
 	Sipc            string      `json:"sipc" gencodec:"required"`
+	// 接收地址在ABI注册表中时, 按ABI解码后的调用
+	Call *DecodedCall `json:"decodedCall,omitempty"`
+}
+
+// 按ABI解码后的合约调用
+type DecodedCall struct {
+	Method    string       `json:"method"`
+	Signature string       `json:"signature"`
+	Selector  string       `json:"selector"`
+	Args      []DecodedArg `json:"args"`
+	// 按签名库解码时选择器冲突, 有多个函数能解码, 顶层字段只是第一个候选
+	Ambiguous  bool           `json:"ambiguous,omitempty"`
+	Candidates []*DecodedCall `json:"candidates,omitempty"`
+}
+
+// 解码后的调用参数, Value 为可直接输出为JSON的值
+type DecodedArg struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
 }
 
 type MixedBlock struct {
@@ -148,6 +187,7 @@ type MixedBlock struct {
 	TotalDifficulty string           `json:"totalDifficulty"`
 	Size            string           `json:"size"`
 	Hash            string           `json:"hash"`
+	BaseFee         string           `json:"baseFeePerGas,omitempty"`
 	Uncles          []Header         `json:"uncles"`
 	Transactions    []MixTransaction `json:"transactions"`
 }
@@ -172,6 +212,7 @@ type Block struct {
 	TotalDifficulty string        `json:"totalDifficulty"`
 	Size            string        `json:"size"`
 	Hash            string        `json:"hash"`
+	BaseFee         string        `json:"baseFeePerGas,omitempty"`
 	Uncles          []Header      `json:"uncles"`
 	Transactions    []Transaction `json:"transactions"`
 }
@@ -193,6 +234,22 @@ type SendTxArgs struct {
 type ContractConfig struct {
 	AbiData      []byte `json:"abiData"` // 合约abi
 	ContractCode string `json:"contractCode"`
+	// 以下字段由编译产物导入时填充
+	Name                   string         `json:"name,omitempty"`                   // 合约名
+	SourceName             string         `json:"sourceName,omitempty"`             // 源文件
+	DeployedCode           string         `json:"deployedCode,omitempty"`           // 运行时字节码
+	LinkReferences         LinkReferences `json:"linkReferences,omitempty"`         // ContractCode中待链接的库
+	DeployedLinkReferences LinkReferences `json:"deployedLinkReferences,omitempty"` // DeployedCode中待链接的库
+	MetadataHash           string         `json:"metadataHash,omitempty"`           // 字节码末尾的元数据哈希, 如 ipfs:Qm...
+}
+
+// 待链接库在字节码中的位置, 源文件 -> 库名 -> 位置
+type LinkReferences map[string]map[string][]LinkReference
+
+// 库地址在字节码中的字节偏移和长度
+type LinkReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
 }
 
 type ClientPara struct {
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+)
+
+// 内置tracer名称
+const (
+	StructLogger   = ""
+	CallTracer     = "callTracer"
+	PrestateTracer = "prestateTracer"
+)
+
+// TraceConfig debug_traceTransaction/debug_traceCall 的参数
+type TraceConfig struct {
+	DisableStorage   bool            `json:"disableStorage,omitempty"`
+	DisableStack     bool            `json:"disableStack,omitempty"`
+	DisableMemory    bool            `json:"disableMemory,omitempty"`
+	EnableMemory     bool            `json:"enableMemory,omitempty"`
+	EnableReturnData bool            `json:"enableReturnData,omitempty"`
+	Tracer           string          `json:"tracer,omitempty"`
+	TracerConfig     json.RawMessage `json:"tracerConfig,omitempty"`
+	Timeout          string          `json:"timeout,omitempty"`
+	Reexec           *uint64         `json:"reexec,omitempty"`
+}
+
+// StructLog 默认struct logger输出的单步执行记录
+type StructLog struct {
+	Pc      uint64            `json:"pc"`
+	Op      string            `json:"op"`
+	Gas     uint64            `json:"gas"`
+	GasCost uint64            `json:"gasCost"`
+	Depth   int               `json:"depth"`
+	Error   string            `json:"error,omitempty"`
+	Stack   []string          `json:"stack,omitempty"`
+	Memory  []string          `json:"memory,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+	Refund  uint64            `json:"refund,omitempty"`
+}
+
+// ExecutionResult 默认struct logger的输出
+type ExecutionResult struct {
+	Gas         uint64      `json:"gas"`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"returnValue"`
+	StructLogs  []StructLog `json:"structLogs"`
+}
+
+// RevertLog 返回执行出错或REVERT的那一步, 未出错返回nil
+func (r *ExecutionResult) RevertLog() *StructLog {
+	if !r.Failed {
+		return nil
+	}
+	for i := len(r.StructLogs) - 1; i >= 0; i-- {
+		if r.StructLogs[i].Op == "REVERT" || r.StructLogs[i].Error != "" {
+			return &r.StructLogs[i]
+		}
+	}
+	return nil
+}
+
+// CallFrame callTracer输出的调用帧, Calls为子调用
+type CallFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []CallFrame     `json:"calls,omitempty"`
+}
+
+// RevertFrame 返回引发回滚的最内层调用帧, 未出错返回nil
+func (f *CallFrame) RevertFrame() *CallFrame {
+	if f.Error == "" {
+		return nil
+	}
+	for i := len(f.Calls) - 1; i >= 0; i-- {
+		if frame := f.Calls[i].RevertFrame(); frame != nil {
+			return frame
+		}
+	}
+	return f
+}
+
+// PrestateAccount prestateTracer输出的账户状态
+type PrestateAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// PrestateResult prestateTracer的输出
+type PrestateResult map[common.Address]*PrestateAccount
+
+// PrestateDiff prestateTracer在diffMode下的输出
+type PrestateDiff struct {
+	Pre  PrestateResult `json:"pre"`
+	Post PrestateResult `json:"post"`
+}
+
+// TraceResult trace结果, 按tracer类型填充对应字段, 自定义tracer只保留Raw
+type TraceResult struct {
+	Tracer       string           `json:"tracer"`
+	StructLogs   *ExecutionResult `json:"structLogs,omitempty"`
+	Call         *CallFrame       `json:"call,omitempty"`
+	Prestate     PrestateResult   `json:"prestate,omitempty"`
+	PrestateDiff *PrestateDiff    `json:"prestateDiff,omitempty"`
+	Raw          json.RawMessage  `json:"raw"`
+}
@@ -0,0 +1,27 @@
+// Copyright 2015 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+import "math/big"
+
+const (
+	GenesisGasLimit uint64 = 4712388 // Gas limit of the Genesis block.
+)
+
+var (
+	GenesisDifficulty = big.NewInt(131072) // Difficulty of the Genesis block.
+)
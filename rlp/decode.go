@@ -394,9 +394,16 @@ func makeStructDecoder(typ reflect.Type) (decoder, error) {
 		if _, err := s.List(); err != nil {
 			return wrapStreamError(err, typ)
 		}
-		for _, f := range fields {
+		for i, f := range fields {
 			err := f.info.decoder(s, val.Field(f.index))
 			if err == EOL {
+				if f.optional {
+					// The field is optional, so reaching the end of the list before
+					// reaching the last field is acceptable. All remaining undecoded
+					// fields are zeroed.
+					zeroFields(val, fields[i:])
+					break
+				}
 				return &decodeError{msg: "too few elements", typ: typ}
 			} else if err != nil {
 				return addErrorContext(err, "."+typ.Field(f.index).Name)
@@ -407,6 +414,13 @@ func makeStructDecoder(typ reflect.Type) (decoder, error) {
 	return dec, nil
 }
 
+func zeroFields(structval reflect.Value, fields []field) {
+	for _, f := range fields {
+		fv := structval.Field(f.index)
+		fv.Set(reflect.Zero(fv.Type()))
+	}
+}
+
 // makePtrDecoder creates a decoder that decodes into the pointer's element type.
 func makePtrDecoder(typ reflect.Type, tag tags) (decoder, error) {
 	etype := typ.Elem()
@@ -654,6 +668,33 @@ func (s *Stream) Uint() (uint64, error) {
 	return s.uint(64)
 }
 
+// Uint64 reads an RLP string of up to 8 bytes and returns its contents
+// as an unsigned integer.
+func (s *Stream) Uint64() (uint64, error) {
+	return s.uint(64)
+}
+
+// Uint32 reads an RLP string of up to 4 bytes and returns its contents
+// as an unsigned integer.
+func (s *Stream) Uint32() (uint32, error) {
+	i, err := s.uint(32)
+	return uint32(i), err
+}
+
+// Uint16 reads an RLP string of up to 2 bytes and returns its contents
+// as an unsigned integer.
+func (s *Stream) Uint16() (uint16, error) {
+	i, err := s.uint(16)
+	return uint16(i), err
+}
+
+// Uint8 reads an RLP string of up to 1 byte and returns its contents
+// as an unsigned integer.
+func (s *Stream) Uint8() (uint8, error) {
+	i, err := s.uint(8)
+	return uint8(i), err
+}
+
 func (s *Stream) uint(maxbits int) (uint64, error) {
 	kind, size, err := s.Kind()
 	if err != nil {
@@ -705,6 +746,67 @@ func (s *Stream) Bool() (bool, error) {
 	}
 }
 
+// BigInt decodes an arbitrary-size integer value.
+func (s *Stream) BigInt() (*big.Int, error) {
+	b, err := s.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	// Reject leading zero bytes
+	if len(b) > 0 && b[0] == 0 {
+		return nil, ErrCanonInt
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// ReadBytes decodes the next RLP value and stores the result in b.
+// The value size must match len(b) exactly.
+func (s *Stream) ReadBytes(b []byte) error {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case Byte:
+		if len(b) == 0 {
+			return fmt.Errorf("rlp: input string too long for [%d]byte", len(b))
+		}
+		if len(b) > 1 {
+			return fmt.Errorf("rlp: input string too short for [%d]byte", len(b))
+		}
+		s.kind = -1 // rearm Kind
+		b[0] = s.byteval
+		return nil
+	case String:
+		if uint64(len(b)) < size {
+			return fmt.Errorf("rlp: input string too long for [%d]byte", len(b))
+		}
+		if uint64(len(b)) > size {
+			return fmt.Errorf("rlp: input string too short for [%d]byte", len(b))
+		}
+		if err = s.readFull(b); err != nil {
+			return err
+		}
+		// Reject cases where single byte encoding should have been used.
+		if size == 1 && b[0] < 128 {
+			return ErrCanonSize
+		}
+		return nil
+	default:
+		return ErrExpectedString
+	}
+}
+
+// MoreDataInList reports whether the current list context contains
+// more data to be read.
+func (s *Stream) MoreDataInList() bool {
+	if len(s.stack) == 0 {
+		return false
+	}
+	tos := s.stack[len(s.stack)-1]
+	return tos.pos < tos.size
+}
+
 // List starts decoding an RLP list. If the input does not contain a
 // list, the returned error will be ErrExpectedList. When the list's
 // end has been reached, any Stream operation will return EOL.
@@ -0,0 +1,74 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rlp
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethclient/common/hexutil"
+)
+
+func TestDecodeOptional(t *testing.T) {
+	tests := []struct {
+		input string
+		ptr   interface{}
+		value interface{}
+		err   string
+	}{
+		{input: "0xc101", ptr: new(optionalFields), value: optionalFields{A: 1}},
+		{input: "0xc20102", ptr: new(optionalFields), value: optionalFields{A: 1, B: 2}},
+		{input: "0xc3010203", ptr: new(optionalFields), value: optionalFields{A: 1, B: 2, C: 3}},
+		{input: "0xc401020304", ptr: new(optionalFields), err: "rlp: input list has too many elements for rlp.optionalFields"},
+		{input: "0xc0", ptr: new(optionalFields), err: "rlp: too few elements for rlp.optionalFields"},
+		{input: "0xc101", ptr: new(optionalAndTailField), value: optionalAndTailField{A: 1}},
+		{input: "0xc401020304", ptr: new(optionalAndTailField), value: optionalAndTailField{A: 1, B: 2, Tail: []uint{3, 4}}},
+		{input: "0xc101", ptr: new(optionalBigIntField), value: optionalBigIntField{A: 1}},
+		{input: "0xc20180", ptr: new(optionalBigIntField), value: optionalBigIntField{A: 1, B: new(big.Int)}},
+		{input: "0xc101", ptr: new(optionalPtrField), value: optionalPtrField{A: 1}},
+		{input: "0xc50183010203", ptr: new(optionalPtrField), value: optionalPtrField{A: 1, B: &[3]byte{1, 2, 3}}},
+	}
+	for i, test := range tests {
+		err := DecodeBytes(hexutil.MustDecode(test.input), test.ptr)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("test %d: got error %v, want %q", i, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if got := reflect.ValueOf(test.ptr).Elem().Interface(); !reflect.DeepEqual(got, test.value) {
+			t.Errorf("test %d: got %#v, want %#v", i, got, test.value)
+		}
+	}
+}
+
+// Missing optional fields are zeroed, also when decoding into a value that
+// was set before.
+func TestDecodeOptionalResetsFields(t *testing.T) {
+	v := optionalFields{A: 9, B: 9, C: 9}
+	if err := DecodeBytes(hexutil.MustDecode("0xc20102"), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v != (optionalFields{A: 1, B: 2}) {
+		t.Errorf("got %+v", v)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rlp
+
+import (
+	"io"
+	"math/big"
+)
+
+// EncoderBuffer is a buffer for incremental encoding. It is used by generated
+// EncodeRLP methods which write their fields directly instead of going through
+// reflection.
+//
+// The zero value is NOT ready for use. To get a usable buffer, create it using
+// NewEncoderBuffer or call Reset.
+type EncoderBuffer struct {
+	buf       *encbuf
+	dst       io.Writer
+	ownBuffer bool
+}
+
+// NewEncoderBuffer creates an encoder buffer writing to dst. When dst is the
+// writer handed to an EncodeRLP method, the buffer appends to the enclosing
+// encoding and Flush does not need to write anything.
+func NewEncoderBuffer(dst io.Writer) EncoderBuffer {
+	var w EncoderBuffer
+	w.Reset(dst)
+	return w
+}
+
+// Reset truncates the buffer and sets the output destination.
+func (w *EncoderBuffer) Reset(dst io.Writer) {
+	if w.buf != nil && !w.ownBuffer {
+		panic("can't Reset derived EncoderBuffer")
+	}
+	// If the destination writer has an *encbuf, use it.
+	if outer := encbufFromWriter(dst); outer != nil {
+		*w = EncoderBuffer{outer, nil, false}
+		return
+	}
+	// Get a fresh buffer.
+	if w.buf == nil {
+		w.buf = encbufPool.Get().(*encbuf)
+		w.ownBuffer = true
+	}
+	w.buf.reset()
+	w.dst = dst
+}
+
+// Flush writes encoded RLP data to the output writer. This can only be called
+// once. If you want to re-use the buffer after Flush, you must call Reset.
+func (w *EncoderBuffer) Flush() error {
+	var err error
+	if w.dst != nil {
+		err = w.buf.toWriter(w.dst)
+	}
+	// Release the internal buffer.
+	if w.ownBuffer {
+		encbufPool.Put(w.buf)
+	}
+	*w = EncoderBuffer{}
+	return err
+}
+
+// ToBytes returns the encoded bytes.
+func (w *EncoderBuffer) ToBytes() []byte {
+	return w.buf.toBytes()
+}
+
+// Write appends b directly to the encoder output.
+func (w EncoderBuffer) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// WriteBool writes b as the integer 0 (false) or 1 (true).
+func (w EncoderBuffer) WriteBool(b bool) {
+	if b {
+		w.buf.str = append(w.buf.str, 0x01)
+	} else {
+		w.buf.str = append(w.buf.str, 0x80)
+	}
+}
+
+// WriteUint64 encodes an unsigned integer.
+func (w EncoderBuffer) WriteUint64(i uint64) {
+	w.buf.encodeUint(i)
+}
+
+// WriteBigInt encodes a big.Int as an RLP string. A nil value is encoded as
+// zero. Note: Unlike with Encode, the sign of i is ignored, callers must
+// reject negative values before writing them.
+func (w EncoderBuffer) WriteBigInt(i *big.Int) {
+	if i == nil || i.Sign() == 0 {
+		w.buf.str = append(w.buf.str, 0x80)
+		return
+	}
+	w.buf.encodeString(i.Bytes())
+}
+
+// WriteBytes encodes b as an RLP string.
+func (w EncoderBuffer) WriteBytes(b []byte) {
+	w.buf.encodeString(b)
+}
+
+// WriteString encodes s as an RLP string.
+func (w EncoderBuffer) WriteString(s string) {
+	if len(s) == 1 && s[0] <= 0x7f {
+		// fits single byte, no string header
+		w.buf.str = append(w.buf.str, s[0])
+	} else {
+		w.buf.encodeStringHeader(len(s))
+		w.buf.str = append(w.buf.str, s...)
+	}
+}
+
+// List starts a list. It returns an internal index. Call ListEnd with this
+// index after encoding the content to finish the list.
+func (w EncoderBuffer) List() int {
+	w.buf.list()
+	return len(w.buf.lheads) - 1
+}
+
+// ListEnd finishes the given list.
+func (w EncoderBuffer) ListEnd(index int) {
+	w.buf.listEnd(w.buf.lheads[index])
+}
+
+// encbufFromWriter returns the encoding buffer behind w, if w is the writer
+// passed to an EncodeRLP method.
+func encbufFromWriter(w io.Writer) *encbuf {
+	switch w := w.(type) {
+	case *encbuf:
+		return w
+	case EncoderBuffer:
+		return w.buf
+	case *EncoderBuffer:
+		return w.buf
+	default:
+		return nil
+	}
+}
@@ -17,6 +17,7 @@
 package rlp
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -44,7 +45,7 @@ type Encoder interface {
 //
 // Please see package-level documentation of encoding rules.
 func Encode(w io.Writer, val interface{}) error {
-	if outer, ok := w.(*encbuf); ok {
+	if outer := encbufFromWriter(w); outer != nil {
 		// Encode was called by some type's EncodeRLP.
 		// Avoid copying by writing to the outer encbuf directly.
 		return outer.encode(val)
@@ -302,6 +303,9 @@ func (r *encReader) next() []byte {
 	}
 }
 
+// ErrNegativeBigInt is returned when encoding a negative *big.Int.
+var ErrNegativeBigInt = errors.New("rlp: cannot encode negative *big.Int")
+
 var (
 	encoderInterface = reflect.TypeOf(new(Encoder)).Elem()
 	big0             = big.NewInt(0)
@@ -352,7 +356,11 @@ func writeRawValue(val reflect.Value, w *encbuf) error {
 }
 
 func writeUint(val reflect.Value, w *encbuf) error {
-	i := val.Uint()
+	w.encodeUint(val.Uint())
+	return nil
+}
+
+func (w *encbuf) encodeUint(i uint64) {
 	if i == 0 {
 		w.str = append(w.str, 0x80)
 	} else if i < 128 {
@@ -364,7 +372,6 @@ func writeUint(val reflect.Value, w *encbuf) error {
 		w.sizebuf[0] = 0x80 + byte(s)
 		w.str = append(w.str, w.sizebuf[:s+1]...)
 	}
-	return nil
 }
 
 func writeBool(val reflect.Value, w *encbuf) error {
@@ -392,7 +399,7 @@ func writeBigIntNoPtr(val reflect.Value, w *encbuf) error {
 
 func writeBigInt(i *big.Int, w *encbuf) error {
 	if cmp := i.Cmp(big0); cmp == -1 {
-		return fmt.Errorf("rlp: cannot encode negative *big.Int")
+		return ErrNegativeBigInt
 	} else if cmp == 0 {
 		w.str = append(w.str, 0x80)
 	} else {
@@ -478,19 +485,52 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 			return nil, structFieldError{typ, f.index, f.info.writerErr}
 		}
 	}
-	writer := func(val reflect.Value, w *encbuf) error {
-		lh := w.list()
-		for _, f := range fields {
-			if err := f.info.writer(val.Field(f.index), w); err != nil {
-				return err
+	var writer writer
+	firstOptionalField := firstOptionalField(fields)
+	if firstOptionalField == len(fields) {
+		// This is the writer function for structs without any optional fields.
+		writer = func(val reflect.Value, w *encbuf) error {
+			lh := w.list()
+			for _, f := range fields {
+				if err := f.info.writer(val.Field(f.index), w); err != nil {
+					return err
+				}
 			}
+			w.listEnd(lh)
+			return nil
+		}
+	} else {
+		// If there are any "optional" fields, the writer needs to perform additional
+		// checks to determine the output list length.
+		writer = func(val reflect.Value, w *encbuf) error {
+			lastField := len(fields) - 1
+			for ; lastField >= firstOptionalField; lastField-- {
+				if !isZeroValue(val.Field(fields[lastField].index)) {
+					break
+				}
+			}
+			lh := w.list()
+			for i := 0; i <= lastField; i++ {
+				if err := fields[i].info.writer(val.Field(fields[i].index), w); err != nil {
+					return err
+				}
+			}
+			w.listEnd(lh)
+			return nil
 		}
-		w.listEnd(lh)
-		return nil
 	}
 	return writer, nil
 }
 
+// isZeroValue reports whether v is the zero value of its type.
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
 func makePtrWriter(typ reflect.Type, ts tags) (writer, error) {
 	etypeinfo := cachedTypeInfo1(typ.Elem(), tags{})
 	if etypeinfo.writerErr != nil {
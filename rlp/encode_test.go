@@ -0,0 +1,86 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rlp
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethclient/common/hexutil"
+)
+
+type optionalFields struct {
+	A uint
+	B uint `rlp:"optional"`
+	C uint `rlp:"optional"`
+}
+
+type optionalAndTailField struct {
+	A    uint
+	B    uint   `rlp:"optional"`
+	Tail []uint `rlp:"tail"`
+}
+
+type optionalBigIntField struct {
+	A uint
+	B *big.Int `rlp:"optional"`
+}
+
+type optionalPtrField struct {
+	A uint
+	B *[3]byte `rlp:"optional"`
+}
+
+func TestEncodeOptional(t *testing.T) {
+	tests := []struct {
+		val    interface{}
+		output string
+	}{
+		{&optionalFields{A: 1}, "0xc101"},
+		{&optionalFields{A: 1, B: 2}, "0xc20102"},
+		{&optionalFields{A: 1, B: 2, C: 3}, "0xc3010203"},
+		// zero values before the last non-zero optional field are written
+		{&optionalFields{A: 1, B: 0, C: 3}, "0xc3018003"},
+		{&optionalAndTailField{A: 1}, "0xc101"},
+		{&optionalAndTailField{A: 1, B: 2}, "0xc20102"},
+		{&optionalAndTailField{A: 1, Tail: []uint{5, 6}}, "0xc401800506"},
+		{&optionalBigIntField{A: 1}, "0xc101"},
+		{&optionalBigIntField{A: 1, B: big.NewInt(0)}, "0xc20180"},
+		{&optionalPtrField{A: 1}, "0xc101"},
+		{&optionalPtrField{A: 1, B: &[3]byte{1, 2, 3}}, "0xc50183010203"},
+	}
+	for i, test := range tests {
+		output, err := EncodeToBytes(test.val)
+		if err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if hexutil.Encode(output) != test.output {
+			t.Errorf("test %d: got %#x, want %s", i, output, test.output)
+		}
+	}
+
+	// Encode into a writer uses the same struct writer.
+	var buf bytes.Buffer
+	if err := Encode(&buf, &optionalFields{A: 1, B: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(buf.Bytes()) != "0xc20102" {
+		t.Errorf("Encode: got %#x", buf.Bytes())
+	}
+}
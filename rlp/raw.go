@@ -28,6 +28,13 @@ type RawValue []byte
 
 var rawValueType = reflect.TypeOf(RawValue{})
 
+var (
+	// EmptyString is the encoding of an empty string.
+	EmptyString = []byte{0x80}
+	// EmptyList is the encoding of an empty list.
+	EmptyList = []byte{0xC0}
+)
+
 // ListSize returns the encoded size of an RLP list with the given
 // content size.
 func ListSize(contentSize uint64) uint64 {
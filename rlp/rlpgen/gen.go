@@ -0,0 +1,704 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const rlpPackage = "github.com/ethclient/rlp"
+
+// tags are the parsed rlp struct tags of a field, see package rlp.
+type tags struct {
+	nilOK    bool
+	nilKind  string // "String" or "List"
+	tail     bool
+	optional bool
+	ignored  bool
+}
+
+type field struct {
+	name string
+	typ  types.Type
+	tags tags
+}
+
+// genContext holds the state of one output file.
+type genContext struct {
+	pkg     *types.Package
+	targets map[*types.Named]bool // types getting methods in this file
+	imports map[string]string     // path => name
+	inline  map[*types.Named]bool // named structs currently being inlined
+	tmp     int
+}
+
+func newGenContext(pkg *types.Package, targets []*types.Named) *genContext {
+	ctx := &genContext{
+		pkg:     pkg,
+		targets: make(map[*types.Named]bool),
+		imports: make(map[string]string),
+		inline:  make(map[*types.Named]bool),
+	}
+	for _, t := range targets {
+		ctx.targets[t] = true
+	}
+	return ctx
+}
+
+// file assembles the output file and formats it.
+func (ctx *genContext) file(body []byte) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by rlpgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", ctx.pkg.Name())
+	paths := make([]string, 0, len(ctx.imports))
+	for path := range ctx.imports {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if isStd(paths[i]) != isStd(paths[j]) {
+			return isStd(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	// Standard library imports go first, separated from the others.
+	fmt.Fprintf(&b, "import (\n")
+	for i, path := range paths {
+		if i > 0 && isStd(paths[i-1]) && !isStd(path) {
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	fmt.Fprintf(&b, ")\n\n")
+	b.Write(body)
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format generated code: %v\n%s", err, b.Bytes())
+	}
+	return code, nil
+}
+
+func isStd(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// typeString returns the name of typ in the generated file and records the
+// imports it needs.
+func (ctx *genContext) typeString(typ types.Type) string {
+	return types.TypeString(typ, func(p *types.Package) string {
+		if p == ctx.pkg {
+			return ""
+		}
+		ctx.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+// rlp returns the qualifier of package rlp.
+func (ctx *genContext) rlp() string {
+	if ctx.pkg.Path() == rlpPackage {
+		return ""
+	}
+	ctx.imports[rlpPackage] = "rlp"
+	return "rlp."
+}
+
+func (ctx *genContext) newTmp() string {
+	ctx.tmp++
+	return fmt.Sprintf("_tmp%d", ctx.tmp)
+}
+
+func (ctx *genContext) genEncodeRLP(b *bytes.Buffer, named *types.Named) error {
+	ctx.tmp = 0
+	ctx.imports["io"] = "io"
+	var body bytes.Buffer
+	if err := ctx.encodeStruct(&body, named.Underlying().(*types.Struct), "obj"); err != nil {
+		return fmt.Errorf("%s: %v", named.Obj().Name(), err)
+	}
+	name := named.Obj().Name()
+	fmt.Fprintf(b, "func (obj *%s) EncodeRLP(_w io.Writer) error {\n", name)
+	fmt.Fprintf(b, "w := %sNewEncoderBuffer(_w)\n", ctx.rlp())
+	b.Write(body.Bytes())
+	fmt.Fprintf(b, "return w.Flush()\n}\n\n")
+	return nil
+}
+
+func (ctx *genContext) genDecodeRLP(b *bytes.Buffer, named *types.Named) error {
+	ctx.tmp = 0
+	var body bytes.Buffer
+	if err := ctx.decodeStruct(&body, named.Underlying().(*types.Struct), "obj"); err != nil {
+		return fmt.Errorf("%s: %v", named.Obj().Name(), err)
+	}
+	name := named.Obj().Name()
+	fmt.Fprintf(b, "func (obj *%s) DecodeRLP(dec *%sStream) error {\n", name, ctx.rlp())
+	b.Write(body.Bytes())
+	fmt.Fprintf(b, "return nil\n}\n\n")
+	return nil
+}
+
+// encodeStruct writes the fields of the struct expr as a list.
+func (ctx *genContext) encodeStruct(b *bytes.Buffer, st *types.Struct, expr string) error {
+	fields, err := structFields(st)
+	if err != nil {
+		return err
+	}
+	list := ctx.newTmp()
+	fmt.Fprintf(b, "%s := w.List()\n", list)
+	// Trailing optional fields are only written if any of them is set.
+	present := make(map[int]string)
+	for i := len(fields) - 1; i >= 0 && fields[i].tags.optional; i-- {
+		f := fields[i]
+		cond, err := ctx.nonZero(f.typ, expr+"."+f.name)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+		present[i] = ctx.newTmp()
+		if next, ok := present[i+1]; ok {
+			cond = cond + " || " + next
+		}
+		fmt.Fprintf(b, "%s := %s\n", present[i], cond)
+	}
+	for i, f := range fields {
+		if cond, ok := present[i]; ok {
+			fmt.Fprintf(b, "if %s {\n", cond)
+		}
+		if err := ctx.encode(b, f.typ, expr+"."+f.name, f.tags); err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+		if _, ok := present[i]; ok {
+			fmt.Fprintf(b, "}\n")
+		}
+	}
+	fmt.Fprintf(b, "w.ListEnd(%s)\n", list)
+	return nil
+}
+
+// encode writes the value of the addressable expression expr.
+func (ctx *genContext) encode(b *bytes.Buffer, typ types.Type, expr string, ts tags) error {
+	switch {
+	case isRawValue(typ):
+		fmt.Fprintf(b, "w.Write(%s)\n", expr)
+
+	case isBigInt(typ):
+		fmt.Fprintf(b, "if %s.Sign() == -1 {\nreturn %sErrNegativeBigInt\n}\n", expr, ctx.rlp())
+		fmt.Fprintf(b, "w.WriteBigInt(&%s)\n", expr)
+
+	case isPointer(typ) && isBigInt(elem(typ)):
+		fmt.Fprintf(b, "if %s == nil {\nw.Write(%sEmptyString)\n} else {\n", expr, ctx.rlp())
+		fmt.Fprintf(b, "if %s.Sign() == -1 {\nreturn %sErrNegativeBigInt\n}\n", expr, ctx.rlp())
+		fmt.Fprintf(b, "w.WriteBigInt(%s)\n}\n", expr)
+
+	case isPointer(typ):
+		fmt.Fprintf(b, "if %s == nil {\n", expr)
+		fmt.Fprintf(b, "w.Write(%sEmpty%s)\n", ctx.rlp(), nilKind(typ, ts))
+		fmt.Fprintf(b, "} else {\n")
+		if ctx.isEncoder(elem(typ)) {
+			fmt.Fprintf(b, "if err := %s.EncodeRLP(&w); err != nil {\nreturn err\n}\n", expr)
+		} else if err := ctx.encode(b, elem(typ), "(*"+expr+")", tags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "}\n")
+
+	case ctx.isEncoder(typ):
+		fmt.Fprintf(b, "if err := %s.EncodeRLP(&w); err != nil {\nreturn err\n}\n", expr)
+
+	case isUint(typ):
+		fmt.Fprintf(b, "w.WriteUint64(%s)\n", convert(typ, types.Uint64, expr))
+
+	case isKind(typ, types.Bool):
+		fmt.Fprintf(b, "w.WriteBool(%s)\n", convert(typ, types.Bool, expr))
+
+	case isKind(typ, types.String):
+		fmt.Fprintf(b, "w.WriteString(%s)\n", convert(typ, types.String, expr))
+
+	case isByteSlice(typ):
+		fmt.Fprintf(b, "w.WriteBytes(%s)\n", expr)
+
+	case isByteArray(typ):
+		fmt.Fprintf(b, "w.WriteBytes(%s[:])\n", expr)
+
+	case isList(typ):
+		var list string
+		if !ts.tail {
+			list = ctx.newTmp()
+			fmt.Fprintf(b, "%s := w.List()\n", list)
+		}
+		index := ctx.newTmp()
+		fmt.Fprintf(b, "for %s := range %s {\n", index, expr)
+		if err := ctx.encode(b, elem(typ), expr+"["+index+"]", tags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "}\n")
+		if !ts.tail {
+			fmt.Fprintf(b, "w.ListEnd(%s)\n", list)
+		}
+
+	case isStruct(typ):
+		named, _ := typ.(*types.Named)
+		if named != nil && ctx.inline[named] {
+			// Recursive type, leave it to the reflective encoder.
+			return ctx.encodeFallback(b, expr)
+		}
+		if named != nil {
+			ctx.inline[named] = true
+			defer delete(ctx.inline, named)
+		}
+		return ctx.encodeStruct(b, typ.Underlying().(*types.Struct), expr)
+
+	case isInterface(typ):
+		return ctx.encodeFallback(b, expr)
+
+	default:
+		return fmt.Errorf("type %s is not RLP-serializable", typ)
+	}
+	return nil
+}
+
+func (ctx *genContext) encodeFallback(b *bytes.Buffer, expr string) error {
+	fmt.Fprintf(b, "if err := %sEncode(&w, &%s); err != nil {\nreturn err\n}\n", ctx.rlp(), expr)
+	return nil
+}
+
+// nonZero returns a condition which is true if expr is not the zero value.
+func (ctx *genContext) nonZero(typ types.Type, expr string) (string, error) {
+	switch u := typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface:
+		return expr + " != nil", nil
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return expr, nil
+		case u.Info()&types.IsString != 0:
+			return expr + ` != ""`, nil
+		case u.Info()&types.IsNumeric != 0:
+			return expr + " != 0", nil
+		}
+	case *types.Array, *types.Struct:
+		if types.Comparable(typ) {
+			return fmt.Sprintf("%s != (%s{})", expr, ctx.typeString(typ)), nil
+		}
+	}
+	return "", fmt.Errorf("optional field of type %s is not supported", typ)
+}
+
+// decodeStruct reads the list of fields into the struct lhs.
+func (ctx *genContext) decodeStruct(b *bytes.Buffer, st *types.Struct, lhs string) error {
+	fields, err := structFields(st)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(b, "if _, err := dec.List(); err != nil {\nreturn err\n}\n")
+	var optional []int // indexes of the optional fields
+	for i, f := range fields {
+		if f.tags.optional {
+			// An optional field may be missing together with all fields after
+			// it, missing fields are zeroed.
+			fmt.Fprintf(b, "if dec.MoreDataInList() {\n")
+			optional = append(optional, i)
+		}
+		if err := ctx.decode(b, f.typ, lhs+"."+f.name, f.tags); err != nil {
+			return fmt.Errorf("field %s: %v", f.name, err)
+		}
+	}
+	// The innermost block belongs to the last optional field.
+	for i := len(optional) - 1; i >= 0; i-- {
+		fmt.Fprintf(b, "} else {\n")
+		for _, f := range fields[optional[i]:] {
+			fmt.Fprintf(b, "%s.%s = %s\n", lhs, f.name, ctx.zero(f.typ))
+		}
+		fmt.Fprintf(b, "}\n")
+	}
+	fmt.Fprintf(b, "if err := dec.ListEnd(); err != nil {\nreturn err\n}\n")
+	return nil
+}
+
+// decode reads one value into the addressable expression lhs.
+func (ctx *genContext) decode(b *bytes.Buffer, typ types.Type, lhs string, ts tags) error {
+	switch {
+	case isRawValue(typ):
+		ctx.decodeCall(b, "dec.Raw()", lhs, "%s")
+
+	case isBigInt(typ):
+		value := ctx.newTmp()
+		fmt.Fprintf(b, "%s, err := dec.BigInt()\nif err != nil {\nreturn err\n}\n", value)
+		fmt.Fprintf(b, "%s.Set(%s)\n", lhs, value)
+
+	case isPointer(typ) && isBigInt(elem(typ)):
+		ctx.decodeCall(b, "dec.BigInt()", lhs, "%s")
+
+	case isPointer(typ):
+		if ts.nilOK {
+			kind := nilKind(typ, ts)
+			fmt.Fprintf(b, "if _kind, _size, err := dec.Kind(); err != nil {\nreturn err\n}")
+			fmt.Fprintf(b, " else if _kind == %s%s && _size == 0 {\n", ctx.rlp(), kind)
+			if kind == "String" {
+				fmt.Fprintf(b, "if _, err := dec.Bytes(); err != nil {\nreturn err\n}\n")
+			} else {
+				fmt.Fprintf(b, "if _, err := dec.List(); err != nil {\nreturn err\n}\n")
+				fmt.Fprintf(b, "if err := dec.ListEnd(); err != nil {\nreturn err\n}\n")
+			}
+			fmt.Fprintf(b, "%s = nil\n} else {\n", lhs)
+		}
+		value := ctx.newTmp()
+		fmt.Fprintf(b, "var %s %s\n", value, ctx.typeString(elem(typ)))
+		if err := ctx.decode(b, elem(typ), value, tags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = &%s\n", lhs, value)
+		if ts.nilOK {
+			fmt.Fprintf(b, "}\n")
+		}
+
+	case ctx.isDecoder(typ):
+		fmt.Fprintf(b, "if err := %s.DecodeRLP(dec); err != nil {\nreturn err\n}\n", lhs)
+
+	case isUint(typ):
+		method := "Uint64"
+		switch typ.Underlying().(*types.Basic).Kind() {
+		case types.Uint8:
+			method = "Uint8"
+		case types.Uint16:
+			method = "Uint16"
+		case types.Uint32:
+			method = "Uint32"
+		}
+		ctx.decodeCall(b, "dec."+method+"()", lhs, ctx.conversion(typ, types.Typ[basicKind(method)]))
+
+	case isKind(typ, types.Bool):
+		ctx.decodeCall(b, "dec.Bool()", lhs, ctx.conversion(typ, types.Typ[types.Bool]))
+
+	case isKind(typ, types.String):
+		ctx.decodeCall(b, "dec.Bytes()", lhs, ctx.typeString(typ)+"(%s)")
+
+	case isByteSlice(typ):
+		ctx.decodeCall(b, "dec.Bytes()", lhs, "%s")
+
+	case isByteArray(typ):
+		fmt.Fprintf(b, "if err := dec.ReadBytes(%s[:]); err != nil {\nreturn err\n}\n", lhs)
+
+	case isSlice(typ):
+		slice := ctx.newTmp()
+		fmt.Fprintf(b, "%s := make(%s, 0)\n", slice, ctx.typeString(typ))
+		if !ts.tail {
+			fmt.Fprintf(b, "if _, err := dec.List(); err != nil {\nreturn err\n}\n")
+		}
+		fmt.Fprintf(b, "for dec.MoreDataInList() {\n")
+		value := ctx.newTmp()
+		fmt.Fprintf(b, "var %s %s\n", value, ctx.typeString(elem(typ)))
+		if err := ctx.decode(b, elem(typ), value, tags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%s = append(%s, %s)\n}\n", slice, slice, value)
+		if !ts.tail {
+			fmt.Fprintf(b, "if err := dec.ListEnd(); err != nil {\nreturn err\n}\n")
+		}
+		fmt.Fprintf(b, "%s = %s\n", lhs, slice)
+
+	case isList(typ):
+		fmt.Fprintf(b, "if _, err := dec.List(); err != nil {\nreturn err\n}\n")
+		index := ctx.newTmp()
+		fmt.Fprintf(b, "for %s := range %s {\n", index, lhs)
+		if err := ctx.decode(b, elem(typ), lhs+"["+index+"]", tags{}); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "}\n")
+		fmt.Fprintf(b, "if err := dec.ListEnd(); err != nil {\nreturn err\n}\n")
+
+	case isStruct(typ):
+		named, _ := typ.(*types.Named)
+		if named != nil && ctx.inline[named] {
+			return ctx.decodeFallback(b, lhs)
+		}
+		if named != nil {
+			ctx.inline[named] = true
+			defer delete(ctx.inline, named)
+		}
+		return ctx.decodeStruct(b, typ.Underlying().(*types.Struct), lhs)
+
+	case isInterface(typ):
+		return ctx.decodeFallback(b, lhs)
+
+	default:
+		return fmt.Errorf("type %s is not RLP-serializable", typ)
+	}
+	return nil
+}
+
+func (ctx *genContext) decodeFallback(b *bytes.Buffer, lhs string) error {
+	fmt.Fprintf(b, "if err := dec.Decode(&%s); err != nil {\nreturn err\n}\n", lhs)
+	return nil
+}
+
+// decodeCall emits a call of a Stream method returning a value and an
+// error, the value is assigned to lhs through the format conv.
+func (ctx *genContext) decodeCall(b *bytes.Buffer, call, lhs, conv string) {
+	value := ctx.newTmp()
+	fmt.Fprintf(b, "%s, err := %s\nif err != nil {\nreturn err\n}\n", value, call)
+	fmt.Fprintf(b, "%s = %s\n", lhs, fmt.Sprintf(conv, value))
+}
+
+// conversion returns the format converting a value of the basic type from
+// to typ.
+func (ctx *genContext) conversion(typ types.Type, from *types.Basic) string {
+	if types.Identical(typ, from) {
+		return "%s"
+	}
+	return ctx.typeString(typ) + "(%s)"
+}
+
+// zero returns the zero value literal of typ.
+func (ctx *genContext) zero(typ types.Type) string {
+	switch u := typ.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Interface, *types.Chan, *types.Signature:
+		return "nil"
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		default:
+			return "0"
+		}
+	default:
+		return ctx.typeString(typ) + "{}"
+	}
+}
+
+// isEncoder reports whether typ has an EncodeRLP method, or gets one in the
+// file being generated.
+func (ctx *genContext) isEncoder(typ types.Type) bool {
+	return ctx.hasMethod(typ, "EncodeRLP")
+}
+
+// isDecoder reports whether *typ has a DecodeRLP method, or gets one in the
+// file being generated.
+func (ctx *genContext) isDecoder(typ types.Type) bool {
+	return ctx.hasMethod(typ, "DecodeRLP")
+}
+
+func (ctx *genContext) hasMethod(typ types.Type, name string) bool {
+	if named, ok := typ.(*types.Named); ok && ctx.targets[named] {
+		return true
+	}
+	if isPointer(typ) {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(typ), false, nil, name)
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// structFields returns the encoded fields of st and checks their tags the
+// same way package rlp does.
+func structFields(st *types.Struct) ([]field, error) {
+	lastPublic := -1
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Exported() {
+			lastPublic = i
+		}
+	}
+	var (
+		fields      []field
+		anyOptional bool
+	)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		ts, err := parseTag(f, reflect.StructTag(st.Tag(i)), i == lastPublic)
+		if err != nil {
+			return nil, err
+		}
+		if ts.ignored {
+			continue
+		}
+		if ts.optional || ts.tail {
+			anyOptional = true
+		} else if anyOptional {
+			return nil, fmt.Errorf(`field %s needs "optional" tag`, f.Name())
+		}
+		fields = append(fields, field{f.Name(), f.Type(), ts})
+	}
+	return fields, nil
+}
+
+func parseTag(f *types.Var, tag reflect.StructTag, last bool) (tags, error) {
+	var ts tags
+	for _, t := range strings.Split(tag.Get("rlp"), ",") {
+		switch t = strings.TrimSpace(t); t {
+		case "":
+		case "-":
+			ts.ignored = true
+		case "nil", "nilString", "nilList":
+			ts.nilOK = true
+			if !isPointer(f.Type()) {
+				return ts, fmt.Errorf("invalid tag %q on field %s: field is not a pointer", t, f.Name())
+			}
+			switch t {
+			case "nilString":
+				ts.nilKind = "String"
+			case "nilList":
+				ts.nilKind = "List"
+			}
+		case "optional":
+			ts.optional = true
+			if ts.tail {
+				return ts, fmt.Errorf(`invalid tag %q on field %s: also has "tail" tag`, t, f.Name())
+			}
+		case "tail":
+			ts.tail = true
+			if !last {
+				return ts, fmt.Errorf(`invalid tag %q on field %s: must be on last field`, t, f.Name())
+			}
+			if ts.optional {
+				return ts, fmt.Errorf(`invalid tag %q on field %s: also has "optional" tag`, t, f.Name())
+			}
+			if !isSlice(f.Type()) {
+				return ts, fmt.Errorf(`invalid tag %q on field %s: field type is not slice`, t, f.Name())
+			}
+		default:
+			return ts, fmt.Errorf("unknown tag %q on field %s", t, f.Name())
+		}
+	}
+	return ts, nil
+}
+
+// nilKind returns whether the nil pointer typ is written as empty string or
+// empty list.
+func nilKind(typ types.Type, ts tags) string {
+	if ts.nilKind != "" {
+		return ts.nilKind
+	}
+	e := elem(typ)
+	if isUint(e) || isKind(e, types.Bool) || isKind(e, types.String) || isByteSlice(e) || isByteArray(e) {
+		return "String"
+	}
+	return "List"
+}
+
+func elem(typ types.Type) types.Type {
+	switch u := typ.Underlying().(type) {
+	case *types.Pointer:
+		return u.Elem()
+	case *types.Slice:
+		return u.Elem()
+	case *types.Array:
+		return u.Elem()
+	}
+	return nil
+}
+
+func isNamed(typ types.Type, path, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == path && named.Obj().Name() == name
+}
+
+func isBigInt(typ types.Type) bool   { return isNamed(typ, "math/big", "Int") }
+func isRawValue(typ types.Type) bool { return isNamed(typ, rlpPackage, "RawValue") }
+
+func isPointer(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Pointer)
+	return ok
+}
+
+func isSlice(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Slice)
+	return ok
+}
+
+func isList(typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		return true
+	}
+	return false
+}
+
+func isStruct(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Struct)
+	return ok
+}
+
+func isInterface(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Interface)
+	return ok
+}
+
+func isKind(typ types.Type, kind types.BasicKind) bool {
+	b, ok := typ.Underlying().(*types.Basic)
+	return ok && b.Kind() == kind
+}
+
+func isUint(typ types.Type) bool {
+	b, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return false
+	}
+	switch b.Kind() {
+	case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64, types.Uintptr:
+		return true
+	}
+	return false
+}
+
+// isByte reports whether typ is encoded as a byte of a byte string.
+func isByte(typ types.Type) bool {
+	return isKind(typ, types.Uint8) && !hasEncodeRLP(typ)
+}
+
+func hasEncodeRLP(typ types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, false, nil, "EncodeRLP")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+func isByteSlice(typ types.Type) bool { return isSlice(typ) && isByte(elem(typ)) }
+
+func isByteArray(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Array)
+	return ok && isByte(elem(typ))
+}
+
+func basicKind(method string) types.BasicKind {
+	switch method {
+	case "Uint8":
+		return types.Uint8
+	case "Uint16":
+		return types.Uint16
+	case "Uint32":
+		return types.Uint32
+	}
+	return types.Uint64
+}
+
+// convert converts expr of typ to the basic type kind if needed.
+func convert(typ types.Type, kind types.BasicKind, expr string) string {
+	if types.Identical(typ, types.Typ[kind]) {
+		return expr
+	}
+	return types.Typ[kind].Name() + "(" + expr + ")"
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// Each testdata/<name>.in.txt holds a package with a type Test, the code
+// generated for it is compared with testdata/<name>.out.txt.
+var goldenTests = []string{"nil", "ignore", "tail", "optional"}
+
+func TestGolden(t *testing.T) {
+	for _, name := range goldenTests {
+		t.Run(name, func(t *testing.T) {
+			input, err := ioutil.ReadFile(filepath.Join("testdata", name+".in.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkg, err := checkPackage(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg := config{types: []string{"Test"}, encoder: true, decoder: true}
+			output, err := cfg.process(pkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".out.txt")
+			if *update {
+				if err := ioutil.WriteFile(golden, output, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(output, want) {
+				t.Errorf("output mismatch, want:\n%s\ngot:\n%s", want, output)
+			}
+		})
+	}
+}
+
+func TestInvalidTags(t *testing.T) {
+	for _, src := range []string{
+		"package test\ntype Test struct {\n\tA uint64 `rlp:\"optional\"`\n\tB uint64\n}\n",
+		"package test\ntype Test struct {\n\tA []uint64 `rlp:\"tail\"`\n\tB uint64\n}\n",
+		"package test\ntype Test struct {\n\tA uint64 `rlp:\"nil\"`\n}\n",
+	} {
+		pkg, err := checkPackage([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := config{types: []string{"Test"}, encoder: true, decoder: true}
+		if _, err := cfg.process(pkg); err == nil {
+			t.Errorf("expected an error for\n%s", src)
+		}
+	}
+}
+
+func checkPackage(src []byte) (*types.Package, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "input.go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return conf.Check("test", fset, []*ast.File{f}, nil)
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// rlpgen generates EncodeRLP and DecodeRLP methods for struct types. The
+// generated methods write and read the fields directly and produce exactly the
+// same encoding as the reflection based encoder in package rlp, honouring the
+// "nil", "optional", "tail" and "-" struct tags.
+//
+// Usage, typically from a go:generate directive in the package of the type:
+//
+//	rlpgen -type Header -out gen_header_rlp.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		dir     = flag.String("dir", ".", "package directory containing the types")
+		typ     = flag.String("type", "", "comma separated struct types to generate methods for")
+		output  = flag.String("out", "", "output file (default is stdout)")
+		encoder = flag.Bool("encoder", true, "generate EncodeRLP")
+		decoder = flag.Bool("decoder", true, "generate DecodeRLP")
+	)
+	flag.Parse()
+	if *typ == "" {
+		fatal(errors.New("-type is required"))
+	}
+
+	pkg, err := loadPackage(*dir, *output)
+	if err != nil {
+		fatal(err)
+	}
+	cfg := config{
+		types:   strings.Split(*typ, ","),
+		encoder: *encoder,
+		decoder: *decoder,
+	}
+	code, err := cfg.process(pkg)
+	if err != nil {
+		fatal(err)
+	}
+	if *output == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := ioutil.WriteFile(*output, code, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "rlpgen:", err)
+	os.Exit(1)
+}
+
+// loadPackage parses and type-checks the package in dir. The output file is
+// left out so that regenerating does not depend on the previous result.
+func loadPackage(dir, output string) (*types.Package, error) {
+	bpkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	var skip string
+	if output != "" {
+		skip = filepath.Base(output)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bpkg.GoFiles {
+		if name == skip {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	path := bpkg.ImportPath
+	if path == "" || path == "." {
+		path = bpkg.Name
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		// Other files of the package may use the methods of the file being
+		// regenerated, the declarations needed here are still resolved.
+		Error: func(error) {},
+	}
+	pkg, err := conf.Check(path, fset, files, nil)
+	if pkg == nil {
+		return nil, fmt.Errorf("can't type-check %s: %v", dir, err)
+	}
+	return pkg, nil
+}
+
+type config struct {
+	types   []string
+	encoder bool
+	decoder bool
+}
+
+// process generates the methods of all configured types into one file.
+func (cfg *config) process(pkg *types.Package) ([]byte, error) {
+	var targets []*types.Named
+	for _, name := range cfg.types {
+		name = strings.TrimSpace(name)
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("no type %s in package %s", name, pkg.Path())
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a named type", name)
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			return nil, fmt.Errorf("%s is not a struct type", name)
+		}
+		targets = append(targets, named)
+	}
+
+	ctx := newGenContext(pkg, targets)
+	var body bytes.Buffer
+	for _, named := range targets {
+		if cfg.encoder {
+			if err := ctx.genEncodeRLP(&body, named); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.decoder {
+			if err := ctx.genDecodeRLP(&body, named); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ctx.file(body.Bytes())
+}
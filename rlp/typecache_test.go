@@ -0,0 +1,65 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rlp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvalidOptionalTags(t *testing.T) {
+	type missingOptional struct {
+		A uint `rlp:"optional"`
+		B uint
+	}
+	type optionalAndTail struct {
+		A []uint `rlp:"optional,tail"`
+	}
+	type tailAndOptional struct {
+		A []uint `rlp:"tail,optional"`
+	}
+	tests := []struct {
+		val interface{}
+		err string
+	}{
+		{missingOptional{}, `rlp: struct field rlp.missingOptional.B needs "optional" tag`},
+		{optionalAndTail{}, `rlp: invalid struct tag "tail" for rlp.optionalAndTail.A (also has "optional" tag)`},
+		{tailAndOptional{}, `rlp: invalid struct tag "optional" for rlp.tailAndOptional.A (also has "tail" tag)`},
+	}
+	for _, test := range tests {
+		typ := reflect.TypeOf(test.val)
+		if _, err := structFields(typ); err == nil || err.Error() != test.err {
+			t.Errorf("%v: got error %v, want %q", typ, err, test.err)
+		}
+		if _, err := EncodeToBytes(test.val); err == nil {
+			t.Errorf("%v: encoding did not fail", typ)
+		}
+		if err := DecodeBytes([]byte{0xc0}, reflect.New(typ).Interface()); err == nil {
+			t.Errorf("%v: decoding did not fail", typ)
+		}
+	}
+
+	// Ignored fields don't need the tag.
+	type ignoredAfterOptional struct {
+		A uint `rlp:"optional"`
+		B uint `rlp:"-"`
+	}
+	fields, err := structFields(reflect.TypeOf(ignoredAfterOptional{}))
+	if err != nil || len(fields) != 1 || !fields[0].optional {
+		t.Errorf("got fields %+v, %v", fields, err)
+	}
+}
@@ -76,12 +76,17 @@ type Client struct {
 	isHTTP   bool
 	isTLS    bool //wsw add
 	services *serviceRegistry
+	connCtx  context.Context // base context of the handlers, carries the server policy
 
 	idCounter uint32
 
 	// This function, if non-nil, is called when the connection is lost.
 	reconnectFunc reconnectFunc
 
+	// interceptor chains installed by Use
+	interceptorList []Interceptor
+	interceptors    interceptorChain
+
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
 	// only be accessed outside of dispatch, with the write lock held. The write lock is
 	// taken by sending on requestOp and released by sending on sendDone.
@@ -109,7 +114,7 @@ type clientConn struct {
 }
 
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
-	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
+	ctx := context.WithValue(c.connCtx, clientContextKey{}, c)
 	handler := newHandler(ctx, conn, c.idgen, c.services)
 	return &clientConn{conn, handler}
 }
@@ -149,10 +154,11 @@ func (op *requestOp) wait(ctx context.Context, c *Client) (*jsonrpcMessage, erro
 
 // Dial creates a new client for the given URL.
 //
-// The currently supported URL schemes are "http", "https", "ws" and "wss". If rawurl is a
-// file name with no URL scheme, a local socket connection is established using UNIX
-// domain sockets on supported platforms and named pipes on Windows. If you want to
-// configure transport options, use DialHTTP, DialWebsocket or DialIPC instead.
+// The currently supported URL schemes are "http", "ws", "ipc" (or "unix") and "inproc".
+// If rawurl is a file name with no URL scheme, a local socket connection is established
+// using UNIX domain sockets on supported platforms. "inproc://<name>" connects to a
+// server registered with RegisterInProc. If you want to configure transport options,
+// use DialHTTP, DialWebsocket or DialIPC instead.
 //
 // For websocket connections, the origin is set to the local host name.
 //
@@ -176,6 +182,12 @@ func DialContext(ctx context.Context, rawurl string, certFile string, keyFile st
 		return DialHTTP(rawurl)
 	case "ws":
 		return DialWebsocket(ctx, rawurl, "")
+	case "ipc", "unix":
+		return DialIPC(ctx, u.Host+u.Path)
+	case "inproc":
+		return dialInProcName(u.Host + u.Path)
+	case "":
+		return DialIPC(ctx, rawurl)
 	default:
 		return nil, fmt.Errorf("no known transport for URL scheme %q", u.Scheme)
 	}
@@ -186,12 +198,12 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(context.Background(), conn, randomIDGenerator(), new(serviceRegistry))
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(connCtx context.Context, conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
 	var isHTTP bool
 	var isTLS bool
 	switch conn.(type) {
@@ -199,6 +211,7 @@ func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *C
 		_, isHTTP = conn.(*httpConn)
 	}
 	c := &Client{
+		connCtx:     connCtx,
 		idgen:       idgen,
 		isHTTP:      isHTTP,
 		isTLS:       isTLS,
@@ -271,6 +284,13 @@ func (c *Client) Call(result interface{}, method string, args ...interface{}) er
 // The result must be a pointer so that package json can unmarshal into it. You
 // can also pass nil, in which case the result is ignored.
 func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if c.interceptors.call != nil {
+		return c.interceptors.call(ctx, result, method, args...)
+	}
+	return c.callContext(ctx, result, method, args...)
+}
+
+func (c *Client) callContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
 	msg, err := c.newMessage(method, args...)
 	if err != nil {
 		return err
@@ -321,6 +341,13 @@ func (c *Client) BatchCall(b []BatchElem) error {
 //
 // Note that batch calls may not be executed atomically on the server side.
 func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
+	if c.interceptors.batch != nil {
+		return c.interceptors.batch(ctx, b)
+	}
+	return c.batchCallContext(ctx, b)
+}
+
+func (c *Client) batchCallContext(ctx context.Context, b []BatchElem) error {
 	msgs := make([]*jsonrpcMessage, len(b))
 	op := &requestOp{
 		ids:  make([]json.RawMessage, len(b)),
@@ -411,6 +438,13 @@ func (c *Client) ShhSubscribe(ctx context.Context, channel interface{}, args ...
 // ErrSubscriptionQueueOverflow. Use a sufficiently large buffer on the channel or ensure
 // that the channel usually has at least one reader to prevent this issue.
 func (c *Client) Subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*ClientSubscription, error) {
+	if c.interceptors.subscribe != nil {
+		return c.interceptors.subscribe(ctx, namespace, channel, args...)
+	}
+	return c.subscribe(ctx, namespace, channel, args...)
+}
+
+func (c *Client) subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*ClientSubscription, error) {
 	// Check type of channel first.
 	chanVal := reflect.ValueOf(channel)
 	if chanVal.Kind() != reflect.Chan || chanVal.Type().ChanDir()&reflect.SendDir == 0 {
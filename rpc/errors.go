@@ -63,3 +63,33 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// request rejected by the authenticator of the server
+type unauthorizedError struct{ err error }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return "unauthorized: " + e.err.Error() }
+
+// method execution exceeded the configured timeout
+type timeoutError struct{ method string }
+
+func (e *timeoutError) ErrorCode() int { return -32002 }
+
+func (e *timeoutError) Error() string { return fmt.Sprintf("request timed out: %s", e.method) }
+
+// encoded result exceeds the configured response size
+type responseTooLargeError struct{ size, limit int }
+
+func (e *responseTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response too large (%d>%d)", e.size, e.limit)
+}
+
+// too many requests from one client
+type rateLimitError struct{ key string }
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string { return "rate limit exceeded for " + e.key }
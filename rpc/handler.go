@@ -60,6 +60,7 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            *flogging.FabricLogger
 	allowSubscribe bool
+	policy         *serverPolicy // limits and middleware of the server, nil for clients
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -84,6 +85,7 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		serverSubs:     make(map[ID]*Subscription),
 		log:            flogging.MustGetLogger("rpc"),
 	}
+	h.policy, _ = connCtx.Value(serverPolicyKey{}).(*serverPolicy)
 	if conn.remoteAddr() != "" {
 		h.log = flogging.MustGetLogger("rpc")
 	}
@@ -100,6 +102,23 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		})
 		return
 	}
+	// Reject every call of oversized batches, so that clients waiting for
+	// the individual responses are answered:
+	if err := h.policy.checkBatch(len(msgs)); err != nil {
+		h.startCallProc(func(cp *callProc) {
+			answers := make([]*jsonrpcMessage, 0, len(msgs))
+			for _, msg := range msgs {
+				if msg.isCall() {
+					answers = append(answers, msg.errorResponse(err))
+				}
+			}
+			if len(answers) == 0 {
+				answers = append(answers, errorMessage(err))
+			}
+			h.conn.writeJSON(cp.ctx, answers)
+		})
+		return
+	}
 
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
@@ -199,6 +218,7 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 	for _, n := range nn {
 		if sub := n.takeSubscription(); sub != nil {
 			h.serverSubs[sub.ID] = sub
+			h.policy.callMetrics().subscriptions.With("namespace", sub.namespace).Add(1)
 		}
 	}
 }
@@ -212,6 +232,7 @@ func (h *handler) cancelServerSubscriptions(err error) {
 		s.err <- err
 		close(s.err)
 		delete(h.serverSubs, id)
+		h.policy.callMetrics().subscriptions.With("namespace", s.namespace).Add(-1)
 	}
 }
 
@@ -288,13 +309,24 @@ func (h *handler) handleResponse(msg *jsonrpcMessage) {
 // handleCallMsg executes a call message and returns the answer.
 func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
 	start := time.Now()
+	metrics := h.policy.callMetrics()
 	switch {
 	case msg.isNotification():
+		metrics.inflight.Add(1)
 		h.handleCall(ctx, msg)
+		metrics.inflight.Add(-1)
+		metrics.observe(h.methodLabel(msg), time.Since(start), "")
 		h.log.Debug("Served "+msg.Method, "t", time.Since(start))
 		return nil
 	case msg.isCall():
+		metrics.inflight.Add(1)
 		resp := h.handleCall(ctx, msg)
+		metrics.inflight.Add(-1)
+		code := ""
+		if resp.Error != nil {
+			code = strconv.Itoa(resp.Error.Code)
+		}
+		metrics.observe(h.methodLabel(msg), time.Since(start), code)
 		if resp.Error != nil {
 			h.log.Warn("Served "+msg.Method, "reqid", idForLog{msg.ID}, "t", time.Since(start), "err", resp.Error.Message)
 		} else {
@@ -308,8 +340,26 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 	}
 }
 
+// methodLabel returns the metrics label of a call. Methods that are not registered
+// share one label, so that clients can't create an unbounded number of series.
+func (h *handler) methodLabel(msg *jsonrpcMessage) string {
+	if h.reg.callback(msg.Method) != nil {
+		return msg.Method
+	}
+	if (msg.isSubscribe() || msg.isUnsubscribe()) && h.reg.hasService(msg.namespace()) {
+		return msg.Method
+	}
+	if h.reg.isFallbackMethod(msg.Method) {
+		return msg.Method
+	}
+	return unknownMethodLabel
+}
+
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
+	if err := h.policy.checkCall(cp.ctx, msg.Method); err != nil {
+		return msg.errorResponse(err)
+	}
 	if msg.isSubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
@@ -320,13 +370,18 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		callb = h.reg.callback(msg.Method)
 	}
 	if callb == nil {
+		if fallback := h.reg.fallbackFunc(); fallback != nil && !msg.isUnsubscribe() {
+			return h.runFallback(cp.ctx, msg, fallback)
+		}
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
-
+	if timeout := h.policy.timeout(msg.Method); timeout > 0 {
+		return h.runMethodTimeout(cp.ctx, msg, callb, args, timeout)
+	}
 	return h.runMethod(cp.ctx, msg, callb, args)
 }
 
@@ -369,7 +424,53 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 	if err != nil {
 		return msg.errorResponse(err)
 	}
-	return msg.response(result)
+	resp := msg.response(result)
+	if err := h.policy.checkResponse(len(resp.Result)); err != nil {
+		return msg.errorResponse(err)
+	}
+	return resp
+}
+
+// runFallback passes a call of an unknown method to the fallback handler.
+func (h *handler) runFallback(ctx context.Context, msg *jsonrpcMessage, fallback FallbackFunc) *jsonrpcMessage {
+	if timeout := h.policy.timeout(msg.Method); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result, err := fallback(ctx, msg.Method, msg.Params)
+	if err == context.DeadlineExceeded {
+		err = &timeoutError{msg.Method}
+	}
+	if err != nil {
+		return msg.errorResponse(err)
+	}
+	if err := h.policy.checkResponse(len(result)); err != nil {
+		return msg.errorResponse(err)
+	}
+	if len(result) == 0 {
+		result = null
+	}
+	return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+}
+
+// runMethodTimeout runs the callback with a deadline. The callback is abandoned
+// when it does not return in time, its context is canceled.
+func (h *handler) runMethodTimeout(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value, timeout time.Duration) *jsonrpcMessage {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan *jsonrpcMessage, 1)
+	go func() { done <- h.runMethod(ctx, msg, callb, args) }()
+	select {
+	case resp := <-done:
+		return resp
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return msg.errorResponse(&timeoutError{msg.Method})
+		}
+		return msg.errorResponse(ctx.Err())
+	}
 }
 
 // unsubscribe is the callback function for all *_unsubscribe calls.
@@ -383,6 +484,7 @@ func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	}
 	close(s.err)
 	delete(h.serverSubs, id)
+	h.policy.callMetrics().subscriptions.With("namespace", s.namespace).Add(-1)
 	return true, nil
 }
 
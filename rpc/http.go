@@ -48,8 +48,12 @@ var acceptedContentTypes = []string{contentType, "application/json-rpc", "applic
 type httpConn struct {
 	client    *http.Client
 	req       *http.Request
+	auth      HTTPAuth
 	closeOnce sync.Once
 	closeCh   chan interface{}
+
+	mu      sync.Mutex // protects headers
+	headers http.Header
 }
 
 // httpConn is treated specially by Client.
@@ -108,7 +112,7 @@ var DefaultHTTPTimeouts = HTTPTimeouts{
 
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
-func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
+func DialHTTPWithClient(endpoint string, client *http.Client, opts ...HTTPOption) (*Client, error) {
 	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -116,12 +120,82 @@ func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", contentType)
 
+	hc := &httpConn{client: client, req: req, headers: make(http.Header), closeCh: make(chan interface{})}
+	for _, opt := range opts {
+		opt(hc)
+	}
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (ServerCodec, error) {
-		return &httpConn{client: client, req: req, closeCh: make(chan interface{})}, nil
+		return hc, nil
 	})
 }
 
+// HTTPAuth sets the authentication headers of an outgoing HTTP request. It is
+// called once per request so that short-lived tokens can be refreshed.
+type HTTPAuth func(h http.Header) error
+
+// HTTPOption configures a client created by DialHTTPWithClient.
+type HTTPOption func(*httpConn)
+
+// WithHeader sets a header sent with every request.
+func WithHeader(key, value string) HTTPOption {
+	return func(hc *httpConn) {
+		hc.headers.Set(key, value)
+	}
+}
+
+// WithHeaders sets headers sent with every request.
+func WithHeaders(h http.Header) HTTPOption {
+	return func(hc *httpConn) {
+		copyHeader(hc.headers, h)
+	}
+}
+
+// WithHTTPAuth installs a function computing the authentication headers.
+func WithHTTPAuth(auth HTTPAuth) HTTPOption {
+	return func(hc *httpConn) {
+		hc.auth = auth
+	}
+}
+
+// WithBearerToken authenticates every request with a static bearer token.
+func WithBearerToken(token string) HTTPOption {
+	return WithHTTPAuth(func(h http.Header) error {
+		h.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// WithJWTAuth authenticates every request with a freshly issued HS256 token,
+// see NewJWTToken.
+func WithJWTAuth(secret []byte) HTTPOption {
+	return WithHTTPAuth(NewJWTAuth(secret))
+}
+
+// NewJWTAuth returns an HTTPAuth issuing a new HS256 bearer token per request.
+func NewJWTAuth(secret []byte) HTTPAuth {
+	return func(h http.Header) error {
+		token, err := NewJWTToken(secret)
+		if err != nil {
+			return err
+		}
+		h.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// SetHeader sets a header sent with every subsequent request. It only has an
+// effect on HTTP clients.
+func (c *Client) SetHeader(key, value string) {
+	hc, ok := c.writeConn.(*httpConn)
+	if !ok {
+		return
+	}
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.headers.Set(key, value)
+}
+
 // DialHTTP creates a new RPC client that connects to an RPC server over HTTP.
 func DialHTTP(endpoint string) (*Client, error) {
 	return DialHTTPWithClient(endpoint, new(http.Client))
@@ -177,6 +251,19 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	// WithContext shares the header map with hc.req, build a new one.
+	req.Header = make(http.Header)
+	copyHeader(req.Header, hc.req.Header)
+	hc.mu.Lock()
+	copyHeader(req.Header, hc.headers)
+	hc.mu.Unlock()
+	copyHeader(req.Header, headersFromContext(ctx))
+	if hc.auth != nil {
+		if err := hc.auth(req.Header); err != nil {
+			return nil, err
+		}
+	}
+
 	resp, err := hc.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -194,8 +281,8 @@ type httpServerConn struct {
 	r *http.Request
 }
 
-func newHTTPServerConn(r *http.Request, w http.ResponseWriter) ServerCodec {
-	body := io.LimitReader(r.Body, maxRequestContentLength)
+func newHTTPServerConn(r *http.Request, w http.ResponseWriter, maxSize int64) ServerCodec {
+	body := io.LimitReader(r.Body, maxSize)
 	conn := &httpServerConn{Reader: body, Writer: w, r: r}
 	return NewCodec(conn)
 }
@@ -248,14 +335,19 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet && r.ContentLength == 0 && r.URL.RawQuery == "" {
 		return
 	}
-	if code, err := validateRequest(r); err != nil {
-		http.Error(w, err.Error(), code)
+	if code, err := validateRequest(r, s.policy.maxRequestSize()); err != nil {
+		writeHTTPError(w, code, &invalidRequestError{err.Error()})
+		return
+	}
+	peer := newHTTPPeerInfo("http", r)
+	if err := s.policy.authenticate(peer); err != nil {
+		writeHTTPError(w, http.StatusUnauthorized, err)
 		return
 	}
 	// All checks passed, create a codec that reads direct from the request body
 	// untilEOF and writes the response to w and order the server to process a
 	// single request.
-	ctx := r.Context()
+	ctx := s.connContext(r.Context(), peer)
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
@@ -267,19 +359,26 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("content-type", contentType)
-	codec := newHTTPServerConn(r, w)
+	codec := newHTTPServerConn(r, w, s.policy.maxRequestSize())
 	defer codec.close()
 	s.serveSingleRequest(ctx, codec)
 }
 
+// writeHTTPError rejects an HTTP request with a JSON-RPC error response.
+func writeHTTPError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("content-type", contentType)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorMessage(err))
+}
+
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
-func validateRequest(r *http.Request) (int, error) {
+func validateRequest(r *http.Request, maxSize int64) (int, error) {
 	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
 		return http.StatusMethodNotAllowed, errors.New("method not allowed")
 	}
-	if r.ContentLength > maxRequestContentLength {
-		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxRequestContentLength)
+	if r.ContentLength > maxSize {
+		err := fmt.Errorf("content length too large (%d>%d)", r.ContentLength, maxSize)
 		return http.StatusRequestEntityTooLarge, err
 	}
 	// Allow OPTIONS (regardless of content-type)
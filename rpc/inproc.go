@@ -0,0 +1,61 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// DialInProc attaches an in-process connection to the given RPC server.
+func DialInProc(handler *Server) *Client {
+	initctx := context.Background()
+	c, _ := newClient(initctx, func(context.Context) (ServerCodec, error) {
+		p1, p2 := net.Pipe()
+		go handler.serveCodec(handler.connContext(context.Background(), PeerInfo{Transport: "inproc"}), NewCodec(p1))
+		return NewCodec(p2), nil
+	})
+	return c
+}
+
+var (
+	inprocMu      sync.Mutex
+	inprocServers = make(map[string]*Server)
+)
+
+// RegisterInProc makes srv reachable through DialContext with the URL
+// "inproc://<name>". Registering a nil server removes the name.
+func RegisterInProc(name string, srv *Server) {
+	inprocMu.Lock()
+	defer inprocMu.Unlock()
+	if srv == nil {
+		delete(inprocServers, name)
+		return
+	}
+	inprocServers[name] = srv
+}
+
+func dialInProcName(name string) (*Client, error) {
+	inprocMu.Lock()
+	srv := inprocServers[name]
+	inprocMu.Unlock()
+	if srv == nil {
+		return nil, fmt.Errorf("no in-process server registered as %q", name)
+	}
+	return DialInProc(srv), nil
+}
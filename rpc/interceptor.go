@@ -0,0 +1,266 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ethclient/common/flogging"
+	"go.uber.org/zap/zapcore"
+)
+
+// CallInvoker performs a call, either on the connection or by invoking the next
+// interceptor of the chain.
+type CallInvoker func(ctx context.Context, result interface{}, method string, args ...interface{}) error
+
+// BatchInvoker performs a batch call.
+type BatchInvoker func(ctx context.Context, b []BatchElem) error
+
+// SubscribeInvoker establishes a subscription.
+type SubscribeInvoker func(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*ClientSubscription, error)
+
+// Interceptor wraps the calls, batch calls and subscriptions made through a
+// Client. Each hook may modify the request and the context, must call the
+// given invoker to continue the chain and may inspect or replace the
+// outcome. Nil hooks are skipped.
+type Interceptor struct {
+	Call      func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error
+	Batch     func(ctx context.Context, b []BatchElem, invoke BatchInvoker) error
+	Subscribe func(ctx context.Context, namespace string, channel interface{}, args []interface{}, invoke SubscribeInvoker) (*ClientSubscription, error)
+}
+
+type interceptorChain struct {
+	call      CallInvoker
+	batch     BatchInvoker
+	subscribe SubscribeInvoker
+}
+
+// Use appends interceptors to the chain of the client. The first interceptor
+// added is the outermost one. Use is not safe for concurrent use with calls on
+// the client and should be called right after dialing.
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.interceptorList = append(c.interceptorList, interceptors...)
+
+	call, batch, subscribe := CallInvoker(c.callContext), BatchInvoker(c.batchCallContext), SubscribeInvoker(c.subscribe)
+	for i := len(c.interceptorList) - 1; i >= 0; i-- {
+		ic := c.interceptorList[i]
+		if ic.Call != nil {
+			next := call
+			call = func(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+				return ic.Call(ctx, result, method, args, next)
+			}
+		}
+		if ic.Batch != nil {
+			next := batch
+			batch = func(ctx context.Context, b []BatchElem) error {
+				return ic.Batch(ctx, b, next)
+			}
+		}
+		if ic.Subscribe != nil {
+			next := subscribe
+			subscribe = func(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*ClientSubscription, error) {
+				return ic.Subscribe(ctx, namespace, channel, args, next)
+			}
+		}
+	}
+	c.interceptors = interceptorChain{call: call, batch: batch, subscribe: subscribe}
+}
+
+type headersKey struct{}
+
+// NewContextWithHeaders returns a context that carries extra HTTP headers for
+// the requests made with it. Headers already present in ctx are kept unless
+// overridden. The headers are ignored by non-HTTP transports.
+func NewContextWithHeaders(ctx context.Context, h http.Header) context.Context {
+	merged := make(http.Header)
+	copyHeader(merged, headersFromContext(ctx))
+	copyHeader(merged, h)
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+func headersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(headersKey{}).(http.Header)
+	return h
+}
+
+// copyHeader sets all values of src in dst, replacing existing values.
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		dst[k] = append([]string(nil), v...)
+	}
+}
+
+// HeaderInterceptor adds fixed HTTP headers to every request.
+func HeaderInterceptor(h http.Header) Interceptor {
+	return Interceptor{
+		Call: func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error {
+			return invoke(NewContextWithHeaders(ctx, h), result, method, args...)
+		},
+		Batch: func(ctx context.Context, b []BatchElem, invoke BatchInvoker) error {
+			return invoke(NewContextWithHeaders(ctx, h), b)
+		},
+	}
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID assigned by RequestIDInterceptor.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDInterceptor assigns a random ID to every call and batch and sends it
+// in the given HTTP header, "X-Request-Id" if empty. Interceptors further down
+// the chain can read the ID with RequestIDFromContext.
+func RequestIDInterceptor(header string) Interceptor {
+	if header == "" {
+		header = "X-Request-Id"
+	}
+	withID := func(ctx context.Context) context.Context {
+		id := RequestIDFromContext(ctx)
+		if id == "" {
+			var b [8]byte
+			rand.Read(b[:])
+			id = hex.EncodeToString(b[:])
+			ctx = context.WithValue(ctx, requestIDKey{}, id)
+		}
+		h := make(http.Header)
+		h.Set(header, id)
+		return NewContextWithHeaders(ctx, h)
+	}
+	return Interceptor{
+		Call: func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error {
+			return invoke(withID(ctx), result, method, args...)
+		},
+		Batch: func(ctx context.Context, b []BatchElem, invoke BatchInvoker) error {
+			return invoke(withID(ctx), b)
+		},
+		Subscribe: func(ctx context.Context, namespace string, channel interface{}, args []interface{}, invoke SubscribeInvoker) (*ClientSubscription, error) {
+			return invoke(withID(ctx), namespace, channel, args...)
+		},
+	}
+}
+
+// DefaultRedactedParams lists the positions of the secret parameters of the
+// personal and clef namespaces.
+var DefaultRedactedParams = map[string][]int{
+	"personal_newAccount":      {0},
+	"personal_importRawKey":    {0, 1},
+	"personal_unlockAccount":   {1},
+	"personal_sendTransaction": {1},
+	"personal_signTransaction": {1},
+	"personal_sign":            {2},
+	"personal_openWallet":      {1},
+}
+
+const redacted = "<redacted>"
+
+// LoggingInterceptor logs the method, latency, payload sizes and outcome of
+// every call at debug level and failures at warn level. Parameters listed in
+// redact, by method name and position, are replaced before logging.
+func LoggingInterceptor(logger *flogging.FabricLogger, redact map[string][]int) Interceptor {
+	return Interceptor{
+		Call: func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error {
+			start := time.Now()
+			err := invoke(ctx, result, method, args...)
+			kv := []interface{}{"method", method, "elapsed", time.Since(start)}
+			if id := RequestIDFromContext(ctx); id != "" {
+				kv = append(kv, "reqid", id)
+			}
+			if err != nil {
+				logger.Warnw("RPC call failed", append(kv, "params", redactParams(redact, method, args), "err", err)...)
+				return err
+			}
+			if logger.IsEnabledFor(zapcore.DebugLevel) {
+				kv = append(kv, "params", redactParams(redact, method, args), "reqsize", jsonSize(args), "respsize", jsonSize(result))
+				logger.Debugw("RPC call", kv...)
+			}
+			return nil
+		},
+		Batch: func(ctx context.Context, b []BatchElem, invoke BatchInvoker) error {
+			start := time.Now()
+			err := invoke(ctx, b)
+			var failed int
+			for _, elem := range b {
+				if elem.Error != nil {
+					failed++
+				}
+			}
+			kv := []interface{}{"size", len(b), "failed", failed, "elapsed", time.Since(start)}
+			if id := RequestIDFromContext(ctx); id != "" {
+				kv = append(kv, "reqid", id)
+			}
+			if err != nil {
+				logger.Warnw("RPC batch failed", append(kv, "err", err)...)
+				return err
+			}
+			if logger.IsEnabledFor(zapcore.DebugLevel) {
+				methods := make([]string, len(b))
+				for i, elem := range b {
+					methods[i] = elem.Method
+				}
+				logger.Debugw("RPC batch", append(kv, "methods", methods)...)
+			}
+			return nil
+		},
+		Subscribe: func(ctx context.Context, namespace string, channel interface{}, args []interface{}, invoke SubscribeInvoker) (*ClientSubscription, error) {
+			start := time.Now()
+			sub, err := invoke(ctx, namespace, channel, args...)
+			method := namespace + subscribeMethodSuffix
+			if err != nil {
+				logger.Warnw("RPC subscribe failed", "method", method, "params", redactParams(redact, method, args), "elapsed", time.Since(start), "err", err)
+				return nil, err
+			}
+			logger.Debugw("RPC subscribe", "method", method, "params", redactParams(redact, method, args), "elapsed", time.Since(start))
+			return sub, nil
+		},
+	}
+}
+
+// redactParams returns a copy of args with the redacted positions of method
+// replaced.
+func redactParams(redact map[string][]int, method string, args []interface{}) []interface{} {
+	positions, ok := redact[method]
+	if !ok {
+		return args
+	}
+	out := make([]interface{}, len(args))
+	copy(out, args)
+	for _, i := range positions {
+		if i < len(out) {
+			out[i] = redacted
+		}
+	}
+	return out
+}
+
+func jsonSize(v interface{}) int {
+	if raw, ok := v.(*json.RawMessage); ok && raw != nil {
+		return len(*raw)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return -1
+	}
+	return len(b)
+}
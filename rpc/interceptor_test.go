@@ -0,0 +1,140 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInterceptorOrder(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var trace []string
+	tracer := func(name string) Interceptor {
+		return Interceptor{
+			Call: func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error {
+				trace = append(trace, name+" "+method)
+				err := invoke(ctx, result, method, args...)
+				trace = append(trace, name+" done")
+				return err
+			},
+		}
+	}
+	client.Use(tracer("a"))
+	client.Use(tracer("b"), Interceptor{
+		Call: func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error {
+			return invoke(ctx, result, method, "rewritten")
+		},
+	})
+	var result string
+	if err := client.Call(&result, "test_echo", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if result != "rewritten" {
+		t.Fatalf("result %q, want rewritten", result)
+	}
+	want := []string{"a test_echo", "b test_echo", "b done", "a done"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Fatalf("trace %v, want %v", trace, want)
+	}
+}
+
+func TestHTTPHeaders(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Stop()
+	secret := []byte("secret")
+
+	var (
+		mu      sync.Mutex
+		headers []http.Header
+	)
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header)
+		mu.Unlock()
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") || VerifyJWTToken(secret, strings.TrimPrefix(auth, "Bearer ")) != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+
+	client, err := DialHTTPWithClient(httpsrv.URL, new(http.Client), WithHeader("X-Gateway", "gw"), WithJWTAuth(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Use(RequestIDInterceptor(""))
+	client.SetHeader("X-Extra", "1")
+
+	ctx := NewContextWithHeaders(context.Background(), http.Header{"X-Call": {"c"}})
+	var result string
+	if err := client.CallContext(ctx, &result, "test_echo", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if result != "hello" {
+		t.Fatalf("result %q", result)
+	}
+	if err := client.Call(&result, "test_echo", "again"); err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 2 {
+		t.Fatalf("%d requests", len(headers))
+	}
+	for i, h := range headers {
+		if h.Get("X-Gateway") != "gw" || h.Get("X-Extra") != "1" || h.Get("X-Request-Id") == "" {
+			t.Errorf("request %d: missing headers %v", i, h)
+		}
+	}
+	if headers[0].Get("X-Call") != "c" || headers[1].Get("X-Call") != "" {
+		t.Errorf("context header leaked: %v", headers)
+	}
+	if headers[0].Get("X-Request-Id") == headers[1].Get("X-Request-Id") {
+		t.Error("request ids are not unique")
+	}
+
+	bad, err := DialHTTPWithClient(httpsrv.URL, new(http.Client), WithJWTAuth([]byte("wrong")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if err := bad.Call(&result, "test_echo", "hello"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected 401, got %v", err)
+	}
+}
+
+func TestRedactParams(t *testing.T) {
+	args := []interface{}{"0xabc", "password", 300}
+	out := redactParams(DefaultRedactedParams, "personal_unlockAccount", args)
+	if out[1] != redacted || args[1] != "password" || out[0] != "0xabc" {
+		t.Fatalf("redacted %v, args %v", out, args)
+	}
+	if out := redactParams(DefaultRedactedParams, "eth_call", args); out[1] != "password" {
+		t.Fatalf("unexpected redaction %v", out)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"context"
+	"net"
+)
+
+// ServeListener accepts connections on l, serving JSON-RPC on them.
+func (s *Server) ServeListener(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if isTemporaryError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		log.Debugw("Accepted RPC connection", "conn", conn.RemoteAddr())
+		go s.serveCodec(s.connContext(context.Background(), PeerInfo{Transport: "ipc"}), NewCodec(conn))
+	}
+}
+
+// DialIPC create a new IPC client that connects to the given endpoint. On Unix it assumes
+// the endpoint is the full path to a unix socket.
+//
+// The context is used for the initial connection establishment. It does not
+// affect subsequent interactions with the client.
+func DialIPC(ctx context.Context, endpoint string) (*Client, error) {
+	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
+		conn, err := newIPCConnection(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return NewCodec(conn), err
+	})
+}
+
+// StartIPCEndpoint starts an IPC endpoint serving the given server.
+func StartIPCEndpoint(ipcEndpoint string, srv *Server) (net.Listener, error) {
+	listener, err := ipcListen(ipcEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	go srv.ServeListener(listener)
+	return listener, nil
+}
+
+func isTemporaryError(err error) bool {
+	tempErr, ok := err.(interface {
+		Temporary() bool
+	})
+	return ok && tempErr.Temporary()
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+var errIPCNotSupported = errors.New("rpc: IPC is only supported on unix platforms")
+
+// ipcListen is not supported on this platform.
+func ipcListen(endpoint string) (net.Listener, error) {
+	return nil, errIPCNotSupported
+}
+
+// newIPCConnection is not supported on this platform.
+func newIPCConnection(ctx context.Context, endpoint string) (net.Conn, error) {
+	return nil, errIPCNotSupported
+}
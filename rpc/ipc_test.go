@@ -0,0 +1,94 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type echoService struct{}
+
+func (echoService) Echo(s string) string { return s }
+
+func newEchoServer(t *testing.T) *Server {
+	server := NewServer()
+	if err := server.RegisterName("test", echoService{}); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func checkEcho(t *testing.T, client *Client) {
+	var result string
+	if err := client.Call(&result, "test_echo", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if result != "hello" {
+		t.Fatalf("wrong result %q", result)
+	}
+}
+
+func TestInProc(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+	checkEcho(t, client)
+
+	RegisterInProc("echo", server)
+	defer RegisterInProc("echo", nil)
+	named, err := DialContext(context.Background(), "inproc://echo", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer named.Close()
+	checkEcho(t, named)
+
+	if _, err := DialContext(context.Background(), "inproc://missing", "", "", nil); err == nil {
+		t.Fatal("expected error for unregistered server")
+	}
+}
+
+func TestIPC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpc-ipc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	endpoint := filepath.Join(dir, "test.ipc")
+
+	server := newEchoServer(t)
+	defer server.Stop()
+	listener, err := StartIPCEndpoint(endpoint, server)
+	if err != nil {
+		t.Skipf("cannot listen on unix socket: %v", err)
+	}
+	defer listener.Close()
+
+	for _, rawurl := range []string{endpoint, "ipc://" + endpoint} {
+		client, err := DialContext(context.Background(), rawurl, "", "", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", rawurl, err)
+		}
+		checkEcho(t, client)
+		client.Close()
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jwtExpiryWindow is the maximum distance between the "iat" claim of a token
+// and the local clock.
+const jwtExpiryWindow = 60 * time.Second
+
+var (
+	errJWTMalformed = errors.New("malformed jwt token")
+	errJWTSignature = errors.New("invalid jwt signature")
+	errJWTStale     = errors.New("stale jwt token")
+
+	jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+)
+
+// jwtClaims are the claims of the tokens issued by NewJWTToken.
+type jwtClaims struct {
+	IssuedAt int64 `json:"iat"`
+}
+
+// NewJWTToken creates a HS256 token carrying the current time as "iat" claim.
+// This is the scheme used by the engine API of go-ethereum.
+func NewJWTToken(secret []byte) (string, error) {
+	claims, err := json.Marshal(jwtClaims{IssuedAt: time.Now().Unix()})
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + jwtSign(secret, unsigned), nil
+}
+
+// VerifyJWTToken checks the signature of a HS256 token and that its "iat"
+// claim is within a minute of the local time.
+func VerifyJWTToken(secret []byte, token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errJWTMalformed
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return errJWTMalformed
+	}
+	var h struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &h); err != nil {
+		return errJWTMalformed
+	}
+	if h.Alg != "HS256" {
+		return fmt.Errorf("unsupported jwt algorithm %q", h.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errJWTMalformed
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errJWTSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errJWTMalformed
+	}
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return errJWTMalformed
+	}
+	issued := time.Unix(claims.IssuedAt, 0)
+	if d := time.Since(issued); d > jwtExpiryWindow || d < -jwtExpiryWindow {
+		return errJWTStale
+	}
+	return nil
+}
+
+func jwtSign(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
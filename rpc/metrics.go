@@ -0,0 +1,164 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ethclient/common/flogging/metrics"
+	"github.com/ethclient/common/flogging/metrics/disabled"
+)
+
+// LatencyBuckets are the buckets of the rpc latency histograms in seconds.
+var LatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// unknownMethodLabel is the server side method label of calls to unregistered methods.
+const unknownMethodLabel = "unknown"
+
+// rpcMetrics are the meters of the client or server side of the rpc package.
+// Their names are rpc_<subsystem>_<name>.
+type rpcMetrics struct {
+	requests      metrics.Counter   // calls per method
+	errors        metrics.Counter   // failed calls per method and error code
+	latency       metrics.Histogram // call duration per method
+	inflight      metrics.Gauge     // calls in progress
+	subscriptions metrics.Gauge     // active subscriptions per namespace
+}
+
+// noopMetrics is used when no provider has been configured.
+var noopMetrics = newRPCMetrics(&disabled.Provider{}, "")
+
+func newRPCMetrics(provider metrics.Provider, subsystem string) *rpcMetrics {
+	return &rpcMetrics{
+		requests: provider.NewCounter(metrics.CounterOpts{
+			Namespace:  "rpc",
+			Subsystem:  subsystem,
+			Name:       "requests_total",
+			Help:       "Number of rpc calls by method.",
+			LabelNames: []string{"method"},
+		}),
+		errors: provider.NewCounter(metrics.CounterOpts{
+			Namespace:  "rpc",
+			Subsystem:  subsystem,
+			Name:       "errors_total",
+			Help:       "Number of failed rpc calls by method and error code.",
+			LabelNames: []string{"method", "code"},
+		}),
+		latency: provider.NewHistogram(metrics.HistogramOpts{
+			Namespace:  "rpc",
+			Subsystem:  subsystem,
+			Name:       "request_duration_seconds",
+			Help:       "Duration of rpc calls by method.",
+			Buckets:    LatencyBuckets,
+			LabelNames: []string{"method"},
+		}),
+		inflight: provider.NewGauge(metrics.GaugeOpts{
+			Namespace: "rpc",
+			Subsystem: subsystem,
+			Name:      "inflight_requests",
+			Help:      "Number of rpc calls in progress.",
+		}),
+		subscriptions: provider.NewGauge(metrics.GaugeOpts{
+			Namespace:  "rpc",
+			Subsystem:  subsystem,
+			Name:       "active_subscriptions",
+			Help:       "Number of active subscriptions by namespace.",
+			LabelNames: []string{"namespace"},
+		}),
+	}
+}
+
+// observe records a finished call, code is empty for successful calls.
+func (m *rpcMetrics) observe(method string, elapsed time.Duration, code string) {
+	m.requests.With("method", method).Add(1)
+	m.latency.With("method", method).Observe(elapsed.Seconds())
+	if code != "" {
+		m.errors.With("method", method, "code", code).Add(1)
+	}
+}
+
+// errorCodeLabel returns the JSON-RPC error code of err, "timeout" for
+// expired contexts and "transport" for all other errors.
+func errorCodeLabel(err error) string {
+	switch err := err.(type) {
+	case nil:
+		return ""
+	case Error:
+		return strconv.Itoa(err.ErrorCode())
+	}
+	if err == context.DeadlineExceeded {
+		return "timeout"
+	}
+	return "transport"
+}
+
+// SetMetricsProvider makes the server record its metrics, named
+// rpc_server_*, with provider. Calls of unregistered methods are labelled
+// "unknown", except the fallback methods named with SetFallbackMethods. It must
+// be called before serving.
+func (s *Server) SetMetricsProvider(provider metrics.Provider) {
+	s.policy.metrics = newRPCMetrics(provider, "server")
+}
+
+// MetricsInterceptor records the client metrics, named rpc_client_*, with
+// provider. The calls of a batch are recorded individually with the duration
+// of the whole batch.
+func MetricsInterceptor(provider metrics.Provider) Interceptor {
+	m := newRPCMetrics(provider, "client")
+	return Interceptor{
+		Call: func(ctx context.Context, result interface{}, method string, args []interface{}, invoke CallInvoker) error {
+			m.inflight.Add(1)
+			start := time.Now()
+			err := invoke(ctx, result, method, args...)
+			m.inflight.Add(-1)
+			m.observe(method, time.Since(start), errorCodeLabel(err))
+			return err
+		},
+		Batch: func(ctx context.Context, b []BatchElem, invoke BatchInvoker) error {
+			m.inflight.Add(1)
+			start := time.Now()
+			err := invoke(ctx, b)
+			m.inflight.Add(-1)
+			elapsed := time.Since(start)
+			for _, elem := range b {
+				code := errorCodeLabel(err)
+				if err == nil {
+					code = errorCodeLabel(elem.Error)
+				}
+				m.observe(elem.Method, elapsed, code)
+			}
+			return err
+		},
+		Subscribe: func(ctx context.Context, namespace string, channel interface{}, args []interface{}, invoke SubscribeInvoker) (*ClientSubscription, error) {
+			start := time.Now()
+			sub, err := invoke(ctx, namespace, channel, args...)
+			m.observe(namespace+subscribeMethodSuffix, time.Since(start), errorCodeLabel(err))
+			if err != nil {
+				return nil, err
+			}
+			active := m.subscriptions.With("namespace", namespace)
+			active.Add(1)
+			go func() {
+				<-sub.quit
+				active.Add(-1)
+			}()
+			return sub, nil
+		},
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethclient/common/flogging/metrics/prometheus"
+)
+
+func TestMetrics(t *testing.T) {
+	provider := prometheus.NewProvider()
+	server := newEchoServer(t)
+	defer server.Stop()
+	server.SetMetricsProvider(provider)
+	client := DialInProc(server)
+	defer client.Close()
+	client.Use(MetricsInterceptor(provider))
+
+	checkEcho(t, client)
+	checkEcho(t, client)
+	var result string
+	client.Call(&result, "test_missing")
+	client.Call(&result, "random_name")
+
+	var buf bytes.Buffer
+	provider.WriteTo(&buf)
+	text := buf.String()
+	for _, line := range []string{
+		`rpc_client_requests_total{method="test_echo"} 2`,
+		`rpc_server_requests_total{method="test_echo"} 2`,
+		`rpc_client_errors_total{method="test_missing",code="-32601"} 1`,
+		`rpc_server_errors_total{method="unknown",code="-32601"} 2`,
+		`rpc_server_request_duration_seconds_count{method="test_echo"} 2`,
+		`rpc_client_inflight_requests 0`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, text)
+		}
+	}
+}
+
+func TestMetricsFallbackMethods(t *testing.T) {
+	provider := prometheus.NewProvider()
+	server := NewServer()
+	defer server.Stop()
+	server.SetMetricsProvider(provider)
+	server.SetFallback(func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`"proxied"`), nil
+	})
+	server.SetFallbackMethods([]string{"proxy_known"})
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result string
+	for _, method := range []string{"proxy_known", "proxy_known", "proxy_other", "proxy_random"} {
+		if err := client.Call(&result, method); err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	provider.WriteTo(&buf)
+	text := buf.String()
+	for _, line := range []string{
+		`rpc_server_requests_total{method="proxy_known"} 2`,
+		`rpc_server_requests_total{method="unknown"} 2`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "proxy_other") {
+		t.Errorf("unlisted fallback method got its own label:\n%s", text)
+	}
+}
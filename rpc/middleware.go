@@ -0,0 +1,311 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PeerInfo describes the remote end of a server connection.
+type PeerInfo struct {
+	// Transport is "http", "ws", "ipc" or "inproc", empty for codecs served
+	// through ServeCodec.
+	Transport string
+	// RemoteAddr is the IP address of HTTP and websocket clients.
+	RemoteAddr string
+	// Token is the bearer token of the HTTP request or websocket handshake.
+	Token string
+}
+
+type peerInfoKey struct{}
+
+// PeerInfoFromContext returns the peer of the connection a call arrived on.
+func PeerInfoFromContext(ctx context.Context) PeerInfo {
+	info, _ := ctx.Value(peerInfoKey{}).(PeerInfo)
+	return info
+}
+
+// newHTTPPeerInfo extracts the peer of an HTTP request or websocket handshake.
+func newHTTPPeerInfo(transport string, r *http.Request) PeerInfo {
+	info := PeerInfo{Transport: transport, RemoteAddr: r.RemoteAddr}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		info.RemoteAddr = host
+	}
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		info.Token = strings.TrimSpace(auth[7:])
+	}
+	return info
+}
+
+// Authenticator validates the credentials of an HTTP request or websocket
+// handshake. IPC and in-process connections are not authenticated.
+type Authenticator func(peer PeerInfo) error
+
+// ServerMiddleware runs before every call, subscription and notification
+// handled by the server. A non-nil error rejects the call, errors implementing
+// Error keep their code. The peer is available through PeerInfoFromContext.
+type ServerMiddleware func(ctx context.Context, method string) error
+
+// ServerLimits restricts the requests served by a Server. Zero values disable
+// the respective limit.
+type ServerLimits struct {
+	MaxBatchLength  int                      // maximum number of calls in a batch
+	MaxRequestSize  int64                    // maximum HTTP body or websocket message size, default 5MB
+	MaxResponseSize int                      // maximum size of an encoded result
+	CallTimeout     time.Duration            // execution timeout of all methods
+	MethodTimeouts  map[string]time.Duration // per method execution timeouts, override CallTimeout
+}
+
+// serverPolicy holds the middleware, limits and metrics of a server.
+type serverPolicy struct {
+	auth       Authenticator
+	middleware []ServerMiddleware
+	limits     ServerLimits
+	metrics    *rpcMetrics
+}
+
+type serverPolicyKey struct{}
+
+// SetAuth installs the authenticator of HTTP and websocket connections. Like
+// Use and SetLimits it must be called before the server starts serving.
+func (s *Server) SetAuth(auth Authenticator) {
+	s.policy.auth = auth
+}
+
+// Use appends call middleware. Middleware runs in the order it was added.
+func (s *Server) Use(middleware ...ServerMiddleware) {
+	s.policy.middleware = append(s.policy.middleware, middleware...)
+}
+
+// SetLimits configures the request limits of the server.
+func (s *Server) SetLimits(limits ServerLimits) {
+	s.policy.limits = limits
+}
+
+// connContext returns the base context of a connection served by s.
+func (s *Server) connContext(ctx context.Context, peer PeerInfo) context.Context {
+	ctx = context.WithValue(ctx, peerInfoKey{}, peer)
+	return context.WithValue(ctx, serverPolicyKey{}, &s.policy)
+}
+
+// callMetrics returns the server metrics, a no-op for clients.
+func (p *serverPolicy) callMetrics() *rpcMetrics {
+	if p == nil || p.metrics == nil {
+		return noopMetrics
+	}
+	return p.metrics
+}
+
+func (p *serverPolicy) maxRequestSize() int64 {
+	if p == nil || p.limits.MaxRequestSize <= 0 {
+		return maxRequestContentLength
+	}
+	return p.limits.MaxRequestSize
+}
+
+func (p *serverPolicy) authenticate(peer PeerInfo) error {
+	if p == nil || p.auth == nil {
+		return nil
+	}
+	if err := p.auth(peer); err != nil {
+		return &unauthorizedError{err}
+	}
+	return nil
+}
+
+func (p *serverPolicy) checkBatch(n int) error {
+	if p != nil && p.limits.MaxBatchLength > 0 && n > p.limits.MaxBatchLength {
+		return &invalidRequestError{"batch too large"}
+	}
+	return nil
+}
+
+func (p *serverPolicy) checkCall(ctx context.Context, method string) error {
+	if p == nil {
+		return nil
+	}
+	for _, mw := range p.middleware {
+		if err := mw(ctx, method); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *serverPolicy) timeout(method string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	if timeout, ok := p.limits.MethodTimeouts[method]; ok {
+		return timeout
+	}
+	return p.limits.CallTimeout
+}
+
+func (p *serverPolicy) checkResponse(size int) error {
+	if p != nil && p.limits.MaxResponseSize > 0 && size > p.limits.MaxResponseSize {
+		return &responseTooLargeError{size, p.limits.MaxResponseSize}
+	}
+	return nil
+}
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid bearer token")
+)
+
+// JWTAuthenticator accepts HS256 bearer tokens signed with secret whose "iat"
+// claim is within a minute of the local time, see NewJWTToken.
+func JWTAuthenticator(secret []byte) Authenticator {
+	return func(peer PeerInfo) error {
+		if peer.Token == "" {
+			return errMissingToken
+		}
+		return VerifyJWTToken(secret, peer.Token)
+	}
+}
+
+// TokenAuthenticator accepts a fixed set of bearer tokens.
+func TokenAuthenticator(tokens ...string) Authenticator {
+	valid := make(map[string]struct{}, len(tokens))
+	for _, token := range tokens {
+		valid[token] = struct{}{}
+	}
+	return func(peer PeerInfo) error {
+		if peer.Token == "" {
+			return errMissingToken
+		}
+		if _, ok := valid[peer.Token]; !ok {
+			return errInvalidToken
+		}
+		return nil
+	}
+}
+
+// MethodFilter rejects calls that are not matched by allow, if not empty, or
+// that are matched by deny. Entries are full method names ("eth_call"),
+// namespaces ("eth") or namespace wildcards ("eth_*"). Rejected methods are
+// reported as not found.
+func MethodFilter(allow, deny []string) ServerMiddleware {
+	return func(ctx context.Context, method string) error {
+		if len(allow) > 0 && !matchMethod(allow, method) || matchMethod(deny, method) {
+			return &methodNotFoundError{method: method}
+		}
+		return nil
+	}
+}
+
+func matchMethod(patterns []string, method string) bool {
+	namespace := method
+	if i := strings.Index(method, serviceMethodSeparator); i >= 0 {
+		namespace = method[:i]
+	}
+	for _, pattern := range patterns {
+		if pattern == method || pattern == namespace || pattern == namespace+serviceMethodSeparator+"*" {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimit configures a token bucket: Rate calls per second on average with
+// bursts of up to Burst calls. A zero Rate disables the limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter limits the calls per client IP address and per bearer token.
+// Calls without address (IPC, in-process) or token are not limited by the
+// respective bucket.
+func RateLimiter(perIP, perToken RateLimit) ServerMiddleware {
+	ips, tokens := newBuckets(perIP), newBuckets(perToken)
+	return func(ctx context.Context, method string) error {
+		peer := PeerInfoFromContext(ctx)
+		if peer.RemoteAddr != "" && !ips.take(peer.RemoteAddr) {
+			return &rateLimitError{peer.RemoteAddr}
+		}
+		if peer.Token != "" && !tokens.take(peer.Token) {
+			return &rateLimitError{"token"}
+		}
+		return nil
+	}
+}
+
+// bucketIdleTimeout is the age after which full buckets are dropped.
+const bucketIdleTimeout = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type buckets struct {
+	limit RateLimit
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func newBuckets(limit RateLimit) *buckets {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &buckets{limit: limit, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// take removes a token from the bucket of key and reports whether there was one.
+func (b *buckets) take(key string) bool {
+	if b.limit.Rate <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if now.Sub(b.swept) > bucketIdleTimeout {
+		for k, bk := range b.buckets {
+			if now.Sub(bk.last) > bucketIdleTimeout {
+				delete(b.buckets, k)
+			}
+		}
+		b.swept = now
+	}
+	bk, ok := b.buckets[key]
+	if !ok {
+		bk = &bucket{tokens: float64(b.limit.Burst), last: now}
+		b.buckets[key] = bk
+	}
+	bk.tokens += now.Sub(bk.last).Seconds() * b.limit.Rate
+	if max := float64(b.limit.Burst); bk.tokens > max {
+		bk.tokens = max
+	}
+	bk.last = now
+	if bk.tokens < 1 {
+		return false
+	}
+	bk.tokens--
+	return true
+}
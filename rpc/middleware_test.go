@@ -0,0 +1,163 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type limitService struct{}
+
+func (limitService) Sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (limitService) Repeat(s string, n int) string { return strings.Repeat(s, n) }
+
+func errorCode(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	rpcErr, ok := err.(Error)
+	if !ok {
+		t.Fatalf("error %v has no code", err)
+	}
+	return rpcErr.ErrorCode()
+}
+
+func TestServerLimits(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Stop()
+	if err := server.RegisterName("limit", limitService{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetLimits(ServerLimits{
+		MaxBatchLength:  2,
+		MaxResponseSize: 100,
+		MethodTimeouts:  map[string]time.Duration{"limit_sleep": 50 * time.Millisecond},
+	})
+	server.Use(MethodFilter([]string{"test", "limit_*"}, []string{"test_echo"}))
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result string
+	if code := errorCode(t, client.Call(&result, "test_echo", "x")); code != -32601 {
+		t.Errorf("denied method: code %d", code)
+	}
+	if code := errorCode(t, client.Call(&result, "rpc_modules")); code != -32601 {
+		t.Errorf("method outside allowlist: code %d", code)
+	}
+	if code := errorCode(t, client.Call(nil, "limit_sleep", time.Second)); code != -32002 {
+		t.Errorf("timeout: code %d", code)
+	}
+	if err := client.Call(nil, "limit_sleep", time.Millisecond); err != nil {
+		t.Errorf("sleep within timeout: %v", err)
+	}
+	if code := errorCode(t, client.Call(&result, "limit_repeat", "a", 200)); code != -32003 {
+		t.Errorf("response size: code %d", code)
+	}
+	if err := client.Call(&result, "limit_repeat", "a", 10); err != nil || result != "aaaaaaaaaa" {
+		t.Errorf("repeat: %q %v", result, err)
+	}
+	batch := make([]BatchElem, 3)
+	for i := range batch {
+		batch[i] = BatchElem{Method: "limit_repeat", Args: []interface{}{"a", 1}, Result: new(string)}
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch[0].Error == nil || errorCode(t, batch[0].Error) != -32600 {
+		t.Errorf("oversized batch: %v", batch[0].Error)
+	}
+	if err := client.BatchCall(batch[:2]); err != nil || batch[0].Error != nil {
+		t.Errorf("batch: %v %v", err, batch[0].Error)
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	server := newEchoServer(t)
+	defer server.Stop()
+	secret := []byte("secret")
+	server.SetAuth(JWTAuthenticator(secret))
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	client, err := DialHTTPWithClient(httpsrv.URL, new(http.Client), WithJWTAuth(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	checkEcho(t, client)
+
+	resp, err := http.Post(httpsrv.URL, contentType, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var msg jsonrpcMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || msg.Error == nil || msg.Error.Code != -32001 {
+		t.Fatalf("status %d, error %+v", resp.StatusCode, msg.Error)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newBuckets(RateLimit{Rate: 2, Burst: 2})
+	b.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if !b.take("a") {
+			t.Fatalf("call %d limited", i)
+		}
+	}
+	if b.take("a") {
+		t.Fatal("burst exceeded")
+	}
+	if !b.take("b") {
+		t.Fatal("separate key limited")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !b.take("a") || b.take("a") {
+		t.Fatal("bucket not refilled at rate")
+	}
+
+	limit := RateLimiter(RateLimit{Rate: 1, Burst: 1}, RateLimit{})
+	ctx := context.WithValue(context.Background(), peerInfoKey{}, PeerInfo{RemoteAddr: "10.0.0.1"})
+	if err := limit(ctx, "eth_call"); err != nil {
+		t.Fatal(err)
+	}
+	if code := errorCode(t, limit(ctx, "eth_call")); code != -32005 {
+		t.Fatalf("code %d", code)
+	}
+	if err := limit(context.Background(), "eth_call"); err != nil {
+		t.Fatalf("local call limited: %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+package rpc
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// ResubscribeConfig configures a subscription created by SubscribeResilient.
+type ResubscribeConfig struct {
+	MinBackoff time.Duration // delay before the first reconnect attempt, doubled on each failure
+	MaxBackoff time.Duration // upper bound of the reconnect delay
+
+	// OnResubscribe, if set, is called after the subscription has been
+	// re-established on a new connection. Notifications of the new subscription
+	// are held back until it returns, so it is the place to backfill the
+	// notifications missed while the connection was down. If it fails, the new
+	// subscription is dropped and reestablished after the next backoff delay.
+	// The context is canceled when the subscription is stopped.
+	OnResubscribe func(ctx context.Context) error
+}
+
+// ResilientSubscription is a subscription which survives connection loss. It
+// is created by SubscribeResilient.
+type ResilientSubscription struct {
+	client    *Client
+	namespace string
+	channel   reflect.Value // the caller's channel
+	args      []interface{}
+	config    ResubscribeConfig
+
+	ctx    context.Context // canceled by Unsubscribe
+	cancel context.CancelFunc
+	mu     sync.Mutex // protects sub
+	sub    *ClientSubscription
+	err    chan error
+}
+
+// SubscribeResilient works like Subscribe, but when the subscription ends because
+// of a connection error, the client reconnects with exponential backoff and
+// re-issues the subscription with the original arguments. Notifications keep
+// arriving on channel, which is never closed. Notifications sent by the server
+// while the connection is down are lost unless OnResubscribe recovers them.
+//
+// The error channel of the subscription only receives errors the server returns
+// when the subscription is re-established, such as an unknown subscription
+// method. Like for ClientSubscription, nil is sent when the client is closed.
+func (c *Client) SubscribeResilient(ctx context.Context, namespace string, channel interface{}, config ResubscribeConfig, args ...interface{}) (*ResilientSubscription, error) {
+	chanVal := reflect.ValueOf(channel)
+	if chanVal.Kind() != reflect.Chan || chanVal.Type().ChanDir()&reflect.SendDir == 0 {
+		panic("first argument to SubscribeResilient must be a writable channel")
+	}
+	if chanVal.IsNil() {
+		panic("channel given to SubscribeResilient must not be nil")
+	}
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = defaultMinBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = config.MinBackoff
+	}
+	rs := &ResilientSubscription{
+		client:    c,
+		namespace: namespace,
+		channel:   chanVal,
+		args:      args,
+		config:    config,
+		err:       make(chan error, 1),
+	}
+	in := rs.makeChan()
+	sub, err := c.Subscribe(ctx, namespace, in.Interface(), args...)
+	if err != nil {
+		return nil, err
+	}
+	rs.ctx, rs.cancel = context.WithCancel(context.Background())
+	rs.sub = sub
+	go rs.loop(sub, in)
+	return rs, nil
+}
+
+// Err returns the subscription error channel. It is closed by Unsubscribe.
+func (rs *ResilientSubscription) Err() <-chan error {
+	return rs.err
+}
+
+// Unsubscribe stops the subscription and closes the error channel. It can
+// safely be called more than once.
+func (rs *ResilientSubscription) Unsubscribe() {
+	rs.cancel()
+	rs.mu.Lock()
+	rs.sub.Unsubscribe()
+	rs.mu.Unlock()
+}
+
+// makeChan creates the channel a single underlying subscription delivers to.
+// Each connection gets its own channel so that notifications of a new
+// subscription can be held back while OnResubscribe runs.
+func (rs *ResilientSubscription) makeChan() reflect.Value {
+	return reflect.MakeChan(reflect.ChanOf(reflect.BothDir, rs.channel.Type().Elem()), 0)
+}
+
+func (rs *ResilientSubscription) loop(sub *ClientSubscription, in reflect.Value) {
+	defer close(rs.err)
+	for {
+		stopped, err := rs.forward(sub, in)
+		switch {
+		case stopped || rs.ctx.Err() != nil:
+			return // unsubscribed
+		case err == nil:
+			rs.err <- nil // client closed
+			return
+		}
+		log.Debugw("RPC subscription lost, resubscribing", "namespace", rs.namespace, "err", err)
+		if sub, in, err = rs.resubscribe(); err != nil {
+			rs.err <- err
+			return
+		}
+		if sub == nil {
+			return
+		}
+	}
+}
+
+// forward copies notifications from in to the caller's channel until the
+// underlying subscription ends, returning its error, or until the
+// subscription is stopped.
+func (rs *ResilientSubscription) forward(sub *ClientSubscription, in reflect.Value) (stopped bool, err error) {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.Err())},
+		{Dir: reflect.SelectRecv, Chan: in},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(rs.ctx.Done())},
+	}
+	for {
+		chosen, recv, ok := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			if !ok {
+				return true, nil
+			}
+			err, _ := recv.Interface().(error)
+			return false, err
+		case 1:
+			send := []reflect.SelectCase{
+				{Dir: reflect.SelectSend, Chan: rs.channel, Send: recv},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(rs.ctx.Done())},
+			}
+			if chosen, _, _ := reflect.Select(send); chosen == 1 {
+				return true, nil
+			}
+		case 2:
+			return true, nil
+		}
+	}
+}
+
+// resubscribe re-issues the subscription until it succeeds, the server
+// rejects it or the subscription is stopped, in which case sub is nil.
+func (rs *ResilientSubscription) resubscribe() (*ClientSubscription, reflect.Value, error) {
+	backoff := rs.config.MinBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-rs.ctx.Done():
+			return nil, reflect.Value{}, nil
+		case <-rs.client.closing:
+			return nil, reflect.Value{}, nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > rs.config.MaxBackoff {
+			backoff = rs.config.MaxBackoff
+		}
+
+		in := rs.makeChan()
+		ctx, cancel := context.WithTimeout(rs.ctx, subscribeTimeout)
+		sub, err := rs.client.Subscribe(ctx, rs.namespace, in.Interface(), rs.args...)
+		cancel()
+		switch err.(type) {
+		case nil:
+		case Error:
+			return nil, reflect.Value{}, err
+		default:
+			if err == ErrClientQuit {
+				return nil, reflect.Value{}, nil
+			}
+			log.Debugw("RPC resubscribe failed", "namespace", rs.namespace, "attempt", attempt, "err", err)
+			continue
+		}
+		if rs.config.OnResubscribe != nil {
+			if err := rs.config.OnResubscribe(rs.ctx); err != nil {
+				log.Debugw("RPC resubscribe hook failed", "namespace", rs.namespace, "attempt", attempt, "err", err)
+				sub.Unsubscribe()
+				continue
+			}
+		}
+		rs.mu.Lock()
+		rs.sub = sub
+		rs.mu.Unlock()
+		if rs.ctx.Err() != nil {
+			sub.Unsubscribe()
+			return nil, reflect.Value{}, nil
+		}
+		log.Debugw("RPC subscription reestablished", "namespace", rs.namespace, "attempt", attempt)
+		return sub, in, nil
+	}
+}
@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"sync/atomic"
 
@@ -45,6 +46,7 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+	policy   serverPolicy
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -65,12 +67,43 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 	return s.services.registerName(name, receiver)
 }
 
+// FallbackFunc handles calls of methods that are not registered, e.g. to proxy
+// them to another server. params holds the raw JSON parameters and the result
+// is sent to the client as is.
+type FallbackFunc func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+
+// SetFallback installs fn as handler of calls to unknown methods. Subscriptions
+// are never passed to fn.
+func (s *Server) SetFallback(fn FallbackFunc) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.fallback = fn
+}
+
+// SetFallbackMethods names the methods handled by the fallback that get their
+// own metrics label. Other calls passed to the fallback are labelled "unknown",
+// so the set must be bounded.
+func (s *Server) SetFallbackMethods(methods []string) {
+	known := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		known[method] = true
+	}
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.fallbackMethods = known
+}
+
 // ServeCodec reads incoming requests from codec, calls the appropriate callback and writes
 // the response back using the given codec. It will block until the codec is closed or the
 // server is stopped. In either case the codec is closed.
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(s.connContext(context.Background(), PeerInfo{}), codec)
+}
+
+// serveCodec is ServeCodec with the base context of the connection.
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -82,7 +115,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(ctx, codec, s.idgen, &s.services)
 	<-codec.closed()
 	c.Close()
 }
@@ -35,8 +35,10 @@ var (
 )
 
 type serviceRegistry struct {
-	mu       sync.Mutex
-	services map[string]service
+	mu              sync.Mutex
+	services        map[string]service
+	fallback        FallbackFunc
+	fallbackMethods map[string]bool // methods of the fallback labelled in metrics
 }
 
 // service represents a registered object.
@@ -101,6 +103,28 @@ func (r *serviceRegistry) callback(method string) *callback {
 	return r.services[elem[0]].callbacks[elem[1]]
 }
 
+// hasService reports whether a service with the given name is registered.
+func (r *serviceRegistry) hasService(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.services[name]
+	return ok
+}
+
+// isFallbackMethod reports whether method is a known method of the fallback.
+func (r *serviceRegistry) isFallbackMethod(method string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fallback != nil && r.fallbackMethods[method]
+}
+
+// fallbackFunc returns the handler of unknown methods.
+func (r *serviceRegistry) fallbackFunc() FallbackFunc {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fallback
+}
+
 // subscription returns a subscription callback in the given service.
 func (r *serviceRegistry) subscription(service, name string) *callback {
 	r.mu.Lock()
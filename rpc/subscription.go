@@ -325,3 +325,11 @@ func (sub *ClientSubscription) requestUnsubscribe() error {
 	var result interface{}
 	return sub.client.Call(&result, sub.namespace+unsubscribeMethodSuffix, sub.subid)
 }
+
+// Disconnect closes the RPC connection of the notifier. Subscription handlers
+// use it to get rid of clients that cannot keep up with their notifications.
+func (n *Notifier) Disconnect() {
+	if codec, ok := n.h.conn.(ServerCodec); ok {
+		codec.close()
+	}
+}
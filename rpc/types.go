@@ -42,6 +42,13 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError contains extra data to explain the error, like the revert reason
+// of eth_call.
+type DataError interface {
+	Error() string          // returns the message
+	ErrorData() interface{} // returns the error data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
@@ -49,13 +49,19 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 		CheckOrigin:     wsHandshakeValidator(allowedOrigins),
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer := newHTTPPeerInfo("ws", r)
+		if err := s.policy.authenticate(peer); err != nil {
+			writeHTTPError(w, http.StatusUnauthorized, err)
+			return
+		}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			log.Debug("WebSocket upgrade failed", "err", err)
 			return
 		}
 		codec := newWebsocketCodec(conn)
-		s.ServeCodec(codec, 0)
+		conn.SetReadLimit(s.policy.maxRequestSize())
+		s.serveCodec(s.connContext(context.Background(), peer), codec)
 	})
 }
 
@@ -0,0 +1,140 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package sigdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+)
+
+// bytes32 is the type reported for indexed event arguments of reference
+// types, which are stored in the topic as the hash of their encoding.
+var bytes32, _ = abi.NewType("bytes32", "", nil)
+
+var errNotCanonical = errors.New("sigdb: data is not in canonical encoding")
+
+// DecodedLog is an event log decoded with a known signature.
+type DecodedLog struct {
+	Event abi.Event
+	Args  []abi.DecodedArgument // all arguments in declaration order
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l *DecodedLog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Event     string                `json:"event"`
+		Signature string                `json:"signature"`
+		Topic     common.Hash           `json:"topic"`
+		Args      []abi.DecodedArgument `json:"args"`
+	}{l.Event.RawName, l.Event.Sig(), l.Event.ID(), l.Args})
+}
+
+// String renders the log as "name(type name=value, ...)".
+func (l *DecodedLog) String() string {
+	args := make([]string, len(l.Args))
+	for i, arg := range l.Args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%s(%s)", l.Event.RawName, strings.Join(args, ", "))
+}
+
+// decodeCall decodes input with method and verifies that the arguments encode
+// back to the input, which rules out most candidates of a colliding selector.
+func decodeCall(method *abi.Method, input []byte) (*abi.DecodedCall, error) {
+	contract := &abi.ABI{Methods: map[string]abi.Method{method.Name: *method}}
+	call, err := abi.DecodeCalldata(contract, input)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(call.Args))
+	for i, arg := range call.Args {
+		values[i] = arg.Value
+	}
+	if err := checkEncoding(method.Inputs, values, input[4:]); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// decodeLog decodes the topics following the event topic and the data of a
+// log with event. Indexed arguments of reference types are returned as their
+// bytes32 hash.
+func decodeLog(event *abi.Event, topics []common.Hash, data []byte) (*DecodedLog, error) {
+	indexed := len(event.Inputs) - event.Inputs.LengthNonIndexed()
+	if len(topics) != indexed {
+		return nil, fmt.Errorf("sigdb: %s has %d indexed arguments, log has %d", event.Sig(), indexed, len(topics))
+	}
+	nonIndexed := event.Inputs.NonIndexed()
+	values, err := nonIndexed.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEncoding(nonIndexed, values, data); err != nil {
+		return nil, err
+	}
+	log := &DecodedLog{Event: *event, Args: make([]abi.DecodedArgument, len(event.Inputs))}
+	for i, input := range event.Inputs {
+		if !input.Indexed {
+			log.Args[i] = abi.DecodedArgument{Name: input.Name, Type: input.Type, Value: values[0]}
+			values = values[1:]
+			continue
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		if isReferenceType(input.Type) {
+			log.Args[i] = abi.DecodedArgument{Name: input.Name, Type: bytes32, Value: topic}
+			continue
+		}
+		args := abi.Arguments{{Name: input.Name, Type: input.Type}}
+		value, err := args.UnpackValues(topic[:])
+		if err != nil {
+			return nil, err
+		}
+		if err := checkEncoding(args, value, topic[:]); err != nil {
+			return nil, err
+		}
+		log.Args[i] = abi.DecodedArgument{Name: input.Name, Type: input.Type, Value: value[0]}
+	}
+	return log, nil
+}
+
+// checkEncoding verifies that values encode to exactly data.
+func checkEncoding(args abi.Arguments, values []interface{}, data []byte) error {
+	packed, err := args.Pack(values...)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(packed, data) {
+		return errNotCanonical
+	}
+	return nil
+}
+
+// isReferenceType reports whether indexed values of type t are hashed.
+func isReferenceType(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
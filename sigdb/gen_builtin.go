@@ -0,0 +1,62 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build ignore
+
+// gen_builtin generates builtin.go from the ABI corpus in abis/.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/sigdb"
+)
+
+func main() {
+	files, err := filepath.Glob(filepath.Join("abis", "*.json"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	db := sigdb.New()
+	for _, file := range files {
+		if _, err := db.AddFile(file); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_builtin.go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package sigdb\n\n")
+	fmt.Fprintf(&buf, "// builtin holds the %d unique functions and events of the ABIs in abis/,\n", db.Len())
+	fmt.Fprintf(&buf, "// ordered by selector or topic.\n")
+	fmt.Fprintf(&buf, "var builtin = []string{\n")
+	for _, sig := range db.Signatures() {
+		fmt.Fprintf(&buf, "\t// %s %s\n\t`%s`,\n", hexutil.Encode(sig.ID), sig.Signature, sig.Entry())
+	}
+	fmt.Fprintf(&buf, "}\n")
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("builtin.go", out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sigdb is an offline database of known function selectors and event
+// topics, used to label and decode calls and logs of contracts whose ABI is
+// not available.
+//
+// The built-in signatures are generated from the ABI corpus in abis/ by
+// gen_builtin.go. Further signatures can be added from ABI JSON files,
+// compiler artifacts and text files listing one signature per line.
+package sigdb
+
+//go:generate go run gen_builtin.go
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+)
+
+// ErrUnknownSignature is returned when no signature is known for a selector
+// or topic.
+var ErrUnknownSignature = errors.New("sigdb: unknown signature")
+
+// Signature is a known function or event signature.
+type Signature struct {
+	ID        []byte      // 4-byte selector of a function, topic hash of an event
+	Signature string      // canonical signature, e.g. transfer(address,uint256)
+	Method    *abi.Method // set for functions
+	Event     *abi.Event  // set for events
+
+	raw json.RawMessage // ABI entry the signature was built from
+}
+
+// String implements fmt.Stringer.
+func (s *Signature) String() string {
+	if s.Event != nil {
+		return s.Event.String()
+	}
+	return s.Method.String()
+}
+
+// Entry returns the JSON ABI entry of the signature.
+func (s *Signature) Entry() json.RawMessage {
+	return s.raw
+}
+
+// key identifies a signature. Events with the same signature but different
+// indexed arguments share a topic yet decode differently, so they are kept
+// apart.
+func (s *Signature) key() string {
+	if s.Event == nil {
+		return "function " + s.Signature
+	}
+	indexed := make([]byte, len(s.Event.Inputs))
+	for i, input := range s.Event.Inputs {
+		indexed[i] = '0'
+		if input.Indexed {
+			indexed[i] = '1'
+		}
+	}
+	return fmt.Sprintf("event %s %s", s.Signature, indexed)
+}
+
+// DB is a set of known signatures indexed by selector and topic. It is safe
+// for concurrent use.
+type DB struct {
+	mu      sync.RWMutex
+	keys    map[string]bool
+	methods map[[4]byte][]*Signature
+	events  map[common.Hash][]*Signature
+	all     []*Signature
+}
+
+// New creates an empty signature database.
+func New() *DB {
+	return &DB{
+		keys:    make(map[string]bool),
+		methods: make(map[[4]byte][]*Signature),
+		events:  make(map[common.Hash][]*Signature),
+	}
+}
+
+var (
+	builtinOnce sync.Once
+	builtinSigs []*Signature
+)
+
+// Default creates a signature database holding the built-in signatures.
+func Default() *DB {
+	builtinOnce.Do(func() {
+		for _, entry := range builtin {
+			sig, err := parseEntry(json.RawMessage(entry))
+			if err != nil {
+				panic(fmt.Sprintf("sigdb: invalid built-in entry %s: %v", entry, err))
+			}
+			builtinSigs = append(builtinSigs, sig)
+		}
+	})
+	db := New()
+	for _, sig := range builtinSigs {
+		db.add(sig)
+	}
+	return db
+}
+
+// Len returns the number of signatures in the database.
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.all)
+}
+
+// Signatures returns all signatures ordered by id and signature.
+func (db *DB) Signatures() []*Signature {
+	db.mu.RLock()
+	sigs := make([]*Signature, len(db.all))
+	copy(sigs, db.all)
+	db.mu.RUnlock()
+
+	sort.SliceStable(sigs, func(i, j int) bool {
+		if c := bytes.Compare(sigs[i].ID, sigs[j].ID); c != 0 {
+			return c < 0
+		}
+		return sigs[i].key() < sigs[j].key()
+	})
+	return sigs
+}
+
+// AddABI adds the functions and events of a JSON ABI and returns the number
+// of signatures that were not known yet. Anonymous events and other entries
+// are ignored.
+func (db *DB) AddABI(data []byte) (int, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, entry := range entries {
+		var head struct{ Type string }
+		if err := json.Unmarshal(entry, &head); err != nil {
+			return added, err
+		}
+		if head.Type != "function" && head.Type != "event" && head.Type != "" {
+			continue
+		}
+		sig, err := parseEntry(entry)
+		if err != nil {
+			return added, err
+		}
+		if sig.Event != nil && sig.Event.Anonymous {
+			continue
+		}
+		if db.add(sig) {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// AddText adds human-readable signatures listed one per line, e.g.
+//
+//	transfer(address,uint256)
+//	function approve(address spender, uint256 amount)
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//
+// Lines without a keyword are functions, see abi.ParseHumanReadable for the
+// syntax. Empty lines and lines starting with '#' or "//" are skipped.
+func (db *DB) AddText(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	blob, err := abi.HumanReadableJSON(lines)
+	if err != nil {
+		return 0, err
+	}
+	return db.AddABI(blob)
+}
+
+// AddFile adds the signatures of a file holding a JSON ABI, a compiler
+// artifact with an "abi" field, or one text signature per line.
+func (db *DB) AddFile(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		n       int
+		trimmed = bytes.TrimSpace(data)
+	)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		n, err = db.AddABI(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err = json.Unmarshal(trimmed, &artifact); err == nil {
+			if len(artifact.ABI) == 0 {
+				err = errors.New("no abi field")
+			} else {
+				n, err = db.AddABI(artifact.ABI)
+			}
+		}
+	default:
+		n, err = db.AddText(bytes.NewReader(data))
+	}
+	if err != nil {
+		return n, fmt.Errorf("%s: %v", path, err)
+	}
+	return n, nil
+}
+
+// Export writes all signatures as a JSON ABI, which AddFile reads back.
+func (db *DB) Export(w io.Writer) error {
+	sigs := db.Signatures()
+	entries := make([]json.RawMessage, len(sigs))
+	for i, sig := range sigs {
+		entries[i] = sig.Entry()
+	}
+	out, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+// Lookup returns all functions with the selector of input, which may be a
+// bare 4-byte selector or complete call data.
+func (db *DB) Lookup(input []byte) []*Signature {
+	if len(input) < 4 {
+		return nil
+	}
+	var selector [4]byte
+	copy(selector[:], input)
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]*Signature(nil), db.methods[selector]...)
+}
+
+// LookupEvent returns all events with the given topic.
+func (db *DB) LookupEvent(topic common.Hash) []*Signature {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]*Signature(nil), db.events[topic]...)
+}
+
+// DecodeCall decodes input with every function candidate of its selector and
+// returns the calls that decode cleanly, i.e. whose arguments encode back to
+// exactly the same input. Several calls are returned if the selector collides
+// and the input is valid for more than one candidate.
+func (db *DB) DecodeCall(input []byte) ([]*abi.DecodedCall, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("sigdb: call data too short (%d bytes)", len(input))
+	}
+	candidates := db.Lookup(input)
+	if len(candidates) == 0 {
+		return nil, ErrUnknownSignature
+	}
+	var calls []*abi.DecodedCall
+	for _, sig := range candidates {
+		if call, err := decodeCall(sig.Method, input); err == nil {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("sigdb: none of the %d candidates of %s decodes the call data", len(candidates), hexutil.Encode(input[:4]))
+	}
+	return calls, nil
+}
+
+// DecodeLog decodes a log with every event candidate of its first topic and
+// returns the events that decode cleanly.
+func (db *DB) DecodeLog(topics []common.Hash, data []byte) ([]*DecodedLog, error) {
+	if len(topics) == 0 {
+		return nil, errors.New("sigdb: log has no topics")
+	}
+	candidates := db.LookupEvent(topics[0])
+	if len(candidates) == 0 {
+		return nil, ErrUnknownSignature
+	}
+	var logs []*DecodedLog
+	for _, sig := range candidates {
+		if log, err := decodeLog(sig.Event, topics[1:], data); err == nil {
+			logs = append(logs, log)
+		}
+	}
+	if len(logs) == 0 {
+		return nil, fmt.Errorf("sigdb: none of the %d candidates of %s decodes the log", len(candidates), topics[0].Hex())
+	}
+	return logs, nil
+}
+
+// add inserts sig unless an identical signature is already known.
+func (db *DB) add(sig *Signature) bool {
+	key := sig.key()
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.keys[key] {
+		return false
+	}
+	db.keys[key] = true
+	db.all = append(db.all, sig)
+	if sig.Event != nil {
+		topic := common.BytesToHash(sig.ID)
+		db.events[topic] = append(db.events[topic], sig)
+	} else {
+		var selector [4]byte
+		copy(selector[:], sig.ID)
+		db.methods[selector] = append(db.methods[selector], sig)
+	}
+	return true
+}
+
+// parseEntry parses a single function or event entry of a JSON ABI.
+func parseEntry(entry json.RawMessage) (*Signature, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, entry); err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(compact.Bytes())
+	parsed, err := abi.JSON(bytes.NewReader(append(append([]byte("["), raw...), ']')))
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range parsed.Methods {
+		method := method
+		return &Signature{ID: method.ID(), Signature: method.Sig(), Method: &method, raw: raw}, nil
+	}
+	for _, event := range parsed.Events {
+		event := event
+		return &Signature{ID: event.ID().Bytes(), Signature: event.Sig(), Event: &event, raw: raw}, nil
+	}
+	return nil, fmt.Errorf("not a function or event: %s", raw)
+}
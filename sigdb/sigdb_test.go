@@ -0,0 +1,157 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package sigdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/crypto"
+)
+
+const collisions = `
+# selectors colliding with transfer(address,uint256)
+many_msg_babbage(bytes1)
+function transfer(bytes4[9], bytes5[6], int48[11])
+func_2093253501(bytes)
+`
+
+func word(v *big.Int) []byte {
+	return common.LeftPadBytes(v.Bytes(), 32)
+}
+
+func TestDecodeCallCollisions(t *testing.T) {
+	db := Default()
+	n, err := db.AddText(strings.NewReader(collisions))
+	if err != nil || n != 3 {
+		t.Fatalf("added %d signatures: %v", n, err)
+	}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	input := append(hexutil.MustDecode("0xa9059cbb"), common.LeftPadBytes(to.Bytes(), 32)...)
+	input = append(input, word(big.NewInt(1000))...)
+	if candidates := db.Lookup(input); len(candidates) != 4 {
+		t.Fatalf("got %d candidates, want 4", len(candidates))
+	}
+	calls, err := db.DecodeCall(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].String() != "transfer(address to=0x00000000000000000000000000000000000000AA, uint256 amount=1000)" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+
+	input = append(hexutil.MustDecode("0xa9059cbb"), common.RightPadBytes([]byte{0x42}, 32)...)
+	calls, err = db.DecodeCall(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || calls[0].Method.Sig() != "many_msg_babbage(bytes1)" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	if _, err := db.DecodeCall(hexutil.MustDecode("0x12345678")); err != ErrUnknownSignature {
+		t.Errorf("got %v, want ErrUnknownSignature", err)
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	db := Default()
+	from := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	topic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	if candidates := db.LookupEvent(topic); len(candidates) != 2 {
+		t.Fatalf("got %d Transfer candidates, want 2", len(candidates))
+	}
+
+	// ERC20: value in data.
+	logs, err := db.DecodeLog([]common.Hash{topic, from.Hash(), to.Hash()}, word(big.NewInt(7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].String() != "Transfer(address from=0x0000000000000000000000000000000000000001, address to=0x0000000000000000000000000000000000000002, uint256 value=7)" {
+		t.Errorf("unexpected logs %v", logs)
+	}
+	// ERC721: token id in topic.
+	logs, err = db.DecodeLog([]common.Hash{topic, from.Hash(), to.Hash(), common.BigToHash(big.NewInt(7))}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Args[2].Name != "tokenId" {
+		t.Errorf("unexpected logs %v", logs)
+	}
+	// Dirty address topics do not decode.
+	dirty := from.Hash()
+	dirty[0] = 1
+	if _, err := db.DecodeLog([]common.Hash{topic, dirty, to.Hash()}, word(big.NewInt(7))); err == nil {
+		t.Errorf("non-canonical topic accepted")
+	}
+}
+
+func TestIndexedReferenceType(t *testing.T) {
+	db := New()
+	if _, err := db.AddText(strings.NewReader("event Named((uint256 id, address) indexed key, string name)")); err != nil {
+		t.Fatal(err)
+	}
+	sigs := db.Signatures()
+	if len(sigs) != 1 || sigs[0].Signature != "Named((uint256,address),string)" {
+		t.Fatalf("unexpected signatures %v", sigs)
+	}
+	key := crypto.Keccak256Hash([]byte("key"))
+	data := append(word(big.NewInt(32)), word(big.NewInt(3))...)
+	data = append(data, common.RightPadBytes([]byte("foo"), 32)...)
+	logs, err := db.DecodeLog([]common.Hash{common.BytesToHash(sigs[0].ID), key}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `Named(bytes32 key=` + key.Hex() + `, string name="foo")`; logs[0].String() != want {
+		t.Errorf("got %s, want %s", logs[0], want)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sigdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := Default()
+	if _, err := db.AddText(strings.NewReader(collisions)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := db.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "sigs.json")
+	if err := ioutil.WriteFile(file, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	if n, err := loaded.AddFile(file); err != nil || n != db.Len() {
+		t.Fatalf("loaded %d of %d signatures: %v", n, db.Len(), err)
+	}
+	if n, _ := loaded.AddFile(file); n != 0 {
+		t.Errorf("re-adding added %d duplicates", n)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package storage decodes contract state from raw storage slots, using the
+// storageLayout output of the solidity compiler.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+// Layout is the storage layout of a contract as reported by solc with the
+// storageLayout output selection.
+type Layout struct {
+	Storage []Variable       `json:"storage"`
+	Types   map[string]*Type `json:"types"`
+}
+
+// Variable is a state variable, or a member of a struct type.
+type Variable struct {
+	AstID    int    `json:"astId"`
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   int    `json:"offset"` // byte offset within the slot, from the right
+	Slot     string `json:"slot"`   // decimal slot number, relative to the struct for members
+	Type     string `json:"type"`
+}
+
+// Type describes how values of a type are laid out in storage. Encoding is
+// one of inplace, mapping, dynamic_array or bytes.
+type Type struct {
+	Encoding      string     `json:"encoding"`
+	Label         string     `json:"label"`
+	NumberOfBytes string     `json:"numberOfBytes"`
+	Key           string     `json:"key,omitempty"`     // key type of mappings
+	Value         string     `json:"value,omitempty"`   // value type of mappings
+	Base          string     `json:"base,omitempty"`    // element type of arrays
+	Members       []Variable `json:"members,omitempty"` // members of structs
+}
+
+var staticArrayRegex = regexp.MustCompile(`\[(\d+)\]$`)
+
+// ParseLayout parses a storage layout. Besides the layout itself, it accepts
+// any JSON object carrying the layout in a storageLayout field, such as the
+// contract output of solc --standard-json or a build artifact.
+func ParseLayout(data []byte) (*Layout, error) {
+	var wrapper struct {
+		StorageLayout *Layout `json:"storageLayout"`
+		Layout
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	layout := wrapper.StorageLayout
+	if layout == nil {
+		if wrapper.Storage == nil && wrapper.Types == nil {
+			return nil, errors.New("storage: no storage layout found")
+		}
+		layout = &wrapper.Layout
+	}
+	// solc reports null types for contracts without state variables.
+	if layout.Types == nil {
+		layout.Types = make(map[string]*Type)
+	}
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+// Variable returns the state variable with the given label.
+func (l *Layout) Variable(label string) (*Variable, bool) {
+	for i := range l.Storage {
+		if l.Storage[i].Label == label {
+			return &l.Storage[i], true
+		}
+	}
+	return nil, false
+}
+
+// validate checks that all referenced types are defined and that slots and
+// sizes are numbers, so decoding does not need to.
+func (l *Layout) validate() error {
+	for _, v := range l.Storage {
+		if err := l.validateVariable(v); err != nil {
+			return err
+		}
+	}
+	for id, t := range l.Types {
+		if _, err := t.size(); err != nil {
+			return fmt.Errorf("storage: type %s: %v", id, err)
+		}
+		for _, ref := range []string{t.Key, t.Value, t.Base} {
+			if _, ok := l.Types[ref]; ref != "" && !ok {
+				return fmt.Errorf("storage: type %s refers to undefined type %s", id, ref)
+			}
+		}
+		switch t.Encoding {
+		case "inplace", "bytes":
+		case "mapping":
+			if t.Key == "" || t.Value == "" {
+				return fmt.Errorf("storage: mapping type %s has no key or value type", id)
+			}
+		case "dynamic_array":
+			if t.Base == "" {
+				return fmt.Errorf("storage: array type %s has no base type", id)
+			}
+		default:
+			return fmt.Errorf("storage: type %s has unknown encoding %q", id, t.Encoding)
+		}
+		if t.Encoding == "inplace" && t.Base != "" && !staticArrayRegex.MatchString(t.Label) {
+			return fmt.Errorf("storage: static array type %s has no length in label %q", id, t.Label)
+		}
+		for _, m := range t.Members {
+			if err := l.validateVariable(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *Layout) validateVariable(v Variable) error {
+	if _, ok := new(big.Int).SetString(v.Slot, 10); !ok {
+		return fmt.Errorf("storage: variable %s has invalid slot %q", v.Label, v.Slot)
+	}
+	if v.Offset < 0 || v.Offset > 31 {
+		return fmt.Errorf("storage: variable %s has invalid offset %d", v.Label, v.Offset)
+	}
+	if _, ok := l.Types[v.Type]; !ok {
+		return fmt.Errorf("storage: variable %s has undefined type %s", v.Label, v.Type)
+	}
+	return nil
+}
+
+// size returns the number of bytes a value of the type occupies in place.
+func (t *Type) size() (uint64, error) {
+	n, err := strconv.ParseUint(t.NumberOfBytes, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid size %q", t.NumberOfBytes)
+	}
+	return n, nil
+}
+
+// length returns the length of a static array type.
+func (t *Type) length() uint64 {
+	n, _ := strconv.ParseUint(staticArrayRegex.FindStringSubmatch(t.Label)[1], 10, 64)
+	return n
+}
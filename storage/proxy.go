@@ -0,0 +1,66 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
+)
+
+// ERC-1967 proxy slots, keccak256 of the slot name minus one.
+var (
+	ImplementationSlot = proxySlot("eip1967.proxy.implementation")
+	AdminSlot          = proxySlot("eip1967.proxy.admin")
+	BeaconSlot         = proxySlot("eip1967.proxy.beacon")
+)
+
+func proxySlot(name string) common.Hash {
+	slot := new(big.Int).SetBytes(crypto.Keccak256([]byte(name)))
+	return common.BigToHash(slot.Sub(slot, big.NewInt(1)))
+}
+
+// Proxy holds the ERC-1967 slots of a proxy contract. Unset slots are the
+// zero address.
+type Proxy struct {
+	Implementation common.Address `json:"implementation"`
+	Admin          common.Address `json:"admin"`
+	Beacon         common.Address `json:"beacon"`
+}
+
+// ReadProxy reads the ERC-1967 slots of the contract at address at the given
+// block, nil meaning the latest block.
+func ReadProxy(ctx context.Context, reader StorageReader, address common.Address, block *big.Int) (*Proxy, error) {
+	var proxy Proxy
+	for _, slot := range []struct {
+		key common.Hash
+		dst *common.Address
+	}{
+		{ImplementationSlot, &proxy.Implementation},
+		{AdminSlot, &proxy.Admin},
+		{BeaconSlot, &proxy.Beacon},
+	} {
+		data, err := reader.StorageAt(ctx, address, slot.key, block)
+		if err != nil {
+			return nil, err
+		}
+		*slot.dst = common.BytesToAddress(data)
+	}
+	return &proxy, nil
+}
@@ -0,0 +1,490 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethclient/abi"
+	"github.com/ethclient/common"
+	"github.com/ethclient/common/hexutil"
+	"github.com/ethclient/crypto"
+)
+
+// DefaultMaxElements is the default limit on the number of elements of a
+// dynamic array, and of 32 byte words of a string or bytes value, that a
+// Contract reads when decoding a whole value.
+const DefaultMaxElements = 1024
+
+// StorageReader reads raw storage slots. It is implemented by
+// ethclient.Client.
+type StorageReader interface {
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// Contract decodes the state of a deployed contract. For a proxy, use the
+// proxy address with the storage layout of the implementation.
+type Contract struct {
+	reader  StorageReader
+	address common.Address
+	layout  *Layout
+	block   *big.Int
+
+	// MaxElements limits the size of dynamic values decoded as a whole.
+	// Larger arrays can still be read element by element.
+	MaxElements uint64
+}
+
+// NewContract creates a decoder for the state of the contract at address,
+// reading the latest block.
+func NewContract(reader StorageReader, address common.Address, layout *Layout) *Contract {
+	return &Contract{
+		reader:      reader,
+		address:     address,
+		layout:      layout,
+		MaxElements: DefaultMaxElements,
+	}
+}
+
+// At returns a copy of the decoder reading the state at the given block, nil
+// meaning the latest block.
+func (c *Contract) At(block *big.Int) *Contract {
+	cpy := *c
+	cpy.block = block
+	return &cpy
+}
+
+// Read decodes the value at path, which names a state variable followed by
+// struct members (.member), array indexes ([3]) and mapping keys
+// ([0x6b17...], ["some key"]), e.g. balances[0x5b38...].amount or items[2].
+//
+// Integers and enums decode to *big.Int, addresses and contracts to
+// common.Address, bool to bool, string to string, other byte types to
+// hexutil.Bytes, structs to map[string]interface{} and arrays to
+// []interface{}. Mappings can not be read without a key.
+func (c *Contract) Read(ctx context.Context, path string) (interface{}, error) {
+	s := c.session(ctx)
+	loc, err := s.locate(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(loc, path)
+}
+
+// Slot returns the slot and the byte offset within it of the value at path.
+func (c *Contract) Slot(ctx context.Context, path string) (common.Hash, int, error) {
+	loc, err := c.session(ctx).locate(path)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	return common.BigToHash(loc.slot), loc.offset, nil
+}
+
+// ReadAll decodes all state variables except mappings, keyed by label.
+func (c *Contract) ReadAll(ctx context.Context) (map[string]interface{}, error) {
+	s := c.session(ctx)
+	values := make(map[string]interface{}, len(c.layout.Storage))
+	for _, v := range c.layout.Storage {
+		if c.layout.Types[v.Type].Encoding == "mapping" {
+			continue
+		}
+		loc, err := s.locate(v.Label)
+		if err != nil {
+			return nil, err
+		}
+		if values[v.Label], err = s.decode(loc, v.Label); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (c *Contract) session(ctx context.Context) *session {
+	return &session{ctx: ctx, c: c, slots: make(map[common.Hash]common.Hash)}
+}
+
+// session caches the slots read while decoding, so packed variables are
+// fetched once.
+type session struct {
+	ctx   context.Context
+	c     *Contract
+	slots map[common.Hash]common.Hash
+}
+
+// location is where a value of type typ is stored.
+type location struct {
+	slot   *big.Int
+	offset int
+	typ    *Type
+}
+
+func (s *session) slot(slot *big.Int) (common.Hash, error) {
+	key := common.BigToHash(slot)
+	if word, ok := s.slots[key]; ok {
+		return word, nil
+	}
+	data, err := s.c.reader.StorageAt(s.ctx, s.c.address, key, s.c.block)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	word := common.BytesToHash(data)
+	s.slots[key] = word
+	return word, nil
+}
+
+// locate resolves path to the location of its value.
+func (s *session) locate(path string) (*location, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	v, ok := s.c.layout.Variable(steps[0].name)
+	if !ok {
+		return nil, fmt.Errorf("storage: no state variable %s", steps[0].name)
+	}
+	slot, _ := new(big.Int).SetString(v.Slot, 10)
+	loc := &location{slot: slot, offset: v.Offset, typ: s.c.layout.Types[v.Type]}
+	at := steps[0].name
+	for _, step := range steps[1:] {
+		if loc, err = s.step(loc, step, at); err != nil {
+			return nil, err
+		}
+		at += step.String()
+	}
+	return loc, nil
+}
+
+// step resolves a member or key of the value at loc, which is found at path.
+func (s *session) step(loc *location, step pathStep, path string) (*location, error) {
+	types, t := s.c.layout.Types, loc.typ
+	if !step.key {
+		for _, m := range t.Members {
+			if m.Label == step.name {
+				slot, _ := new(big.Int).SetString(m.Slot, 10)
+				return &location{slot: slot.Add(slot, loc.slot), offset: m.Offset, typ: types[m.Type]}, nil
+			}
+		}
+		return nil, fmt.Errorf("storage: %s (%s) has no member %s", path, t.Label, step.name)
+	}
+	switch {
+	case t.Encoding == "mapping":
+		key, err := encodeKey(types[t.Key], step.name)
+		if err != nil {
+			return nil, fmt.Errorf("storage: key of %s: %v", path, err)
+		}
+		slot := crypto.Keccak256(key, common.BigToHash(loc.slot).Bytes())
+		return &location{slot: new(big.Int).SetBytes(slot), typ: types[t.Value]}, nil
+
+	case t.Encoding == "dynamic_array":
+		index, err := parseIndex(step.name, path)
+		if err != nil {
+			return nil, err
+		}
+		length, err := s.slot(loc.slot)
+		if err != nil {
+			return nil, err
+		}
+		if n := length.Big(); n.Cmp(new(big.Int).SetUint64(index)) <= 0 {
+			return nil, fmt.Errorf("storage: index %d out of range, %s has %v elements", index, path, n)
+		}
+		return element(dataSlot(loc.slot), index, types[t.Base]), nil
+
+	case t.Encoding == "inplace" && t.Base != "":
+		index, err := parseIndex(step.name, path)
+		if err != nil {
+			return nil, err
+		}
+		if n := t.length(); index >= n {
+			return nil, fmt.Errorf("storage: index %d out of range, %s has %d elements", index, path, n)
+		}
+		return element(loc.slot, index, types[t.Base]), nil
+	}
+	return nil, fmt.Errorf("storage: %s (%s) is not an array or mapping", path, t.Label)
+}
+
+// element returns the location of the index-th element of an array of elem
+// starting at base. Elements smaller than a slot are packed.
+func element(base *big.Int, index uint64, elem *Type) *location {
+	size, _ := elem.size()
+	slot := new(big.Int).Set(base)
+	if size < 32 {
+		perSlot := 32 / size
+		slot.Add(slot, new(big.Int).SetUint64(index/perSlot))
+		return &location{slot: slot, offset: int(index % perSlot * size), typ: elem}
+	}
+	slots := new(big.Int).SetUint64((size + 31) / 32)
+	slot.Add(slot, slots.Mul(slots, new(big.Int).SetUint64(index)))
+	return &location{slot: slot, typ: elem}
+}
+
+// dataSlot returns the first slot of the data of the dynamic value at slot.
+func dataSlot(slot *big.Int) *big.Int {
+	return new(big.Int).SetBytes(crypto.Keccak256(common.BigToHash(slot).Bytes()))
+}
+
+// decode decodes the value at loc, which is found at path.
+func (s *session) decode(loc *location, path string) (interface{}, error) {
+	types, t := s.c.layout.Types, loc.typ
+	switch {
+	case t.Encoding == "mapping":
+		return nil, fmt.Errorf("storage: %s is a mapping, add a key to read it", path)
+
+	case t.Encoding == "bytes":
+		data, err := s.bytes(loc.slot, path)
+		if err != nil {
+			return nil, err
+		}
+		if t.Label == "string" {
+			return string(data), nil
+		}
+		return hexutil.Bytes(data), nil
+
+	case t.Encoding == "dynamic_array":
+		word, err := s.slot(loc.slot)
+		if err != nil {
+			return nil, err
+		}
+		length := word.Big()
+		if !length.IsUint64() || length.Uint64() > s.c.MaxElements {
+			return nil, fmt.Errorf("storage: %s has %v elements, more than the limit of %d", path, length, s.c.MaxElements)
+		}
+		return s.array(dataSlot(loc.slot), length.Uint64(), types[t.Base], path)
+
+	case t.Base != "":
+		return s.array(loc.slot, t.length(), types[t.Base], path)
+
+	case len(t.Members) > 0:
+		values := make(map[string]interface{}, len(t.Members))
+		for _, m := range t.Members {
+			member, err := s.step(loc, pathStep{name: m.Label}, path)
+			if err != nil {
+				return nil, err
+			}
+			if values[m.Label], err = s.decode(member, path+"."+m.Label); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	word, err := s.slot(loc.slot)
+	if err != nil {
+		return nil, err
+	}
+	size, _ := t.size()
+	end := 32 - loc.offset
+	if start := end - int(size); start >= 0 {
+		return decodeValue(t, word[start:end]), nil
+	}
+	return nil, fmt.Errorf("storage: %s (%s) does not fit at offset %d", path, t.Label, loc.offset)
+}
+
+func (s *session) array(base *big.Int, length uint64, elem *Type, path string) ([]interface{}, error) {
+	values := make([]interface{}, length)
+	for i := range values {
+		var err error
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		if values[i], err = s.decode(element(base, uint64(i), elem), elemPath); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// bytes reads a string or bytes value. Values shorter than 32 bytes are
+// stored in the slot itself with twice the length in the lowest byte, longer
+// ones store twice the length plus one and the data from keccak256(slot).
+func (s *session) bytes(slot *big.Int, path string) ([]byte, error) {
+	word, err := s.slot(slot)
+	if err != nil {
+		return nil, err
+	}
+	if word[31]&1 == 0 {
+		length := int(word[31] / 2)
+		if length > 31 {
+			return nil, fmt.Errorf("storage: %s has invalid short length %d", path, length)
+		}
+		return common.CopyBytes(word[:length]), nil
+	}
+	length := new(big.Int).Rsh(word.Big(), 1)
+	words := new(big.Int).Add(length, big.NewInt(31))
+	words.Rsh(words, 5)
+	if !words.IsUint64() || words.Uint64() > s.c.MaxElements {
+		return nil, fmt.Errorf("storage: %s has %v bytes, more than the limit of %d words", path, length, s.c.MaxElements)
+	}
+	data := make([]byte, 0, words.Uint64()*32)
+	base := dataSlot(slot)
+	for i := uint64(0); i < words.Uint64(); i++ {
+		word, err := s.slot(new(big.Int).Add(base, new(big.Int).SetUint64(i)))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, word[:]...)
+	}
+	return data[:length.Uint64()], nil
+}
+
+// decodeValue decodes a value type from its bytes in the slot.
+func decodeValue(t *Type, data []byte) interface{} {
+	label := t.Label
+	switch {
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(data)
+	case strings.HasPrefix(label, "int"):
+		v := new(big.Int).SetBytes(data)
+		if data[0]&0x80 != 0 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+		}
+		return v
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		return common.BytesToAddress(data)
+	case label == "bool":
+		return data[len(data)-1] != 0
+	}
+	return hexutil.Bytes(common.CopyBytes(data))
+}
+
+// encodeKey encodes a mapping key the way solidity hashes it: value types are
+// padded to 32 bytes, strings and bytes are used as is.
+func encodeKey(t *Type, s string) ([]byte, error) {
+	label := t.Label
+	switch {
+	case label == "string":
+		return []byte(s), nil
+	case label == "bytes":
+		return hexutil.Decode(s)
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "int") || strings.HasPrefix(label, "enum "):
+		v, err := abi.ParseInteger(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", label, s)
+		}
+		size, _ := t.size()
+		bits := uint(size * 8)
+		lo, hi := big.NewInt(0), new(big.Int).Lsh(big.NewInt(1), bits)
+		if strings.HasPrefix(label, "int") {
+			lo.Neg(hi.Rsh(hi, 1))
+		}
+		if v.Cmp(lo) < 0 || v.Cmp(hi) >= 0 {
+			return nil, fmt.Errorf("%s %q out of range", label, s)
+		}
+		if v.Sign() < 0 {
+			v.Add(v, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return common.BigToHash(v).Bytes(), nil
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		return common.HexToAddress(s).Hash().Bytes(), nil
+	case label == "bool":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool %q", s)
+		}
+		if b {
+			return common.BigToHash(big.NewInt(1)).Bytes(), nil
+		}
+		return make([]byte, 32), nil
+	case strings.HasPrefix(label, "bytes"):
+		data, err := hexutil.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", label, s, err)
+		}
+		if size, _ := t.size(); uint64(len(data)) > size {
+			return nil, fmt.Errorf("%s %q is too long", label, s)
+		}
+		return common.RightPadBytes(data, 32), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", label)
+}
+
+func parseIndex(s, path string) (uint64, error) {
+	index, err := abi.ParseInteger(s)
+	if err != nil || index.Sign() < 0 || !index.IsUint64() {
+		return 0, fmt.Errorf("storage: invalid index %q of %s", s, path)
+	}
+	return index.Uint64(), nil
+}
+
+// pathStep is a member name, or the text of an index or key.
+type pathStep struct {
+	name string
+	key  bool
+}
+
+func (p pathStep) String() string {
+	if p.key {
+		return "[" + p.name + "]"
+	}
+	return "." + p.name
+}
+
+// parsePath splits a path like items[2].owner into its steps. Keys may be
+// double quoted, e.g. names["a]b"].
+func parsePath(path string) ([]pathStep, error) {
+	var steps []pathStep
+	rest := path
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == '[':
+			rest = rest[1:]
+			var key string
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.Index(rest[1:], `"]`) + 1
+				if end == 0 {
+					return nil, fmt.Errorf("storage: unterminated key in path %q", path)
+				}
+				unquoted, err := strconv.Unquote(rest[:end+1])
+				if err != nil {
+					return nil, fmt.Errorf("storage: invalid key in path %q", path)
+				}
+				key, rest = unquoted, rest[end+1:]
+			} else {
+				end := strings.IndexByte(rest, ']')
+				if end < 0 {
+					return nil, fmt.Errorf("storage: unterminated key in path %q", path)
+				}
+				key = strings.TrimSpace(rest[:end])
+				rest = rest[end:]
+			}
+			steps = append(steps, pathStep{name: key, key: true})
+			rest = rest[1:]
+		case rest[0] == '.' && len(steps) > 0 || len(steps) == 0:
+			if len(steps) > 0 {
+				rest = rest[1:]
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("storage: empty name in path %q", path)
+			}
+			steps = append(steps, pathStep{name: rest[:end]})
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("storage: invalid path %q", path)
+		}
+	}
+	if len(steps) == 0 || steps[0].key {
+		return nil, fmt.Errorf("storage: path %q does not start with a variable", path)
+	}
+	return steps, nil
+}
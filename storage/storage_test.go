@@ -0,0 +1,243 @@
+// Copyright 2021 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
+)
+
+// Storage layout of
+//
+//	contract Sample {
+//		struct Item { uint256 id; address owner; uint32 qty; }
+//		address owner; bool paused; uint64 count; int16 delta;
+//		mapping(address => uint256) balances;
+//		mapping(uint256 => mapping(address => bool)) approvals;
+//		uint128[] amounts;
+//		string name;
+//		bytes data;
+//		Item[] items;
+//		uint8[3] small;
+//		mapping(string => Item) byName;
+//	}
+const sampleLayout = `{"storageLayout": {
+	"storage": [
+		{"astId": 1, "contract": "Sample.sol:Sample", "label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+		{"astId": 2, "contract": "Sample.sol:Sample", "label": "paused", "offset": 20, "slot": "0", "type": "t_bool"},
+		{"astId": 3, "contract": "Sample.sol:Sample", "label": "count", "offset": 21, "slot": "0", "type": "t_uint64"},
+		{"astId": 4, "contract": "Sample.sol:Sample", "label": "delta", "offset": 29, "slot": "0", "type": "t_int16"},
+		{"astId": 5, "contract": "Sample.sol:Sample", "label": "balances", "offset": 0, "slot": "1", "type": "t_mapping(t_address,t_uint256)"},
+		{"astId": 6, "contract": "Sample.sol:Sample", "label": "approvals", "offset": 0, "slot": "2", "type": "t_mapping(t_uint256,t_mapping(t_address,t_bool))"},
+		{"astId": 7, "contract": "Sample.sol:Sample", "label": "amounts", "offset": 0, "slot": "3", "type": "t_array(t_uint128)dyn_storage"},
+		{"astId": 8, "contract": "Sample.sol:Sample", "label": "name", "offset": 0, "slot": "4", "type": "t_string_storage"},
+		{"astId": 9, "contract": "Sample.sol:Sample", "label": "data", "offset": 0, "slot": "5", "type": "t_bytes_storage"},
+		{"astId": 10, "contract": "Sample.sol:Sample", "label": "items", "offset": 0, "slot": "6", "type": "t_array(t_struct(Item)12_storage)dyn_storage"},
+		{"astId": 11, "contract": "Sample.sol:Sample", "label": "small", "offset": 0, "slot": "7", "type": "t_array(t_uint8)3_storage"},
+		{"astId": 13, "contract": "Sample.sol:Sample", "label": "byName", "offset": 0, "slot": "8", "type": "t_mapping(t_string_memory_ptr,t_struct(Item)12_storage)"}
+	],
+	"types": {
+		"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+		"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+		"t_uint8": {"encoding": "inplace", "label": "uint8", "numberOfBytes": "1"},
+		"t_uint32": {"encoding": "inplace", "label": "uint32", "numberOfBytes": "4"},
+		"t_uint64": {"encoding": "inplace", "label": "uint64", "numberOfBytes": "8"},
+		"t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+		"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+		"t_int16": {"encoding": "inplace", "label": "int16", "numberOfBytes": "2"},
+		"t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+		"t_string_memory_ptr": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+		"t_bytes_storage": {"encoding": "bytes", "label": "bytes", "numberOfBytes": "32"},
+		"t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+		"t_mapping(t_address,t_bool)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => bool)", "numberOfBytes": "32", "value": "t_bool"},
+		"t_mapping(t_uint256,t_mapping(t_address,t_bool))": {"encoding": "mapping", "key": "t_uint256", "label": "mapping(uint256 => mapping(address => bool))", "numberOfBytes": "32", "value": "t_mapping(t_address,t_bool)"},
+		"t_mapping(t_string_memory_ptr,t_struct(Item)12_storage)": {"encoding": "mapping", "key": "t_string_memory_ptr", "label": "mapping(string => struct Sample.Item)", "numberOfBytes": "32", "value": "t_struct(Item)12_storage"},
+		"t_array(t_uint128)dyn_storage": {"base": "t_uint128", "encoding": "dynamic_array", "label": "uint128[]", "numberOfBytes": "32"},
+		"t_array(t_uint8)3_storage": {"base": "t_uint8", "encoding": "inplace", "label": "uint8[3]", "numberOfBytes": "32"},
+		"t_array(t_struct(Item)12_storage)dyn_storage": {"base": "t_struct(Item)12_storage", "encoding": "dynamic_array", "label": "struct Sample.Item[]", "numberOfBytes": "32"},
+		"t_struct(Item)12_storage": {"encoding": "inplace", "label": "struct Sample.Item", "numberOfBytes": "64", "members": [
+			{"astId": 14, "contract": "Sample.sol:Sample", "label": "id", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"astId": 15, "contract": "Sample.sol:Sample", "label": "owner", "offset": 0, "slot": "1", "type": "t_address"},
+			{"astId": 16, "contract": "Sample.sol:Sample", "label": "qty", "offset": 20, "slot": "1", "type": "t_uint32"}
+		]}
+	}
+}}`
+
+type memStorage map[common.Hash]common.Hash
+
+func (m memStorage) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	word := m[key]
+	return word[:], nil
+}
+
+// set writes data into the slot, ending offset bytes from the right.
+func (m memStorage) set(slot common.Hash, offset int, data []byte) {
+	word := m[slot]
+	copy(word[32-offset-len(data):], data)
+	m[slot] = word
+}
+
+func slotHash(data ...[]byte) common.Hash {
+	return crypto.Keccak256Hash(data...)
+}
+
+func pad(n int64) []byte {
+	return common.BigToHash(big.NewInt(n)).Bytes()
+}
+
+func add(slot common.Hash, n int64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(slot.Big(), big.NewInt(n)))
+}
+
+func sampleStorage() memStorage {
+	m := make(memStorage)
+	zero := common.Hash{}
+	m.set(zero, 0, common.HexToAddress("0xaa").Bytes())
+	m.set(zero, 20, []byte{1})
+	m.set(zero, 21, pad(7)[24:])
+	m.set(zero, 29, []byte{0xff, 0xfe})
+	m.set(slotHash(common.HexToAddress("0xbb").Hash().Bytes(), pad(1)), 0, pad(100))
+	m.set(slotHash(common.HexToAddress("0xcc").Hash().Bytes(), slotHash(pad(5), pad(2)).Bytes()), 0, []byte{1})
+
+	m.set(common.BigToHash(big.NewInt(3)), 0, pad(3))
+	amounts := slotHash(pad(3))
+	m.set(amounts, 0, pad(10)[16:])
+	m.set(amounts, 16, pad(20)[16:])
+	m.set(add(amounts, 1), 0, pad(30)[16:])
+
+	name := common.BigToHash(big.NewInt(4))
+	m.set(name, 0, []byte{10})
+	m.set(name, 27, []byte("hello"))
+
+	m.set(common.BigToHash(big.NewInt(5)), 0, pad(81))
+	data := slotHash(pad(5))
+	for i := 0; i < 40; i++ {
+		word := add(data, int64(i/32))
+		m.set(word, 31-i%32, []byte{byte(i)})
+	}
+
+	m.set(common.BigToHash(big.NewInt(6)), 0, pad(1))
+	items := slotHash(pad(6))
+	m.set(items, 0, pad(9))
+	m.set(add(items, 1), 0, common.HexToAddress("0xdd").Bytes())
+	m.set(add(items, 1), 20, pad(4)[28:])
+
+	small := common.BigToHash(big.NewInt(7))
+	m.set(small, 0, []byte{1})
+	m.set(small, 1, []byte{2})
+	m.set(small, 2, []byte{3})
+
+	m.set(slotHash([]byte("x"), pad(8)), 0, pad(42))
+	return m
+}
+
+func TestReadStorage(t *testing.T) {
+	layout, err := ParseLayout([]byte(sampleLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewContract(sampleStorage(), common.HexToAddress("0x01"), layout)
+	ctx := context.Background()
+
+	all, err := c.ReadAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(all)
+	want := `{"amounts":[10,20,30],"count":7,"data":"0x000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f2021222324252627","delta":-2,"items":[{"id":9,"owner":"0x00000000000000000000000000000000000000dd","qty":4}],"name":"hello","owner":"0x00000000000000000000000000000000000000aa","paused":true,"small":[1,2,3]}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+
+	for path, want := range map[string]string{
+		`balances[0x00000000000000000000000000000000000000bb]`:       `100`,
+		`balances[0x00000000000000000000000000000000000000ee]`:       `0`,
+		`approvals[5][0x00000000000000000000000000000000000000cc]`:   `true`,
+		`approvals[0x5][0x00000000000000000000000000000000000000cc]`: `true`,
+		`approvals[05][0x00000000000000000000000000000000000000cc]`:  `true`,
+		`approvals[5e0][0x00000000000000000000000000000000000000cc]`: `true`,
+		`amounts[2]`:     `30`,
+		`items[0].qty`:   `4`,
+		`small[1]`:       `2`,
+		`byName["x"].id`: `42`,
+		`byName[x]`:      `{"id":42,"owner":"0x0000000000000000000000000000000000000000","qty":0}`,
+	} {
+		value, err := c.Read(ctx, path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if out, _ := json.Marshal(value); string(out) != want {
+			t.Errorf("%s: got %s, want %s", path, out, want)
+		}
+	}
+
+	for path, want := range map[string]string{
+		`balances`:        `storage: balances is a mapping, add a key to read it`,
+		`amounts[3]`:      `storage: index 3 out of range, amounts has 3 elements`,
+		`small[3]`:        `storage: index 3 out of range, small has 3 elements`,
+		`items[0].amount`: `storage: items[0] (struct Sample.Item) has no member amount`,
+		`balances[0x12]`:  `storage: key of balances: invalid address "0x12"`,
+		`owner[1]`:        `storage: owner (address) is not an array or mapping`,
+		`supply`:          `storage: no state variable supply`,
+		`items[0`:         `storage: unterminated key in path "items[0"`,
+		`amounts[1_0]`:    `storage: invalid index "1_0" of amounts`,
+		`amounts[0b1]`:    `storage: invalid index "0b1" of amounts`,
+		`amounts[-1]`:     `storage: invalid index "-1" of amounts`,
+		`approvals[0x-5]`: `storage: key of approvals: invalid uint256 "0x-5"`,
+	} {
+		if _, err := c.Read(ctx, path); err == nil || err.Error() != want {
+			t.Errorf("%s: got error %v, want %s", path, err, want)
+		}
+	}
+
+	slot, offset, err := c.Slot(ctx, "count")
+	if err != nil || slot != (common.Hash{}) || offset != 21 {
+		t.Errorf("unexpected slot %x offset %d: %v", slot, offset, err)
+	}
+	c.MaxElements = 1
+	if _, err := c.Read(ctx, "amounts"); err == nil {
+		t.Errorf("expected error reading more than MaxElements elements")
+	}
+}
+
+func TestProxySlots(t *testing.T) {
+	for slot, want := range map[common.Hash]string{
+		ImplementationSlot: "0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc",
+		AdminSlot:          "0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103",
+		BeaconSlot:         "0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50",
+	} {
+		if slot.Hex() != want {
+			t.Errorf("got slot %s, want %s", slot.Hex(), want)
+		}
+	}
+	m := make(memStorage)
+	m.set(ImplementationSlot, 0, common.HexToAddress("0x11").Bytes())
+	m.set(AdminSlot, 0, common.HexToAddress("0x22").Bytes())
+	proxy, err := ReadProxy(context.Background(), m, common.HexToAddress("0x01"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxy.Implementation != common.HexToAddress("0x11") || proxy.Admin != common.HexToAddress("0x22") || proxy.Beacon != (common.Address{}) {
+		t.Errorf("unexpected proxy slots %+v", proxy)
+	}
+}
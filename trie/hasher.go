@@ -0,0 +1,62 @@
+// Copyright 2015 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"github.com/ethclient/crypto"
+	"github.com/ethclient/rlp"
+)
+
+// hash collapses a node down into a hash node, also returning a copy of the
+// original node with the computed hash of its children. Nodes whose encoding
+// is shorter than 32 bytes are embedded into their parent instead, unless
+// force is set (which is the case for the root node).
+func hash(n node, force bool) (hashed node, collapsed node) {
+	switch n := n.(type) {
+	case *shortNode:
+		collapsed := n.copy()
+		collapsed.Key = hexToCompact(n.Key)
+		if _, ok := n.Val.(valueNode); !ok {
+			collapsed.Val, _ = hash(n.Val, false)
+		}
+		return store(collapsed, force), collapsed
+	case *fullNode:
+		collapsed := n.copy()
+		for i := 0; i < 16; i++ {
+			if n.Children[i] != nil {
+				collapsed.Children[i], _ = hash(n.Children[i], false)
+			}
+		}
+		return store(collapsed, force), collapsed
+	default:
+		// Value and hash nodes don't have children so they're left as were
+		return n, n
+	}
+}
+
+// store returns the node itself if its encoding is small enough to be
+// embedded, otherwise the keccak256 hash of the encoding.
+func store(n node, force bool) node {
+	enc, err := rlp.EncodeToBytes(n)
+	if err != nil {
+		panic("encode error: " + err.Error())
+	}
+	if len(enc) < 32 && !force {
+		return n
+	}
+	return hashNode(crypto.Keccak256(enc))
+}
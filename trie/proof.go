@@ -0,0 +1,137 @@
+// Copyright 2015 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethclient/common"
+	"github.com/ethclient/crypto"
+	"github.com/ethclient/rlp"
+)
+
+// Prove constructs a Merkle proof for key. The result contains the RLP
+// encodings of all nodes on the path to the value, starting with the root.
+// Nodes embedded into their parent are not included, which matches the
+// accountProof and storageProof lists returned by eth_getProof.
+//
+// If the trie does not contain a value for key, the returned proof contains
+// all nodes of the longest existing prefix of the key, proving the absence
+// of the key.
+func (t *Trie) Prove(key []byte) [][]byte {
+	var nodes []node
+	tn, k := t.root, keybytesToHex(key)
+	for len(k) > 0 && tn != nil {
+		switch n := tn.(type) {
+		case *shortNode:
+			if len(k) < len(n.Key) || !bytes.Equal(n.Key, k[:len(n.Key)]) {
+				// The trie doesn't contain the key.
+				tn = nil
+			} else {
+				tn = n.Val
+				k = k[len(n.Key):]
+			}
+			nodes = append(nodes, n)
+		case *fullNode:
+			tn = n.Children[k[0]]
+			k = k[1:]
+			nodes = append(nodes, n)
+		case valueNode:
+			tn = nil
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
+		}
+	}
+	var proof [][]byte
+	for i, n := range nodes {
+		hashed, collapsed := hash(n, i == 0)
+		if _, ok := hashed.(hashNode); ok {
+			enc, err := rlp.EncodeToBytes(collapsed)
+			if err != nil {
+				panic("encode error: " + err.Error())
+			}
+			proof = append(proof, enc)
+		}
+	}
+	return proof
+}
+
+// VerifyProof checks a Merkle proof for key against rootHash. The proof
+// nodes may be given in any order. It returns the value stored for key, or
+// nil if the proof shows that the key is absent. An error is returned if the
+// proof is incomplete or does not hash to rootHash.
+func VerifyProof(rootHash common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, enc := range proof {
+		nodes[crypto.Keccak256Hash(enc)] = enc
+	}
+	k := keybytesToHex(key)
+	wantHash := rootHash
+	for i := 0; ; i++ {
+		buf, ok := nodes[wantHash]
+		if !ok {
+			if i == 0 && rootHash == EmptyRoot {
+				// Everything is absent from the empty trie.
+				return nil, nil
+			}
+			return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash)
+		}
+		n, err := decodeNode(wantHash[:], buf)
+		if err != nil {
+			return nil, fmt.Errorf("bad proof node %d: %v", i, err)
+		}
+		keyrest, cld := get(n, k)
+		switch cld := cld.(type) {
+		case nil:
+			// The trie doesn't contain the key.
+			return nil, nil
+		case hashNode:
+			k = keyrest
+			copy(wantHash[:], cld)
+		case valueNode:
+			return cld, nil
+		}
+	}
+}
+
+// get walks a decoded proof node along key, descending into embedded nodes,
+// and returns the rest of the key together with the first hash or value
+// node reached.
+func get(tn node, key []byte) ([]byte, node) {
+	for {
+		switch n := tn.(type) {
+		case *shortNode:
+			if len(key) < len(n.Key) || !bytes.Equal(n.Key, key[:len(n.Key)]) {
+				return nil, nil
+			}
+			tn = n.Val
+			key = key[len(n.Key):]
+		case *fullNode:
+			tn = n.Children[key[0]]
+			key = key[1:]
+		case hashNode:
+			return key, n
+		case nil:
+			return key, nil
+		case valueNode:
+			return nil, n
+		default:
+			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2015 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/ethclient/crypto"
+)
+
+func randomTrie(n int) (*Trie, map[string][]byte) {
+	trie := New()
+	vals := make(map[string][]byte)
+	for i := byte(0); i < 100; i++ {
+		// small values get embedded into their parents
+		k, v := []byte{i}, []byte{i, 1}
+		trie.Update(k, v)
+		vals[string(k)] = v
+	}
+	for i := 0; i < n; i++ {
+		k, v := make([]byte, 32), make([]byte, 20)
+		rand.Read(k)
+		rand.Read(v)
+		trie.Update(k, v)
+		vals[string(k)] = v
+	}
+	return trie, vals
+}
+
+func TestProof(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+	for k, v := range vals {
+		proof := trie.Prove([]byte(k))
+		if len(proof) == 0 {
+			t.Fatalf("missing key %x while constructing proof", k)
+		}
+		val, err := VerifyProof(root, []byte(k), proof)
+		if err != nil {
+			t.Fatalf("VerifyProof error for key %x: %v", k, err)
+		}
+		if !bytes.Equal(val, v) {
+			t.Fatalf("VerifyProof returned wrong value for key %x: got %x, want %x", k, val, v)
+		}
+	}
+}
+
+func TestMissingKeyProof(t *testing.T) {
+	trie := New()
+	trie.Update([]byte("k"), []byte("v"))
+	for _, key := range []string{"a", "j", "l", "z"} {
+		proof := trie.Prove([]byte(key))
+		if len(proof) != 1 {
+			t.Errorf("test %s: proof length %d, want 1", key, len(proof))
+		}
+		val, err := VerifyProof(trie.Hash(), []byte(key), proof)
+		if err != nil || val != nil {
+			t.Errorf("test %s: verified value %x, error %v; want no value", key, val, err)
+		}
+	}
+	if val, err := VerifyProof(EmptyRoot, []byte("k"), nil); err != nil || val != nil {
+		t.Errorf("empty trie: value %x, error %v", val, err)
+	}
+}
+
+func TestBadProof(t *testing.T) {
+	trie, vals := randomTrie(800)
+	root := trie.Hash()
+	for k := range vals {
+		proof := trie.Prove([]byte(k))
+		i := rand.Intn(len(proof))
+		mutated := append([]byte{}, proof[i]...)
+		mutated[rand.Intn(len(mutated))] ^= 0xff
+		proof[i] = mutated
+		if _, err := VerifyProof(root, []byte(k), proof); err == nil {
+			t.Fatalf("expected proof to fail for key %x", k)
+		}
+	}
+	// a valid proof for another root must not verify
+	k := crypto.Keccak256([]byte("missing"))
+	if _, err := VerifyProof(crypto.Keccak256Hash(k), k, trie.Prove(k)); err == nil {
+		t.Fatal("proof verified against the wrong root")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2015 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package trie implements an in-memory Merkle Patricia Trie, enough to
+// compute state, transaction and receipt roots and to create and verify
+// Merkle proofs offline.
+package trie
+
+import (
+	"bytes"
+
+	"github.com/ethclient/common"
+)
+
+// EmptyRoot is the known root hash of an empty trie.
+var EmptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// Trie is an in-memory Merkle Patricia Trie. The zero value is an empty
+// trie. Trie is not safe for concurrent use.
+type Trie struct {
+	root node
+}
+
+// New creates an empty trie.
+func New() *Trie {
+	return new(Trie)
+}
+
+// Get returns the value stored for key, or nil if the key is not present.
+func (t *Trie) Get(key []byte) []byte {
+	n, key := t.root, keybytesToHex(key)
+	for {
+		switch nd := n.(type) {
+		case nil:
+			return nil
+		case valueNode:
+			if len(key) == 0 {
+				return nd
+			}
+			return nil
+		case *shortNode:
+			if len(key) < len(nd.Key) || !bytes.Equal(nd.Key, key[:len(nd.Key)]) {
+				return nil
+			}
+			n, key = nd.Val, key[len(nd.Key):]
+		case *fullNode:
+			n, key = nd.Children[key[0]], key[1:]
+		default:
+			panic("unexpected node type in memory trie")
+		}
+	}
+}
+
+// Update associates key with value in the trie. An empty value deletes the
+// key from the trie.
+func (t *Trie) Update(key, value []byte) {
+	k := keybytesToHex(key)
+	if len(value) != 0 {
+		t.root = t.insert(t.root, k, valueNode(common.CopyBytes(value)))
+	} else {
+		t.root = t.delete(t.root, k)
+	}
+}
+
+// Delete removes any existing value for key from the trie.
+func (t *Trie) Delete(key []byte) {
+	t.root = t.delete(t.root, keybytesToHex(key))
+}
+
+// Hash returns the root hash of the trie.
+func (t *Trie) Hash() common.Hash {
+	if t.root == nil {
+		return EmptyRoot
+	}
+	hashed, _ := hash(t.root, true)
+	return common.BytesToHash(hashed.(hashNode))
+}
+
+func (t *Trie) insert(n node, key []byte, value node) node {
+	if len(key) == 0 {
+		return value
+	}
+	switch n := n.(type) {
+	case *shortNode:
+		matchlen := prefixLen(key, n.Key)
+		// If the whole key matches, keep this short node as is
+		// and only update the value.
+		if matchlen == len(n.Key) {
+			return &shortNode{n.Key, t.insert(n.Val, key[matchlen:], value)}
+		}
+		// Otherwise branch out at the index where they differ.
+		branch := &fullNode{}
+		branch.Children[n.Key[matchlen]] = t.insert(nil, n.Key[matchlen+1:], n.Val)
+		branch.Children[key[matchlen]] = t.insert(nil, key[matchlen+1:], value)
+		// Replace this shortNode with the branch if it occurs at index 0.
+		if matchlen == 0 {
+			return branch
+		}
+		// Otherwise, replace it with a short node leading up to the branch.
+		return &shortNode{key[:matchlen], branch}
+
+	case *fullNode:
+		n = n.copy()
+		n.Children[key[0]] = t.insert(n.Children[key[0]], key[1:], value)
+		return n
+
+	case nil:
+		return &shortNode{key, value}
+
+	default:
+		panic("unexpected node type in memory trie")
+	}
+}
+
+// delete returns the new root of the trie with key deleted.
+// It reduces the trie to minimal form by simplifying
+// nodes on the way up after deleting recursively.
+func (t *Trie) delete(n node, key []byte) node {
+	switch n := n.(type) {
+	case *shortNode:
+		matchlen := prefixLen(key, n.Key)
+		if matchlen < len(n.Key) {
+			return n // don't replace n on mismatch
+		}
+		if matchlen == len(key) {
+			return nil // remove n entirely for whole matches
+		}
+		// The key is longer than n.Key. Remove the remaining suffix
+		// from the subtrie. Child can never be nil here since the
+		// subtrie must contain at least two other values with keys
+		// longer than n.Key.
+		child := t.delete(n.Val, key[len(n.Key):])
+		switch child := child.(type) {
+		case *shortNode:
+			// Deleting from the subtrie reduced it to another
+			// short node. Merge the nodes to avoid creating a
+			// shortNode{..., shortNode{...}}.
+			return &shortNode{concat(n.Key, child.Key...), child.Val}
+		default:
+			return &shortNode{n.Key, child}
+		}
+
+	case *fullNode:
+		n = n.copy()
+		n.Children[key[0]] = t.delete(n.Children[key[0]], key[1:])
+		// Check how many non-nil entries are left after deleting and
+		// reduce the full node to a short node if only one entry is
+		// left.
+		pos := -1
+		for i, cld := range &n.Children {
+			if cld != nil {
+				if pos == -1 {
+					pos = i
+				} else {
+					pos = -2
+					break
+				}
+			}
+		}
+		if pos >= 0 {
+			if pos != 16 {
+				// If the remaining entry is a short node, it replaces
+				// n and its key gets the missing nibble tacked to the
+				// front.
+				if cnode, ok := n.Children[pos].(*shortNode); ok {
+					k := append([]byte{byte(pos)}, cnode.Key...)
+					return &shortNode{k, cnode.Val}
+				}
+			}
+			// Otherwise, n is replaced by a one-nibble short node
+			// containing the child.
+			return &shortNode{[]byte{byte(pos)}, n.Children[pos]}
+		}
+		// n still contains at least two values and cannot be reduced.
+		return n
+
+	case valueNode:
+		return nil
+
+	case nil:
+		return nil
+
+	default:
+		panic("unexpected node type in memory trie")
+	}
+}
+
+func concat(s1 []byte, s2 ...byte) []byte {
+	r := make([]byte, len(s1)+len(s2))
+	copy(r, s1)
+	copy(r[len(s1):], s2)
+	return r
+}
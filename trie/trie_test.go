@@ -0,0 +1,80 @@
+// Copyright 2015 The go-simplechain Authors
+// This file is part of the go-simplechain library.
+//
+// The go-simplechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-simplechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-simplechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethclient/common"
+)
+
+func TestEmptyTrie(t *testing.T) {
+	if root := New().Hash(); root != EmptyRoot {
+		t.Errorf("empty root: got %x, want %x", root, EmptyRoot)
+	}
+}
+
+func TestInsertGetDelete(t *testing.T) {
+	trie := New()
+	trie.Update([]byte("doe"), []byte("reindeer"))
+	trie.Update([]byte("dog"), []byte("puppy"))
+	trie.Update([]byte("dogglesworth"), []byte("cat"))
+
+	exp := common.HexToHash("8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3")
+	if root := trie.Hash(); root != exp {
+		t.Errorf("case 1: exp %x got %x", exp, root)
+	}
+	if v := trie.Get([]byte("dog")); string(v) != "puppy" {
+		t.Errorf("get dog: got %q", v)
+	}
+	if v := trie.Get([]byte("do")); v != nil {
+		t.Errorf("get do: got %q", v)
+	}
+
+	trie = New()
+	trie.Update([]byte("A"), []byte(strings.Repeat("a", 76)))
+	exp = common.HexToHash("f48c286ccf6e75aee0a63ef116576d54bbc2e85de4d23561e71e896575dedc63")
+	if root := trie.Hash(); root != exp {
+		t.Errorf("case 2: exp %x got %x", exp, root)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	trie := New()
+	vals := []struct{ k, v string }{
+		{"do", "verb"},
+		{"ether", "wookiedoo"},
+		{"horse", "stallion"},
+		{"shaman", "horse"},
+		{"doge", "coin"},
+		{"ether", ""},
+		{"dog", "puppy"},
+		{"shaman", ""},
+	}
+	for _, val := range vals {
+		if val.v != "" {
+			trie.Update([]byte(val.k), []byte(val.v))
+		} else {
+			trie.Delete([]byte(val.k))
+		}
+	}
+	exp := common.HexToHash("5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84")
+	if hash := trie.Hash(); hash != exp {
+		t.Errorf("expected %x got %x", exp, hash)
+	}
+}